@@ -2,21 +2,30 @@ package main
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
+	"time"
 
 	_ "github.com/lib/pq"
 	log "github.com/sirupsen/logrus"
 	"github.com/xopxe23/news-server/internal/config"
+	"github.com/xopxe23/news-server/internal/domain"
 	"github.com/xopxe23/news-server/internal/repository"
 	"github.com/xopxe23/news-server/internal/service"
 	grpc_client "github.com/xopxe23/news-server/internal/transport/grpc"
+	"github.com/xopxe23/news-server/internal/transport/graphql"
+	"github.com/xopxe23/news-server/internal/transport/grpc_server"
 	"github.com/xopxe23/news-server/internal/transport/rest"
 	"github.com/xopxe23/news-server/pkg/database"
 	hasher "github.com/xopxe23/news-server/pkg/hash"
+	"github.com/xopxe23/news-server/pkg/oidc"
+	"github.com/xopxe23/news-server/pkg/storage"
 )
 
 const (
@@ -34,17 +43,20 @@ func init() {
 // @version 1.0
 // @description Sample Server for News App
 // @host localhost:8000
-// @basepath /
+// @basepath /v1
 // @securityDefinitions.apikey BearerAuth
 // @in header
 // @name Authorization
+// @securityDefinitions.apikey APIKeyAuth
+// @in header
+// @name X-API-Key
 
 func main() {
 	cfg, err := config.New(CONFIG_DIR, CONFIG_FILE)
 	if err != nil {
 		log.Fatal(err)
 	}
-	log.Printf("config: %+v\n", cfg)
+	log.Printf("config: %+v\n", cfg.Redacted())
 
 	db, err := database.NewPostgresConnection(database.ConnectionInfo{
 		Host:     cfg.DB.Host,
@@ -60,20 +72,139 @@ func main() {
 
 	hasher := hasher.NewSHA1Hasher("salt")
 
+	transactor := repository.NewTransactor(db)
+	outboxRepos := repository.NewOutboxRepository(db)
+
 	usersRepos := repository.NewUsersRepository(db)
 	tokensRepos := repository.NewTokensRepository(db)
-	
-	auditClient, err := grpc_client.NewClient(9000)
-	if err != nil {
-		log.Fatal(err)
+
+	auditSink, closeAudit := newAuditSink(cfg.Audit)
+
+	signInGuard := service.NewSignInGuard(repository.NewLoginFailuresRepository(db), service.SignInGuardConfig{
+		MaxFailures:   cfg.SignIn.MaxFailures,
+		IPMaxFailures: cfg.SignIn.IPMaxFailures,
+		Window:        cfg.SignIn.FailureWindow,
+		Lockout:       cfg.SignIn.Lockout,
+		BaseDelay:     cfg.SignIn.BaseDelay,
+		MaxDelay:      cfg.SignIn.MaxDelay,
+	})
+
+	identitiesRepos := repository.NewIdentitiesRepository(db)
+	apiKeysRepos := repository.NewAPIKeysRepository(db)
+	oauthRepos := repository.NewOAuthRepository(db)
+
+	twoFactor := service.NewTwoFactor(repository.NewTwoFactorRepository(db), transactor, hasher, cfg.TwoFactor.Issuer)
+
+	var oidcLogin *service.OIDC
+	if cfg.OIDC.Enabled {
+		provider := oidc.NewProvider(oidc.Config{
+			Issuer:       cfg.OIDC.Issuer,
+			ClientID:     cfg.OIDC.ClientID,
+			ClientSecret: cfg.OIDC.ClientSecret,
+			RedirectURL:  cfg.OIDC.RedirectURL,
+			Scopes:       cfg.OIDC.Scopes,
+		}, &http.Client{Timeout: cfg.OIDC.Timeout})
+		oidcLogin = service.NewOIDC(provider, identitiesRepos)
 	}
-	usersService := service.NewUsersService(usersRepos, auditClient, hasher, tokensRepos, []byte("sample secret"))
+
+	secret := []byte("sample secret")
+
+	users := service.NewUsersService(usersRepos, transactor, outboxRepos, hasher, tokensRepos, signInGuard, twoFactor,
+		apiKeysRepos, oidcLogin, repository.NewImpersonationsRepository(db), secret)
+	usersService := service.NewAuditedUsersService(users, auditSink)
+	bootstrapAdmin(usersService, cfg.Admin.BootstrapEmail)
+
+	privacy := service.NewPrivacyService(repository.NewExportsRepository(db), service.UserData{
+		Users:      usersRepos,
+		Sessions:   tokensRepos,
+		APIKeys:    apiKeysRepos,
+		OAuth:      oauthRepos,
+		Identities: identitiesRepos,
+	}, outboxRepos, transactor, users, signInGuard, service.PrivacyConfig{
+		PollInterval:  cfg.Privacy.PollInterval,
+		BatchSize:     cfg.Privacy.BatchSize,
+		ExportTTL:     cfg.Privacy.ExportTTL,
+		DeletionGrace: cfg.Privacy.DeletionGrace,
+	})
+	privacyService := service.NewAuditedPrivacyService(privacy, auditSink)
 
 	authorsRepos := repository.NewAuthorsRepository(db)
 	articlesRepos := repository.NewArticlesRepository(db)
-	articlesService := service.NewArticlesService(authorsRepos, articlesRepos)
+	articles := service.NewArticlesService(authorsRepos, articlesRepos, transactor, outboxRepos)
+	articlesService := service.NewAuditedArticlesService(articles, auditSink)
+
+	mediaService := service.NewAuditedMediaService(
+		service.NewMediaService(repository.NewMediaRepository(db), newFileStorage(cfg.Media), service.MediaConfig{
+			MaxSize:       cfg.Media.MaxSize,
+			MaxPixels:     cfg.Media.MaxPixels,
+			ThumbnailSize: cfg.Media.ThumbnailSize,
+		}),
+		auditSink,
+	)
+
+	webhooksRepos := repository.NewWebhooksRepository(db)
+	webhooks := service.NewWebhooksService(webhooksRepos)
+	webhooksService := service.NewAuditedWebhooksService(webhooks, auditSink)
+
+	bus := service.NewEventBus()
+	bus.Subscribe("audit", service.NewAuditHandler(auditSink), domain.EventUserRegistered, domain.EventUserLocked,
+		domain.EventUserEmailChanged, domain.EventUserPasswordReset, domain.EventUserDeleted)
+	bus.Subscribe("webhooks", webhooks.HandleEvent, domain.WebhookEvents...)
+	bus.Subscribe("mail", service.NewMailHandler(service.NewLogMailer(), secret), domain.EventUserEmailChangeRequested,
+		domain.EventUserPasswordResetRequested)
+
+	relay := service.NewOutboxRelay(outboxRepos, bus, service.RelayConfig{
+		PollInterval: cfg.Outbox.PollInterval,
+		BatchSize:    cfg.Outbox.BatchSize,
+		Lease:        cfg.Outbox.Lease,
+		MaxBackoff:   cfg.Outbox.MaxBackoff,
+	})
+	dispatcher := service.NewWebhookDispatcher(webhooksRepos, service.DispatcherConfig{
+		PollInterval: cfg.Webhooks.PollInterval,
+		Timeout:      cfg.Webhooks.Timeout,
+		BatchSize:    cfg.Webhooks.BatchSize,
+		MaxAttempts:  cfg.Webhooks.MaxAttempts,
+		BaseBackoff:  cfg.Webhooks.BaseBackoff,
+		MaxBackoff:   cfg.Webhooks.MaxBackoff,
+	})
+
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+	for _, worker := range []func(context.Context){relay.Run, dispatcher.Run, privacy.Run, articles.BackfillMetadata} {
+		workers.Add(1)
+		go func(run func(context.Context)) {
+			defer workers.Done()
+			run(workersCtx)
+		}(worker)
+	}
+
+	graphqlHandler := graphql.NewHandler(articlesService, usersService, graphql.Config{
+		MaxDepth:      cfg.GraphQL.MaxDepth,
+		MaxComplexity: cfg.GraphQL.MaxComplexity,
+	})
 
-	handler := rest.NewHandler(usersService, articlesService)
+	var legacySunset time.Time
+	if cfg.Server.LegacySunset != "" {
+		legacySunset, err = time.Parse("2006-01-02", cfg.Server.LegacySunset)
+		if err != nil {
+			log.Fatalf("invalid server.legacy_sunset: %s", err.Error())
+		}
+	}
+	oauthService := service.NewAuditedOAuthService(
+		service.NewOAuthService(oauthRepos, service.OAuthConfig{
+			AccessTokenTTL: cfg.OAuth.AccessTokenTTL,
+			CodeTTL:        cfg.OAuth.CodeTTL,
+		}),
+		auditSink,
+	)
+
+	handler := rest.NewHandler(usersService, articlesService, webhooksService, oauthService, privacyService, mediaService, graphqlHandler, rest.Config{
+		LegacySunset:   legacySunset,
+		TrustedProxies: cfg.Server.TrustedProxies,
+		RateLimit:      newRateLimitConfig(cfg.RateLimit, db),
+		Audit:          auditSink,
+		MaxUploadSize:  cfg.Media.MaxSize,
+	})
 
 	// init & run server
 	srv := &http.Server{
@@ -86,6 +217,18 @@ func main() {
 			log.Fatal(err)
 		}
 	}()
+
+	grpcSrv := grpc_server.NewServer(articlesService, usersService)
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.GRPC.Port))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	go func() {
+		if err := grpcSrv.Serve(lis); err != nil {
+			log.Fatal(err)
+		}
+	}()
 	log.Info("SERVER STARTED")
 
 	quit := make(chan os.Signal, 1)
@@ -97,8 +240,148 @@ func main() {
 	if err := srv.Shutdown(context.Background()); err != nil {
 		log.Errorf("error on server shutting: %s", err.Error())
 	}
+	grpcSrv.GracefulStop()
+
+	stopWorkers()
+	workers.Wait()
+
+	closeAudit()
 
 	if err := db.Close(); err != nil {
 		log.Errorf("error on db closing: %s", err.Error())
 	}
 }
+
+// bootstrapAdmin makes the user with the email an admin. The user has to sign up first, until then
+// the failure is only logged so the server still starts.
+func bootstrapAdmin(users service.Users, email string) {
+	if email == "" {
+		return
+	}
+
+	userId, changed, err := users.BootstrapAdmin(context.Background(), email)
+	if err != nil {
+		log.Errorf("failed to bootstrap admin %s: %s", email, err.Error())
+		return
+	}
+	if changed {
+		log.Infof("user %d is made an admin", userId)
+	}
+}
+
+// newFileStorage returns the storage uploaded media is kept in.
+func newFileStorage(cfg config.Media) service.FileStorage {
+	switch cfg.Storage {
+	case "local":
+		local, err := storage.NewLocal(cfg.Dir)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return local
+	case "s3":
+		s3, err := storage.NewS3(storage.S3Config{
+			Endpoint:  cfg.S3.Endpoint,
+			Region:    cfg.S3.Region,
+			Bucket:    cfg.S3.Bucket,
+			AccessKey: cfg.S3.AccessKey,
+			SecretKey: cfg.S3.SecretKey,
+			PathStyle: cfg.S3.PathStyle,
+		}, &http.Client{Timeout: cfg.S3.Timeout})
+		if err != nil {
+			log.Fatal(err)
+		}
+		return s3
+	default:
+		log.Fatalf("unknown media storage %q", cfg.Storage)
+		return nil
+	}
+}
+
+// newRateLimitConfig builds the rate limit policies with the store sharing the buckets.
+func newRateLimitConfig(cfg config.RateLimit, db *sql.DB) rest.RateLimitConfig {
+	if !cfg.Enabled {
+		log.Warn("rate limiting is disabled")
+		return rest.RateLimitConfig{}
+	}
+
+	policies := make(map[string]domain.RateLimit, len(cfg.Policies))
+	for name, p := range cfg.Policies {
+		if p.Limit <= 0 || p.Period <= 0 {
+			log.Fatalf("rate limit policy %q must have positive limit and period", name)
+		}
+		policies[name] = domain.RateLimit{Limit: p.Limit, Period: p.Period, Burst: p.Burst}
+	}
+
+	var store rest.RateLimitStore
+	switch cfg.Store {
+	case "memory":
+		store = repository.NewMemoryRateLimits()
+	case "postgres":
+		store = repository.NewRateLimitsRepository(db)
+	default:
+		log.Fatalf("unknown rate limit store %q", cfg.Store)
+	}
+
+	return rest.RateLimitConfig{
+		Store:    store,
+		Policies: policies,
+	}
+}
+
+// newAuditSink builds the audit sink and returns it together with the function draining it on shutdown.
+// With the audit service disabled records only go to the application log.
+func newAuditSink(cfg config.Audit) (service.AuditSink, func()) {
+	logSink := service.NewLogAuditSink()
+	if !cfg.Enabled {
+		log.Warn("audit service is disabled, audit records are only logged")
+		return logSink, func() {}
+	}
+
+	auditClient, err := grpc_client.NewClient(grpc_client.ClientConfig{
+		Address:    cfg.Address,
+		Timeout:    cfg.CallTimeout,
+		CAFile:     cfg.TLS.CAFile,
+		CertFile:   cfg.TLS.CertFile,
+		KeyFile:    cfg.TLS.KeyFile,
+		ServerName: cfg.TLS.ServerName,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ConnectTimeout)
+	defer cancel()
+	if err := auditClient.CheckConnection(ctx); err != nil {
+		// not fatal: the dispatcher spools records until the service comes up
+		log.Warnf("audit health check failed: %s", err.Error())
+	}
+
+	auditDispatcher, err := grpc_client.NewDispatcher(auditClient, grpc_client.DispatcherConfig{
+		QueueSize:        cfg.QueueSize,
+		BatchSize:        cfg.BatchSize,
+		FlushInterval:    cfg.FlushInterval,
+		MaxRetries:       cfg.MaxRetries,
+		BaseBackoff:      cfg.BaseBackoff,
+		MaxBackoff:       cfg.MaxBackoff,
+		BreakerThreshold: cfg.BreakerThreshold,
+		BreakerCooldown:  cfg.BreakerCooldown,
+		SpoolPath:        cfg.SpoolPath,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	closeAudit := func() {
+		drainCtx, cancel := context.WithTimeout(context.Background(), cfg.DrainTimeout)
+		defer cancel()
+		if err := auditDispatcher.Close(drainCtx); err != nil {
+			log.Errorf("error on audit draining: %s", err.Error())
+		}
+
+		if err := auditClient.CloseConnection(); err != nil {
+			log.Errorf("error on audit connection closing: %s", err.Error())
+		}
+	}
+
+	return service.NewMultiSink(logSink, service.NewAuditClientSink(auditDispatcher)), closeAudit
+}
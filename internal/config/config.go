@@ -1,6 +1,8 @@
 package config
 
 import (
+	"time"
+
 	"github.com/kelseyhightower/envconfig"
 	"github.com/spf13/viper"
 )
@@ -10,7 +12,159 @@ type Config struct {
 
 	Server struct {
 		Port int `mapstructure:"port"`
+		// LegacySunset is the date (YYYY-MM-DD) unprefixed API routes are removed.
+		LegacySunset string `mapstructure:"legacy_sunset"`
+		// TrustedProxies is the number of proxies in front of the server appending to X-Forwarded-For.
+		// Zero ignores the header.
+		TrustedProxies int `mapstructure:"trusted_proxies"`
 	} `mapstructure:"server"`
+
+	GRPC struct {
+		Port int `mapstructure:"port"`
+	} `mapstructure:"grpc"`
+
+	GraphQL struct {
+		MaxDepth      int `mapstructure:"max_depth"`
+		MaxComplexity int `mapstructure:"max_complexity"`
+	} `mapstructure:"graphql"`
+
+	Webhooks Webhooks `mapstructure:"webhooks"`
+	Outbox   Outbox   `mapstructure:"outbox"`
+	Audit    Audit    `mapstructure:"audit"`
+
+	RateLimit RateLimit `mapstructure:"rate_limit"`
+	SignIn    SignIn    `mapstructure:"sign_in"`
+
+	TwoFactor struct {
+		// Issuer is the name authenticator apps show next to the codes.
+		Issuer string `mapstructure:"issuer"`
+	} `mapstructure:"two_factor"`
+
+	OIDC OIDC `mapstructure:"oidc"`
+
+	// OAuth configures the authorization server for third-party apps.
+	OAuth struct {
+		AccessTokenTTL time.Duration `mapstructure:"access_token_ttl"`
+		CodeTTL        time.Duration `mapstructure:"code_ttl"`
+	} `mapstructure:"oauth"`
+
+	// Privacy configures data exports and account deletion.
+	Privacy struct {
+		PollInterval  time.Duration `mapstructure:"poll_interval"`
+		BatchSize     int           `mapstructure:"batch_size"`
+		ExportTTL     time.Duration `mapstructure:"export_ttl"`
+		DeletionGrace time.Duration `mapstructure:"deletion_grace"`
+	} `mapstructure:"privacy"`
+
+	Media Media `mapstructure:"media"`
+
+	Admin Admin `mapstructure:"admin"`
+}
+
+// Admin bootstraps the first administrator, later ones are appointed through the admin API.
+type Admin struct {
+	// BootstrapEmail is the email of the registered user made an admin on startup, empty disables it.
+	BootstrapEmail string `mapstructure:"bootstrap_email" split_words:"true"`
+}
+
+// Media configures uploads. The S3 secret key is taken from MEDIA_S3_SECRET_KEY.
+type Media struct {
+	// Storage is "local" to keep files in Dir or "s3" to keep them in a bucket.
+	Storage string `mapstructure:"storage"`
+	Dir     string `mapstructure:"dir"`
+	// MaxSize is the largest accepted file in bytes.
+	MaxSize int64 `mapstructure:"max_size" split_words:"true"`
+	// MaxPixels limits width×height of uploaded images.
+	MaxPixels     int     `mapstructure:"max_pixels" split_words:"true"`
+	ThumbnailSize int     `mapstructure:"thumbnail_size" split_words:"true"`
+	S3            MediaS3 `mapstructure:"s3"`
+}
+
+type MediaS3 struct {
+	Endpoint  string        `mapstructure:"endpoint"`
+	Region    string        `mapstructure:"region"`
+	Bucket    string        `mapstructure:"bucket"`
+	AccessKey string        `mapstructure:"access_key" split_words:"true"`
+	SecretKey string        `mapstructure:"secret_key" split_words:"true"`
+	PathStyle bool          `mapstructure:"path_style" split_words:"true"`
+	Timeout   time.Duration `mapstructure:"timeout"`
+}
+
+// OIDC configures sign-in with an OpenID Connect identity provider. The client secret is
+// taken from OIDC_CLIENT_SECRET.
+type OIDC struct {
+	Enabled      bool          `mapstructure:"enabled"`
+	Issuer       string        `mapstructure:"issuer"`
+	ClientID     string        `mapstructure:"client_id" split_words:"true"`
+	ClientSecret string        `mapstructure:"client_secret" split_words:"true"`
+	RedirectURL  string        `mapstructure:"redirect_url" split_words:"true"`
+	Scopes       []string      `mapstructure:"scopes"`
+	Timeout      time.Duration `mapstructure:"timeout"`
+}
+
+type SignIn struct {
+	MaxFailures   int           `mapstructure:"max_failures"`
+	IPMaxFailures int           `mapstructure:"ip_max_failures"`
+	FailureWindow time.Duration `mapstructure:"failure_window"`
+	Lockout       time.Duration `mapstructure:"lockout"`
+	BaseDelay     time.Duration `mapstructure:"base_delay"`
+	MaxDelay      time.Duration `mapstructure:"max_delay"`
+}
+
+type RateLimit struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Store is "memory" for a single instance or "postgres" to share limits between instances.
+	Store    string                     `mapstructure:"store"`
+	Policies map[string]RateLimitPolicy `mapstructure:"policies"`
+}
+
+// RateLimitPolicy allows Limit requests per Period with bursts of up to Burst requests.
+type RateLimitPolicy struct {
+	Limit  int           `mapstructure:"limit"`
+	Period time.Duration `mapstructure:"period"`
+	Burst  int           `mapstructure:"burst"`
+}
+
+type Audit struct {
+	Enabled        bool          `mapstructure:"enabled"`
+	Address        string        `mapstructure:"address"`
+	CallTimeout    time.Duration `mapstructure:"call_timeout" split_words:"true"`
+	ConnectTimeout time.Duration `mapstructure:"connect_timeout" split_words:"true"`
+	TLS            AuditTLS      `mapstructure:"tls"`
+
+	QueueSize        int           `mapstructure:"queue_size"`
+	BatchSize        int           `mapstructure:"batch_size"`
+	FlushInterval    time.Duration `mapstructure:"flush_interval"`
+	MaxRetries       int           `mapstructure:"max_retries"`
+	BaseBackoff      time.Duration `mapstructure:"base_backoff"`
+	MaxBackoff       time.Duration `mapstructure:"max_backoff"`
+	BreakerThreshold int           `mapstructure:"breaker_threshold"`
+	BreakerCooldown  time.Duration `mapstructure:"breaker_cooldown"`
+	SpoolPath        string        `mapstructure:"spool_path"`
+	DrainTimeout     time.Duration `mapstructure:"drain_timeout"`
+}
+
+type AuditTLS struct {
+	CAFile     string `mapstructure:"ca_file" split_words:"true"`
+	CertFile   string `mapstructure:"cert_file" split_words:"true"`
+	KeyFile    string `mapstructure:"key_file" split_words:"true"`
+	ServerName string `mapstructure:"server_name" split_words:"true"`
+}
+
+type Outbox struct {
+	PollInterval time.Duration `mapstructure:"poll_interval"`
+	BatchSize    int           `mapstructure:"batch_size"`
+	Lease        time.Duration `mapstructure:"lease"`
+	MaxBackoff   time.Duration `mapstructure:"max_backoff"`
+}
+
+type Webhooks struct {
+	PollInterval time.Duration `mapstructure:"poll_interval"`
+	Timeout      time.Duration `mapstructure:"timeout"`
+	BatchSize    int           `mapstructure:"batch_size"`
+	MaxAttempts  int           `mapstructure:"max_attempts"`
+	BaseBackoff  time.Duration `mapstructure:"base_backoff"`
+	MaxBackoff   time.Duration `mapstructure:"max_backoff"`
 }
 
 type Postgres struct {
@@ -22,6 +176,18 @@ type Postgres struct {
 	Password string
 }
 
+const redacted = "[REDACTED]"
+
+// Redacted returns a copy of the config with the secrets masked, so it can be logged.
+func (c Config) Redacted() Config {
+	for _, secret := range []*string{&c.DB.Password, &c.OIDC.ClientSecret, &c.Media.S3.SecretKey} {
+		if *secret != "" {
+			*secret = redacted
+		}
+	}
+	return c
+}
+
 func New(folder, filename string) (*Config, error) {
 	cfg := new(Config)
 
@@ -39,5 +205,21 @@ func New(folder, filename string) (*Config, error) {
 	if err := envconfig.Process("db", &cfg.DB); err != nil {
 		return nil, err
 	}
+
+	if err := envconfig.Process("audit", &cfg.Audit); err != nil {
+		return nil, err
+	}
+
+	if err := envconfig.Process("oidc", &cfg.OIDC); err != nil {
+		return nil, err
+	}
+
+	if err := envconfig.Process("media", &cfg.Media); err != nil {
+		return nil, err
+	}
+
+	if err := envconfig.Process("admin", &cfg.Admin); err != nil {
+		return nil, err
+	}
 	return cfg, nil
 }
@@ -0,0 +1,67 @@
+package domain
+
+import "time"
+
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+// UserFilter selects users for administrators. Query is matched against names and emails.
+type UserFilter struct {
+	Query    string `json:"q" validate:"lte=255"`
+	Role     string `json:"role" validate:"omitempty,oneof=reader admin"`
+	Disabled *bool  `json:"disabled"`
+	Limit    int    `json:"limit" validate:"gte=1,lte=100"`
+	Offset   int    `json:"offset" validate:"gte=0"`
+}
+
+func (f UserFilter) Validate() error {
+	return validationError(validate.Struct(f))
+}
+
+// AdminUser is a user as administrators see them.
+type AdminUser struct {
+	Id                  int        `json:"id"`
+	Name                string     `json:"name"`
+	Email               string     `json:"email"`
+	Role                string     `json:"role"`
+	DisabledAt          *time.Time `json:"disabled_at,omitempty"`
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at,omitempty"`
+}
+
+// UsersPage is one page of users and the number of users matching the filter.
+type UsersPage struct {
+	Users  []AdminUser `json:"users"`
+	Total  int         `json:"total"`
+	Limit  int         `json:"limit"`
+	Offset int         `json:"offset"`
+}
+
+type AdminUserDetails struct {
+	AdminUser
+	BookmarksCount int       `json:"bookmarks_count"`
+	Sessions       []Session `json:"sessions"`
+}
+
+// AccountStatus decides whether credentials of a user are still accepted.
+type AccountStatus struct {
+	DisabledAt *time.Time
+	// SessionsRevokedAt invalidates access tokens issued before it.
+	SessionsRevokedAt *time.Time
+}
+
+type ResetPasswordInput struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,gte=6"`
+}
+
+func (i ResetPasswordInput) Validate() error {
+	return validationError(validate.Struct(i))
+}
+
+// PasswordReset is the user whose password was reset with a token.
+type PasswordReset struct {
+	UserId int
+	Email  string
+}
@@ -0,0 +1,45 @@
+package domain
+
+import "time"
+
+type APIKey struct {
+	Id     int    `json:"id"`
+	UserId int    `json:"-"`
+	Name   string `json:"name"`
+	// Prefix is the beginning of the key, it helps to tell keys apart.
+	Prefix     string     `json:"prefix"`
+	Scopes     Scopes     `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func (k APIKey) Expired(now time.Time) bool {
+	return k.ExpiresAt != nil && !now.Before(*k.ExpiresAt)
+}
+
+type CreateAPIKeyInput struct {
+	Name      string     `json:"name" validate:"required,max=64"`
+	Scopes    []string   `json:"scopes" validate:"required,min=1,dive,oneof=read write admin"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+func (i CreateAPIKeyInput) Validate() error {
+	if err := validationError(validate.Struct(i)); err != nil {
+		return err
+	}
+	if i.ExpiresAt != nil && !i.ExpiresAt.After(time.Now()) {
+		return &Error{
+			Kind:    ErrValidation,
+			Message: "request has invalid fields",
+			Fields:  []FieldError{{Field: "expires_at", Message: "must be in the future"}},
+		}
+	}
+	return nil
+}
+
+// CreatedAPIKey is returned once on creation, the key itself isn't stored.
+type CreatedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
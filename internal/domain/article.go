@@ -1,39 +1,103 @@
 package domain
 
-import "time"
+import (
+	"encoding/xml"
+	"time"
+)
+
+// Formats of article content. Content of both is rendered to HTML which is safe to embed as is.
+const (
+	ContentPlain    = "plain"
+	ContentMarkdown = "markdown"
+)
+
+// ValidContentFormat tells if the format is one of the supported ones.
+func ValidContentFormat(format string) bool {
+	return format == ContentPlain || format == ContentMarkdown
+}
 
 type Article struct {
-	Id        int       `json:"id"`
-	AuthorId  int       `json:"author_id"`
-	Title     string    `json:"title" validate:"required,gte=10"`
-	Content   string    `json:"content" validate:"required,gte=20"`
-	CreatedAt time.Time `json:"created_at"`
+	XMLName  xml.Name `json:"-" xml:"article"`
+	Id       int      `json:"id" xml:"id"`
+	AuthorId int      `json:"author_id" xml:"author_id"`
+	Title    string   `json:"title" xml:"title" validate:"required,gte=10"`
+	Content  string   `json:"content" xml:"content" validate:"required,gte=20"`
+	// ContentFormat is plain unless set.
+	ContentFormat string          `json:"content_format" xml:"content_format" validate:"omitempty,oneof=plain markdown"`
+	ContentHTML   string          `json:"-" xml:"-"`
+	Metadata      ArticleMetadata `json:"-" xml:"-"`
+	CreatedAt     time.Time       `json:"created_at" xml:"created_at"`
 }
 
 type Author struct {
-	Id      int    `json:"id"`
-	Name    string `json:"name"`
-	Surname string `json:"surname"`
+	XMLName xml.Name `json:"-" xml:"author"`
+	Id      int      `json:"id" xml:"id"`
+	Name    string   `json:"name" xml:"name"`
+	Surname string   `json:"surname" xml:"surname"`
 }
 
 type UpdateAuthorInput struct {
-	Name    *string `json:"name"`
-	Surname *string `json:"surname"`
+	XMLName xml.Name `json:"-" xml:"author"`
+	Name    *string  `json:"name" xml:"name"`
+	Surname *string  `json:"surname" xml:"surname"`
 }
 
 type UpdateArticleInput struct {
-	Title     *string    `json:"title" validate:"required,gte=10"`
-	Content   *string    `json:"content" validate:"required,gte=20"`
+	XMLName       xml.Name `json:"-" xml:"article"`
+	Title         *string  `json:"title" xml:"title" validate:"required,gte=10"`
+	Content       *string  `json:"content" xml:"content" validate:"required,gte=20"`
+	ContentFormat *string  `json:"content_format" xml:"content_format"`
+	// ContentHTML and Metadata are computed by the service whenever the content or its format change.
+	ContentHTML *string          `json:"-" xml:"-"`
+	Metadata    *ArticleMetadata `json:"-" xml:"-"`
 }
 
+// ArticleOutput is an article as clients see it. Lists can ask for some of the fields only, see ArticleQuery,
+// the rest are left zero.
 type ArticleOutput struct {
-	Id        int       `json:"id"`
-	Author    string    `json:"author"`
-	Title     string    `json:"title"`
-	Content   string    `json:"content"`
-	CreatedAt time.Time `json:"created_at"`
+	XMLName  xml.Name `json:"-" xml:"article"`
+	Id       int      `json:"id" xml:"id"`
+	AuthorId int      `json:"author_id" xml:"author_id"`
+	// Author is the name and the surname of the author.
+	Author string `json:"author" xml:"author"`
+	Title  string `json:"title" xml:"title"`
+	// Content and ContentHTML are left out of summaries.
+	Content       string `json:"content,omitempty" xml:"content,omitempty"`
+	ContentFormat string `json:"content_format" xml:"content_format"`
+	// ContentHTML is the content rendered and sanitized, ready to embed into pages.
+	ContentHTML string `json:"content_html,omitempty" xml:"content_html,omitempty"`
+	ArticleMetadata
+	CreatedAt time.Time        `json:"created_at" xml:"created_at"`
+	Embedded  *ArticleEmbedded `json:"embedded,omitempty" xml:"embedded,omitempty"`
+}
+
+// ArticleEmbedded holds the related resources asked for with ArticleQuery.
+type ArticleEmbedded struct {
+	Author *Author `json:"author,omitempty" xml:"author,omitempty"`
+}
+
+// ArticleMetadata is computed from the text of an article whenever its content changes.
+type ArticleMetadata struct {
+	Excerpt   string `json:"excerpt" xml:"excerpt"`
+	WordCount int    `json:"word_count" xml:"word_count"`
+	// ReadingTime is the estimated reading time in minutes.
+	ReadingTime int `json:"reading_time" xml:"reading_time"`
+}
+
+// ArticleQuery tells list endpoints what to return.
+type ArticleQuery struct {
+	// Summary leaves out the content for article cards, the excerpt and the metadata are enough for them.
+	Summary bool
+	// Fields are the JSON names of the fields to return, the id is always returned. Empty means all of them.
+	Fields []string `json:"fields" validate:"dive,oneof=id author_id author title content content_format content_html excerpt word_count reading_time created_at"`
+	// IncludeAuthor embeds the author.
+	IncludeAuthor bool
+}
+
+func (q ArticleQuery) Validate() error {
+	return validationError(validate.Struct(q))
 }
 
 func (a *Article) Validate() error {
-	return validate.Struct(a)
+	return validationError(validate.Struct(a))
 }
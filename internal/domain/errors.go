@@ -0,0 +1,113 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Kinds of errors the transports know how to report. Match them with errors.Is.
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrValidation   = errors.New("validation failed")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+
+	ErrTooManyRequests = errors.New("too many requests")
+
+	ErrTooLarge             = errors.New("too large")
+	ErrUnsupportedMediaType = errors.New("unsupported media type")
+)
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error is an error of a known kind with a message which is safe to show to the client.
+type Error struct {
+	Kind    error
+	Message string
+	Fields  []FieldError
+	Err     error
+	// RetryAfter tells when the client may try again, for ErrTooManyRequests.
+	RetryAfter time.Duration
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %s", e.Message, e.Err)
+	}
+	return e.Message
+}
+
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// NewError returns an error of the given kind. The cause is kept for logs only.
+func NewError(kind error, message string, cause error) error {
+	return &Error{Kind: kind, Message: message, Err: cause}
+}
+
+func NotFound(message string) error {
+	return &Error{Kind: ErrNotFound, Message: message}
+}
+
+func Conflict(message string) error {
+	return &Error{Kind: ErrConflict, Message: message}
+}
+
+func Unauthorized(message string) error {
+	return &Error{Kind: ErrUnauthorized, Message: message}
+}
+
+func Forbidden(message string) error {
+	return &Error{Kind: ErrForbidden, Message: message}
+}
+
+func TooManyRequests(message string, retryAfter time.Duration) error {
+	return &Error{Kind: ErrTooManyRequests, Message: message, RetryAfter: retryAfter}
+}
+
+func InvalidInput(message string, cause error) error {
+	return &Error{Kind: ErrValidation, Message: message, Err: cause}
+}
+
+// validationError turns the result of validate.Struct into a validation error with one entry per invalid field.
+func validationError(err error) error {
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return err
+	}
+
+	fields := make([]FieldError, 0, len(validationErrors))
+	for _, fe := range validationErrors {
+		fields = append(fields, FieldError{Field: fe.Field(), Message: fieldMessage(fe)})
+	}
+	return &Error{Kind: ErrValidation, Message: "request has invalid fields", Fields: fields, Err: err}
+}
+
+func fieldMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email"
+	case "url":
+		return "must be a valid URL"
+	case "gte":
+		return fmt.Sprintf("must be at least %s characters long", fe.Param())
+	case "oneof":
+		return fmt.Sprintf("must be one of: %s", fe.Param())
+	default:
+		return fmt.Sprintf("failed on the '%s' rule", fe.Tag())
+	}
+}
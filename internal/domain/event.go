@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+const (
+	EventArticleCreated = "article.created"
+	EventArticleUpdated = "article.updated"
+	EventArticleDeleted = "article.deleted"
+
+	EventAuthorCreated = "author.created"
+	EventAuthorUpdated = "author.updated"
+	EventAuthorDeleted = "author.deleted"
+
+	EventUserRegistered = "user.registered"
+	EventUserLocked     = "user.locked"
+	// EventUserEmailChangeRequested carries the confirmation token for the mailer, sealed with the application secret.
+	EventUserEmailChangeRequested = "user.email_change_requested"
+	EventUserEmailChanged         = "user.email_changed"
+	EventUserDeleted              = "user.deleted"
+	// EventUserPasswordResetRequested carries the reset token for the mailer, sealed with the application secret.
+	EventUserPasswordResetRequested = "user.password_reset_requested"
+	EventUserPasswordReset          = "user.password_reset"
+)
+
+// Event is a fact about a state change, stored in the outbox together with the change itself.
+type Event struct {
+	Id             int64           `json:"-"`
+	Type           string          `json:"type"`
+	AggregateId    int             `json:"aggregate_id"`
+	Payload        json.RawMessage `json:"payload"`
+	IdempotencyKey string          `json:"idempotency_key"`
+	Attempts       int             `json:"-"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+// NewEvent builds an event with a fresh idempotency key and data marshalled as its payload.
+func NewEvent(eventType string, aggregateId int, data interface{}) (Event, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return Event{}, err
+	}
+
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		return Event{}, err
+	}
+
+	return Event{
+		Type:           eventType,
+		AggregateId:    aggregateId,
+		Payload:        payload,
+		IdempotencyKey: hex.EncodeToString(key),
+		CreatedAt:      time.Now().UTC(),
+	}, nil
+}
@@ -0,0 +1,36 @@
+package domain
+
+import "time"
+
+// Impersonation is a support session in which an admin acts as another user.
+type Impersonation struct {
+	Id int
+	// AdminId is zero once the admin is deleted.
+	AdminId   int
+	UserId    int
+	ExpiresAt time.Time
+	EndedAt   *time.Time
+}
+
+// Active tells if the impersonation can still be used at now.
+func (i Impersonation) Active(now time.Time) bool {
+	return i.EndedAt == nil && now.Before(i.ExpiresAt)
+}
+
+// ImpersonationToken is a short-lived access token of the impersonated user.
+type ImpersonationToken struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Principal is who an access token speaks for. ImpersonatorId is set for tokens issued to an admin
+// impersonating the user.
+type Principal struct {
+	UserId          int
+	ImpersonatorId  int
+	ImpersonationId int
+}
+
+func (p Principal) Impersonated() bool {
+	return p.ImpersonatorId != 0
+}
@@ -0,0 +1,44 @@
+package domain
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+// Media is an uploaded image. The original and its thumbnail are served by the API, URL and ThumbnailURL
+// point to them.
+type Media struct {
+	XMLName      xml.Name  `json:"-" xml:"media"`
+	Id           int       `json:"id" xml:"id"`
+	OwnerId      int       `json:"-" xml:"-"`
+	ContentType  string    `json:"content_type" xml:"content_type"`
+	Size         int64     `json:"size" xml:"size"`
+	Width        int       `json:"width" xml:"width"`
+	Height       int       `json:"height" xml:"height"`
+	URL          string    `json:"url" xml:"url"`
+	ThumbnailURL string    `json:"thumbnail_url" xml:"thumbnail_url"`
+	CreatedAt    time.Time `json:"created_at" xml:"created_at"`
+
+	StorageKey   string `json:"-" xml:"-"`
+	ThumbnailKey string `json:"-" xml:"-"`
+}
+
+// ArticleMedia is media attached to an article, in the order of attaching.
+type ArticleMedia struct {
+	Media
+	Caption  string `json:"caption" xml:"caption"`
+	Credit   string `json:"credit" xml:"credit"`
+	Position int    `json:"position" xml:"position"`
+}
+
+// AttachMediaInput attaches media to an article. Attaching it again updates the caption and the credit.
+type AttachMediaInput struct {
+	XMLName xml.Name `json:"-" xml:"media"`
+	MediaId int      `json:"media_id" xml:"media_id" validate:"required"`
+	Caption string   `json:"caption" xml:"caption" validate:"lte=1000"`
+	Credit  string   `json:"credit" xml:"credit" validate:"lte=255"`
+}
+
+func (i AttachMediaInput) Validate() error {
+	return validationError(validate.Struct(i))
+}
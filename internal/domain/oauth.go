@@ -0,0 +1,142 @@
+package domain
+
+import "time"
+
+const (
+	GrantAuthorizationCode = "authorization_code"
+	GrantClientCredentials = "client_credentials"
+)
+
+// OAuthClient is a third-party application registered by a user. Confidential clients
+// authenticate with a secret, public ones (mobile and browser apps) can't keep one.
+type OAuthClient struct {
+	Id           int       `json:"-"`
+	ClientId     string    `json:"client_id"`
+	Name         string    `json:"name"`
+	RedirectURIs []string  `json:"redirect_uris"`
+	Scopes       Scopes    `json:"scopes"`
+	Confidential bool      `json:"confidential"`
+	OwnerId      int       `json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type OAuthClientInput struct {
+	Name         string   `json:"name" validate:"required,max=64"`
+	RedirectURIs []string `json:"redirect_uris" validate:"required,min=1,dive,url"`
+	// OAuth clients can't be given the admin scope.
+	Scopes       []string `json:"scopes" validate:"required,min=1,dive,oneof=read write"`
+	Confidential bool     `json:"confidential"`
+}
+
+func (i OAuthClientInput) Validate() error {
+	return validationError(validate.Struct(i))
+}
+
+// RegisteredOAuthClient is returned once on registration, the secret isn't stored.
+type RegisteredOAuthClient struct {
+	OAuthClient
+	ClientSecret string `json:"client_secret,omitempty"`
+}
+
+// AuthorizeRequest holds the parameters of the authorization endpoint. Only the code flow with
+// S256 PKCE is supported.
+type AuthorizeRequest struct {
+	ResponseType        string `json:"response_type" validate:"required,eq=code"`
+	ClientId            string `json:"client_id" validate:"required"`
+	RedirectURI         string `json:"redirect_uri" validate:"required"`
+	Scope               string `json:"scope"`
+	State               string `json:"state"`
+	CodeChallenge       string `json:"code_challenge" validate:"required,min=43,max=128"`
+	CodeChallengeMethod string `json:"code_challenge_method" validate:"required,eq=S256"`
+}
+
+func (i AuthorizeRequest) Validate() error {
+	return validationError(validate.Struct(i))
+}
+
+// ConsentInput is the answer of the user to the consent screen.
+type ConsentInput struct {
+	AuthorizeRequest
+	Approve bool `json:"approve"`
+}
+
+// Consent is what the consent screen shows the user.
+type Consent struct {
+	ClientId    string `json:"client_id"`
+	ClientName  string `json:"client_name"`
+	Scopes      Scopes `json:"scopes"`
+	RedirectURI string `json:"redirect_uri"`
+}
+
+type AuthorizationCode struct {
+	ClientId      int
+	UserId        int
+	RedirectURI   string
+	Scopes        Scopes
+	CodeChallenge string
+	ExpiresAt     time.Time
+}
+
+// TokenRequest holds the parameters of the token endpoint.
+type TokenRequest struct {
+	GrantType    string
+	Code         string
+	RedirectURI  string
+	CodeVerifier string
+	Scope        string
+	ClientId     string
+	ClientSecret string
+}
+
+// OAuthToken is an access token issued to a client. Tokens of the client credentials grant
+// act on behalf of the owner of the client.
+type OAuthToken struct {
+	Id        int
+	ClientId  int
+	UserId    int
+	Scopes    Scopes
+	ExpiresAt time.Time
+	RevokedAt *time.Time
+	CreatedAt time.Time
+}
+
+func (t OAuthToken) Active(now time.Time) bool {
+	return t.RevokedAt == nil && now.Before(t.ExpiresAt)
+}
+
+// IssuedToken is the response of the token endpoint.
+type IssuedToken struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+	Scope       string `json:"scope"`
+}
+
+// TokenIntrospection is the response of the introspection endpoint, see RFC 7662.
+type TokenIntrospection struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`
+	ClientId  string `json:"client_id,omitempty"`
+	Subject   string `json:"sub,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+	IssuedAt  int64  `json:"iat,omitempty"`
+}
+
+// OAuthError is an error of the token, revocation and introspection endpoints,
+// which answer in the format of RFC 6749 instead of problem details.
+type OAuthError struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+}
+
+func (e *OAuthError) Error() string {
+	if e.Description == "" {
+		return e.Code
+	}
+	return e.Code + ": " + e.Description
+}
+
+func NewOAuthError(code, description string) error {
+	return &OAuthError{Code: code, Description: description}
+}
@@ -0,0 +1,24 @@
+package domain
+
+// ExternalIdentity is a user as an OpenID Connect provider knows them.
+type ExternalIdentity struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// OIDCLogin is the start of a sign-in with the identity provider. The user is sent to URL,
+// StateToken has to come back with the callback.
+type OIDCLogin struct {
+	URL        string
+	StateToken string
+}
+
+// OIDCCallback is what the identity provider sends the user back with.
+type OIDCCallback struct {
+	Code       string
+	State      string
+	StateToken string
+}
@@ -0,0 +1,48 @@
+package domain
+
+import "time"
+
+const (
+	ExportPending = "pending"
+	ExportReady   = "ready"
+	ExportFailed  = "failed"
+)
+
+// DataExport is an archive of everything the service stores about a user. It is assembled in the background,
+// the archive can be downloaded once the status is ready and until it expires.
+type DataExport struct {
+	Id          int        `json:"id"`
+	UserId      int        `json:"-"`
+	Status      string     `json:"status"`
+	Error       string     `json:"error,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
+
+// DeleteAccountInput asks for the password, so a session left open can't be used to delete the account.
+type DeleteAccountInput struct {
+	Password string `json:"password" validate:"required"`
+}
+
+func (i DeleteAccountInput) Validate() error {
+	return validationError(validate.Struct(i))
+}
+
+// AccountDeletion tells when a scheduled deletion takes place. Until then it can be cancelled.
+type AccountDeletion struct {
+	ScheduledAt time.Time `json:"scheduled_at"`
+}
+
+// Session is a refresh session as shown to its owner, without the token.
+type Session struct {
+	Id        int       `json:"id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// LinkedIdentity is an external account linked to the user.
+type LinkedIdentity struct {
+	Issuer    string    `json:"issuer"`
+	Subject   string    `json:"subject"`
+	CreatedAt time.Time `json:"created_at"`
+}
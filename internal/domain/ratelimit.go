@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"math"
+	"time"
+)
+
+// RateLimit is a token bucket policy: Limit requests per Period on average, with bursts of up to Burst requests.
+type RateLimit struct {
+	Limit  int
+	Period time.Duration
+	Burst  int
+}
+
+// TokenBucket is the state of one client's bucket.
+type TokenBucket struct {
+	Tokens    float64
+	UpdatedAt time.Time
+}
+
+type RateLimitResult struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is the time until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is the time until the next request is allowed, zero if it is allowed now.
+	RetryAfter time.Duration
+}
+
+func (l RateLimit) capacity() float64 {
+	if l.Burst > 0 {
+		return float64(l.Burst)
+	}
+	return float64(l.Limit)
+}
+
+// perSecond is the refill rate of the bucket.
+func (l RateLimit) perSecond() float64 {
+	return float64(l.Limit) / l.Period.Seconds()
+}
+
+// NewBucket returns a full bucket.
+func (l RateLimit) NewBucket(now time.Time) TokenBucket {
+	return TokenBucket{Tokens: l.capacity(), UpdatedAt: now}
+}
+
+// Take refills the bucket for the time passed since its last update and takes a token from it if there is one.
+func (l RateLimit) Take(bucket TokenBucket, now time.Time) (TokenBucket, RateLimitResult) {
+	capacity, rate := l.capacity(), l.perSecond()
+
+	// a clock behind the one which updated the bucket last, e.g. of another instance, neither refills nor
+	// moves the update back, which would refill the same time twice
+	if elapsed := now.Sub(bucket.UpdatedAt).Seconds(); elapsed > 0 {
+		bucket.Tokens = math.Min(capacity, bucket.Tokens+elapsed*rate)
+		bucket.UpdatedAt = now
+	}
+
+	result := RateLimitResult{Limit: int(capacity)}
+	if bucket.Tokens >= 1 {
+		bucket.Tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = seconds((1 - bucket.Tokens) / rate)
+	}
+	result.Remaining = int(bucket.Tokens)
+	result.Reset = seconds((capacity - bucket.Tokens) / rate)
+	return bucket, result
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimitTake(t *testing.T) {
+	// a token per second with bursts of three
+	limit := RateLimit{Limit: 60, Period: time.Minute, Burst: 3}
+	start := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	steps := []struct {
+		name       string
+		at         time.Duration
+		allowed    bool
+		remaining  int
+		retryAfter time.Duration
+		reset      time.Duration
+	}{
+		{"full bucket", 0, true, 2, 0, time.Second},
+		{"burst", 0, true, 1, 0, 2 * time.Second},
+		{"last of the burst", 0, true, 0, 0, 3 * time.Second},
+		{"empty", 0, false, 0, time.Second, 3 * time.Second},
+		{"half a token", 500 * time.Millisecond, false, 0, 500 * time.Millisecond, 2500 * time.Millisecond},
+		{"refilled token", time.Second, true, 0, 0, 3 * time.Second},
+		{"clock behind", 500 * time.Millisecond, false, 0, time.Second, 3 * time.Second},
+		{"no refill after the clock caught up", time.Second, false, 0, time.Second, 3 * time.Second},
+		{"refilled up to the burst only", time.Hour, true, 2, 0, time.Second},
+	}
+
+	bucket := limit.NewBucket(start)
+	for _, step := range steps {
+		var result RateLimitResult
+		bucket, result = limit.Take(bucket, start.Add(step.at))
+
+		want := RateLimitResult{
+			Allowed:    step.allowed,
+			Limit:      3,
+			Remaining:  step.remaining,
+			Reset:      step.reset,
+			RetryAfter: step.retryAfter,
+		}
+		if result != want {
+			t.Fatalf("%s: got %+v, want %+v", step.name, result, want)
+		}
+	}
+}
+
+func TestRateLimitWithoutBurst(t *testing.T) {
+	limit := RateLimit{Limit: 2, Period: time.Second}
+	now := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	bucket := limit.NewBucket(now)
+	for i := 0; i < 2; i++ {
+		bucket, _ = limit.Take(bucket, now)
+	}
+	_, result := limit.Take(bucket, now)
+
+	want := RateLimitResult{Limit: 2, RetryAfter: 500 * time.Millisecond, Reset: time.Second}
+	if result != want {
+		t.Errorf("got %+v, want %+v", result, want)
+	}
+}
@@ -0,0 +1,50 @@
+package domain
+
+import "context"
+
+// Scopes of API keys and OAuth tokens. Every scope includes the ones before it: write can read
+// and admin can do everything the owner of the credential can.
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+	ScopeAdmin = "admin"
+)
+
+var scopeLevels = map[string]int{ScopeRead: 1, ScopeWrite: 2, ScopeAdmin: 3}
+
+// Scopes limit what a request made with an API key or an OAuth token may do.
+type Scopes []string
+
+// Allows reports whether the scopes grant the scope.
+func (s Scopes) Allows(scope string) bool {
+	for _, granted := range s {
+		if scopeLevels[granted] >= scopeLevels[scope] {
+			return true
+		}
+	}
+	return false
+}
+
+// Contains reports whether every scope of other is granted by s.
+func (s Scopes) Contains(other Scopes) bool {
+	for _, scope := range other {
+		if !s.Allows(scope) {
+			return false
+		}
+	}
+	return true
+}
+
+type scopesKey struct{}
+
+// WithScopes returns a copy of ctx carrying the scopes of the API key or OAuth token the request is made with.
+func WithScopes(ctx context.Context, scopes Scopes) context.Context {
+	return context.WithValue(ctx, scopesKey{}, scopes)
+}
+
+// ScopesFrom returns the scopes the request is limited to. ok is false for requests made with a session,
+// they aren't limited.
+func ScopesFrom(ctx context.Context) (scopes Scopes, ok bool) {
+	scopes, ok = ctx.Value(scopesKey{}).(Scopes)
+	return scopes, ok
+}
@@ -0,0 +1,15 @@
+package domain
+
+import "time"
+
+// LoginFailures counts failed sign-in attempts made for an account or from an IP address.
+type LoginFailures struct {
+	Failures      int
+	LastFailureAt time.Time
+	LockedUntil   time.Time
+}
+
+// Locked tells whether sign-in is blocked at the moment.
+func (f LoginFailures) Locked(now time.Time) bool {
+	return f.LockedUntil.After(now)
+}
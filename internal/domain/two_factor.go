@@ -0,0 +1,44 @@
+package domain
+
+// TOTP is the two-factor authentication state of a user. Secret is set once enrollment starts,
+// Enabled once it's confirmed with a valid code.
+type TOTP struct {
+	Secret   string
+	Enabled  bool
+	LastStep int64
+}
+
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+type RecoveryCodes struct {
+	Codes []string `json:"recovery_codes"`
+}
+
+// TOTPCodeInput carries either a code from the authenticator app or a recovery code.
+type TOTPCodeInput struct {
+	Code string `json:"code" validate:"required"`
+}
+
+func (i TOTPCodeInput) Validate() error {
+	return validationError(validate.Struct(i))
+}
+
+type MFAVerifyInput struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code" validate:"required"`
+}
+
+func (i MFAVerifyInput) Validate() error {
+	return validationError(validate.Struct(i))
+}
+
+// SignInResult holds the issued tokens. Users with two-factor authentication get only
+// a ChallengeToken, which is exchanged for the tokens together with a code.
+type SignInResult struct {
+	AccessToken    string
+	RefreshToken   string
+	ChallengeToken string
+}
@@ -1,18 +1,103 @@
 package domain
 
-import "github.com/go-playground/validator/v10"
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
 
 var validate *validator.Validate
 
 func init() {
 	validate = validator.New()
+	// report fields the way clients send them
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
 }
 
+const (
+	RoleReader = "reader"
+	RoleAdmin  = "admin"
+)
+
 type User struct {
-	Id       int    `json:"id"`
-	Name     string `json:"name"`
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Id        int    `json:"id"`
+	Name      string `json:"name"`
+	Email     string `json:"email"`
+	Password  string `json:"password"`
+	Role      string `json:"role"`
+	AvatarURL string `json:"avatar_url"`
+	// PendingEmail is the new email waiting for confirmation.
+	PendingEmail string `json:"pending_email"`
+	// EmailVerified is set once the user has proven to own the email.
+	EmailVerified bool `json:"email_verified"`
+	// DeletionScheduledAt is when the account is going to be deleted, nil if it isn't.
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at"`
+}
+
+// Profile is what users see about themselves.
+type Profile struct {
+	Id           int    `json:"id"`
+	Name         string `json:"name"`
+	Email        string `json:"email"`
+	Role         string `json:"role"`
+	AvatarURL    string `json:"avatar_url,omitempty"`
+	PendingEmail string `json:"pending_email,omitempty"`
+	// DeletionScheduledAt is set while the account waits for deletion.
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at,omitempty"`
+}
+
+// UpdateProfileInput changes the given fields, an empty avatar URL removes the avatar.
+type UpdateProfileInput struct {
+	Name      *string `json:"name" validate:"omitempty,gte=2,lte=255"`
+	AvatarURL *string `json:"avatar_url" validate:"omitempty,url,startswith=https://|startswith=http://"`
+}
+
+func (i UpdateProfileInput) Validate() error {
+	if i.AvatarURL != nil && *i.AvatarURL == "" {
+		i.AvatarURL = nil
+	}
+	return validationError(validate.Struct(i))
+}
+
+type ChangePasswordInput struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,gte=6"`
+}
+
+func (i ChangePasswordInput) Validate() error {
+	return validationError(validate.Struct(i))
+}
+
+// ChangeEmailInput asks for the password, so a session left open can't be used to take over the account.
+type ChangeEmailInput struct {
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
+}
+
+func (i ChangeEmailInput) Validate() error {
+	return validationError(validate.Struct(i))
+}
+
+type EmailChange struct {
+	UserId   int
+	OldEmail string
+	NewEmail string
+}
+
+type ConfirmEmailInput struct {
+	Token string `json:"token" validate:"required"`
+}
+
+func (i ConfirmEmailInput) Validate() error {
+	return validationError(validate.Struct(i))
 }
 
 type SignUpInput struct {
@@ -22,7 +107,7 @@ type SignUpInput struct {
 }
 
 func (i SignUpInput) Validate() error {
-	return validate.Struct(i)
+	return validationError(validate.Struct(i))
 }
 
 type SignInInput struct {
@@ -31,5 +116,13 @@ type SignInInput struct {
 }
 
 func (i SignInInput) Validate() error {
-	return validate.Struct(i)
+	return validationError(validate.Struct(i))
+}
+
+type SetRoleInput struct {
+	Role string `json:"role" validate:"required,oneof=reader admin"`
+}
+
+func (i SetRoleInput) Validate() error {
+	return validationError(validate.Struct(i))
 }
@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead"
+)
+
+var WebhookEvents = []string{EventArticleCreated, EventArticleUpdated, EventArticleDeleted}
+
+type Webhook struct {
+	Id        int       `json:"id"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"-"`
+	Events    []string  `json:"events"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type WebhookInput struct {
+	URL    string   `json:"url" validate:"required,url"`
+	Secret string   `json:"secret" validate:"required,gte=16"`
+	Events []string `json:"events" validate:"dive,oneof=article.created article.updated article.deleted"`
+}
+
+func (i WebhookInput) Validate() error {
+	return validationError(validate.Struct(i))
+}
+
+type UpdateWebhookInput struct {
+	URL    *string   `json:"url" validate:"omitempty,url"`
+	Secret *string   `json:"secret" validate:"omitempty,gte=16"`
+	Events *[]string `json:"events" validate:"omitempty,dive,oneof=article.created article.updated article.deleted"`
+	Active *bool     `json:"active"`
+}
+
+func (i UpdateWebhookInput) Validate() error {
+	return validationError(validate.Struct(i))
+}
+
+type WebhookDelivery struct {
+	Id            int             `json:"id"`
+	WebhookId     int             `json:"webhook_id"`
+	Event         string          `json:"event"`
+	Payload       json.RawMessage `json:"payload" swaggertype:"object"`
+	Status        string          `json:"status"`
+	Attempts      int             `json:"attempts"`
+	NextAttemptAt time.Time       `json:"next_attempt_at"`
+	LastError     string          `json:"last_error"`
+	ResponseCode  int             `json:"response_code"`
+	CreatedAt     time.Time       `json:"created_at"`
+	DeliveredAt   *time.Time      `json:"delivered_at"`
+}
+
+// PendingDelivery is a claimed delivery together with the target it has to be sent to.
+type PendingDelivery struct {
+	WebhookDelivery
+	URL    string
+	Secret string
+}
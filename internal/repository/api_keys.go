@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/xopxe23/news-server/internal/domain"
+)
+
+// lastUsedResolution is how stale last_used_at may get, so busy keys don't write on every request.
+const lastUsedResolution = time.Minute
+
+type APIKeysRepository struct {
+	db *sql.DB
+}
+
+func NewAPIKeysRepository(db *sql.DB) *APIKeysRepository {
+	return &APIKeysRepository{db: db}
+}
+
+func (r *APIKeysRepository) Create(ctx context.Context, key domain.APIKey, hash string) (domain.APIKey, error) {
+	err := r.db.QueryRowContext(ctx, `INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at`,
+		key.UserId, key.Name, key.Prefix, hash, pq.Array([]string(key.Scopes)), key.ExpiresAt).Scan(&key.Id, &key.CreatedAt)
+	return key, err
+}
+
+func (r *APIKeysRepository) GetByUser(ctx context.Context, userId int) ([]domain.APIKey, error) {
+	keys := make([]domain.APIKey, 0)
+	rows, err := r.db.QueryContext(ctx, `SELECT id, user_id, name, prefix, scopes, expires_at, last_used_at, created_at
+		FROM api_keys WHERE user_id = $1 ORDER BY id`, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+func (r *APIKeysRepository) GetByHash(ctx context.Context, hash string) (domain.APIKey, error) {
+	key, err := scanAPIKey(r.db.QueryRowContext(ctx, `SELECT id, user_id, name, prefix, scopes, expires_at, last_used_at, created_at
+		FROM api_keys WHERE key_hash = $1`, hash))
+	return key, notFound(err, "api key not found")
+}
+
+func (r *APIKeysRepository) Delete(ctx context.Context, userId, id int) error {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM api_keys WHERE id = $1 AND user_id = $2", id, userId)
+	return affectedOne(res, err, "api key not found")
+}
+
+// Touch records that the key was used at the given time.
+func (r *APIKeysRepository) Touch(ctx context.Context, id int, at time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE api_keys SET last_used_at = $2
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < $3)`, id, at, at.Add(-lastUsedResolution))
+	return err
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanAPIKey(row rowScanner) (domain.APIKey, error) {
+	var (
+		key                 domain.APIKey
+		expiresAt, lastUsed sql.NullTime
+	)
+	if err := row.Scan(&key.Id, &key.UserId, &key.Name, &key.Prefix, pq.Array((*[]string)(&key.Scopes)),
+		&expiresAt, &lastUsed, &key.CreatedAt); err != nil {
+		return domain.APIKey{}, err
+	}
+	if expiresAt.Valid {
+		key.ExpiresAt = &expiresAt.Time
+	}
+	if lastUsed.Valid {
+		key.LastUsedAt = &lastUsed.Time
+	}
+	return key, nil
+}
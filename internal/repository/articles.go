@@ -9,6 +9,88 @@ import (
 	"github.com/xopxe23/news-server/internal/domain"
 )
 
+// articleField is a column read into domain.ArticleOutput, ar is articles and au is authors.
+type articleField struct {
+	// name is the JSON name of the field, see domain.ArticleQuery
+	name   string
+	column string
+	// content fields are left out of summaries
+	content bool
+	// author fields need authors to be joined
+	author bool
+	dest   func(article *domain.ArticleOutput) interface{}
+}
+
+var articleFields = []articleField{
+	{name: "id", column: "ar.id", dest: func(a *domain.ArticleOutput) interface{} { return &a.Id }},
+	{name: "author_id", column: "ar.author_id", dest: func(a *domain.ArticleOutput) interface{} { return &a.AuthorId }},
+	{name: "author", column: "CONCAT(au.name, ' ', au.surname)", author: true,
+		dest: func(a *domain.ArticleOutput) interface{} { return &a.Author }},
+	{name: "title", column: "ar.title", dest: func(a *domain.ArticleOutput) interface{} { return &a.Title }},
+	{name: "content", column: "ar.content", content: true, dest: func(a *domain.ArticleOutput) interface{} { return &a.Content }},
+	{name: "content_format", column: "ar.content_format", dest: func(a *domain.ArticleOutput) interface{} { return &a.ContentFormat }},
+	{name: "content_html", column: "ar.content_html", content: true,
+		dest: func(a *domain.ArticleOutput) interface{} { return &a.ContentHTML }},
+	{name: "excerpt", column: "ar.excerpt", dest: func(a *domain.ArticleOutput) interface{} { return &a.Excerpt }},
+	{name: "word_count", column: "ar.word_count", dest: func(a *domain.ArticleOutput) interface{} { return &a.WordCount }},
+	{name: "reading_time", column: "ar.reading_time", dest: func(a *domain.ArticleOutput) interface{} { return &a.ReadingTime }},
+	{name: "created_at", column: "ar.created_at", dest: func(a *domain.ArticleOutput) interface{} { return &a.CreatedAt }},
+}
+
+// embeddedAuthorFields are read into ArticleOutput.Embedded when the query includes the author.
+var embeddedAuthorFields = []articleField{
+	{column: "au.id", author: true, dest: func(a *domain.ArticleOutput) interface{} { return &embeddedAuthor(a).Id }},
+	{column: "au.name", author: true, dest: func(a *domain.ArticleOutput) interface{} { return &embeddedAuthor(a).Name }},
+	{column: "au.surname", author: true, dest: func(a *domain.ArticleOutput) interface{} { return &embeddedAuthor(a).Surname }},
+}
+
+func embeddedAuthor(article *domain.ArticleOutput) *domain.Author {
+	if article.Embedded == nil {
+		article.Embedded = &domain.ArticleEmbedded{}
+	}
+	if article.Embedded.Author == nil {
+		article.Embedded.Author = &domain.Author{}
+	}
+	return article.Embedded.Author
+}
+
+// selectArticles builds the SELECT of the fields the query asks for from articles ar. Authors au are joined
+// only when a field needs them, callers add their own joins and conditions.
+func selectArticles(query domain.ArticleQuery) (string, []articleField) {
+	requested := make(map[string]bool, len(query.Fields))
+	for _, name := range query.Fields {
+		requested[name] = true
+	}
+
+	fields := make([]articleField, 0, len(articleFields)+len(embeddedAuthorFields))
+	for _, field := range articleFields {
+		switch {
+		case field.name == "id":
+		case len(requested) > 0 && !requested[field.name]:
+			continue
+		case query.Summary && field.content:
+			continue
+		}
+		fields = append(fields, field)
+	}
+	if query.IncludeAuthor {
+		fields = append(fields, embeddedAuthorFields...)
+	}
+
+	columns := make([]string, len(fields))
+	joinAuthors := false
+	for i, field := range fields {
+		columns[i] = field.column
+		joinAuthors = joinAuthors || field.author
+	}
+
+	selectQuery := "SELECT " + strings.Join(columns, ", ") + " FROM articles ar"
+	if joinAuthors {
+		selectQuery += " INNER JOIN authors au ON ar.author_id = au.id"
+	}
+	return selectQuery, fields
+}
+
 type ArticlesRepository struct {
 	db *sql.DB
 }
@@ -19,41 +101,52 @@ func NewArticlesRepository(db *sql.DB) *ArticlesRepository {
 
 func (r *ArticlesRepository) Create(ctx context.Context, input domain.Article) (int, error) {
 	var articleId int
-	err := r.db.QueryRow("INSERT INTO articles(author_id, title, content, created_at) values($1, $2, $3, $4) RETURNING id",
-		input.AuthorId, input.Title, input.Content, input.CreatedAt).Scan(&articleId)
+	err := conn(ctx, r.db).QueryRowContext(ctx, `INSERT INTO articles(author_id, title, content, content_format, content_html,
+			excerpt, word_count, reading_time, created_at)
+		values($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id`,
+		input.AuthorId, input.Title, input.Content, input.ContentFormat, input.ContentHTML,
+		input.Metadata.Excerpt, input.Metadata.WordCount, input.Metadata.ReadingTime, input.CreatedAt).Scan(&articleId)
+	if isViolation(err, foreignKeyViolation) {
+		return 0, domain.InvalidInput("author does not exist", err)
+	}
 
 	return articleId, err
 }
 
-func (r *ArticlesRepository) GetAll(ctx context.Context) ([]domain.ArticleOutput, error) {
+func (r *ArticlesRepository) GetAll(ctx context.Context, articleQuery domain.ArticleQuery) ([]domain.ArticleOutput, error) {
 	var articles []domain.ArticleOutput
-	query := `SELECT ar.id, CONCAT(au.name, ' ', au.surname) as author, ar.title, ar.content, ar.created_at 
-			  FROM articles ar INNER JOIN authors au ON ar.author_id = au.id;`
-	rows, err := r.db.Query(query)
+	query, fields := selectArticles(articleQuery)
+	rows, err := r.db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
+
 	for rows.Next() {
-		var article domain.ArticleOutput
-		if err := rows.Scan(&article.Id, &article.Author, &article.Title, &article.Content, &article.CreatedAt); err != nil {
+		article, err := scanArticle(rows, fields)
+		if err != nil {
 			return nil, err
 		}
 		articles = append(articles, article)
 	}
-	return articles, nil
+	return articles, rows.Err()
 }
 
 func (r *ArticlesRepository) GetById(ctx context.Context, articleId int) (domain.ArticleOutput, error) {
-	var article domain.ArticleOutput
-	query := `SELECT ar.id, CONCAT(au.name, ' ', au.surname) as author, ar.title, ar.content, ar.created_at 
-			  FROM articles ar INNER JOIN authors au ON ar.author_id = au.id WHERE ar.id = $1;`
-	err := r.db.QueryRow(query, articleId).Scan(&article.Id, &article.Author, &article.Title, &article.Content, &article.CreatedAt)
+	query, fields := selectArticles(domain.ArticleQuery{})
+	article, err := scanArticle(conn(ctx, r.db).QueryRowContext(ctx, query+" WHERE ar.id = $1", articleId), fields)
 
-	return article, err
+	return article, notFound(err, "article not found")
 }
 
 func (r *ArticlesRepository) AddInBookmars(ctx context.Context, id, userId int) error {
 	_, err := r.db.Exec("INSERT INTO bookmarks (user_id, article_id) VALUES ($1, $2)", userId, id)
+	switch {
+	case isViolation(err, uniqueViolation):
+		return domain.Conflict("article is already in bookmarks")
+	case isViolation(err, foreignKeyViolation):
+		return domain.NotFound("article not found")
+	}
 	return err
 }
 
@@ -72,17 +165,74 @@ func (r *ArticlesRepository) Update(ctx context.Context, articleId int, input do
 		args = append(args, *input.Content)
 		argId++
 	}
+	if input.ContentFormat != nil {
+		setValues = append(setValues, fmt.Sprintf("content_format = $%d", argId))
+		args = append(args, *input.ContentFormat)
+		argId++
+	}
+	if input.ContentHTML != nil {
+		setValues = append(setValues, fmt.Sprintf("content_html = $%d", argId))
+		args = append(args, *input.ContentHTML)
+		argId++
+	}
+	if input.Metadata != nil {
+		setValues = append(setValues, fmt.Sprintf("excerpt = $%d, word_count = $%d, reading_time = $%d, metadata_stale = false",
+			argId, argId+1, argId+2))
+		args = append(args, input.Metadata.Excerpt, input.Metadata.WordCount, input.Metadata.ReadingTime)
+		argId += 3
+	}
+
+	if len(setValues) == 0 {
+		return errNothingToUpdate
+	}
 
 	setQuery := strings.Join(setValues, ", ")
 	query := fmt.Sprintf("UPDATE articles SET %s WHERE id = $%d", setQuery, argId)
-	fmt.Println(query)
 	args = append(args, articleId)
 
-	_, err := r.db.Exec(query, args...)
+	res, err := conn(ctx, r.db).ExecContext(ctx, query, args...)
+	return affectedOne(res, err, "article not found")
+}
+
+// GetStaleMetadata returns the id and the rendered content of articles whose metadata was never computed.
+func (r *ArticlesRepository) GetStaleMetadata(ctx context.Context, limit int) ([]domain.Article, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT id, content_html FROM articles WHERE metadata_stale ORDER BY id LIMIT $1", limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var articles []domain.Article
+	for rows.Next() {
+		var article domain.Article
+		if err := rows.Scan(&article.Id, &article.ContentHTML); err != nil {
+			return nil, err
+		}
+		articles = append(articles, article)
+	}
+	return articles, rows.Err()
+}
+
+// SetMetadata stores the metadata unless the article was updated meanwhile, the update has computed it already.
+func (r *ArticlesRepository) SetMetadata(ctx context.Context, articleId int, metadata domain.ArticleMetadata) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE articles SET excerpt = $1, word_count = $2, reading_time = $3, metadata_stale = false
+		WHERE id = $4 AND metadata_stale`,
+		metadata.Excerpt, metadata.WordCount, metadata.ReadingTime, articleId)
 	return err
 }
 
 func (r *ArticlesRepository) Delete(ctx context.Context, articleId int) error {
-	_, err := r.db.Exec("DELETE FROM articles WHERE id = $1", articleId)
-	return err
+	res, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM articles WHERE id = $1", articleId)
+	return affectedOne(res, err, "article not found")
+}
+
+// scanArticle reads a row selected by selectArticles with the fields it returned.
+func scanArticle(row rowScanner, fields []articleField) (domain.ArticleOutput, error) {
+	var article domain.ArticleOutput
+	dest := make([]interface{}, len(fields))
+	for i, field := range fields {
+		dest[i] = field.dest(&article)
+	}
+	err := row.Scan(dest...)
+	return article, err
 }
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/lib/pq"
 	"github.com/xopxe23/news-server/internal/domain"
 )
 
@@ -19,7 +20,7 @@ func NewAuthorsRepository(db *sql.DB) *AuthorsRepository {
 
 func (r *AuthorsRepository) Create(ctx context.Context, author domain.Author) (int, error) {
 	var id int
-	err := r.db.QueryRow("INSERT INTO authors(name, surname) VALUES($1, $2) RETURNING id",
+	err := conn(ctx, r.db).QueryRowContext(ctx, "INSERT INTO authors(name, surname) VALUES($1, $2) RETURNING id",
 		author.Name, author.Surname).Scan(&id)
 	return id, err
 }
@@ -44,27 +45,71 @@ func (r *AuthorsRepository) GetAll(ctx context.Context) ([]domain.Author, error)
 
 func (r *AuthorsRepository) GetById(ctx context.Context, id int) (domain.Author, error) {
 	var author domain.Author
-	err := r.db.QueryRow("SELECT id, name, surname FROM AUTHORS WHERE id = $1",
+	err := conn(ctx, r.db).QueryRowContext(ctx, "SELECT id, name, surname FROM AUTHORS WHERE id = $1",
 		id).Scan(&author.Id, &author.Name, &author.Surname)
 
-	return author, err
+	return author, notFound(err, "author not found")
 }
 
-func (r *AuthorsRepository) GetArticles(ctx context.Context, id int) ([]domain.ArticleOutput, error) {
+func (r *AuthorsRepository) GetByIds(ctx context.Context, ids []int) ([]domain.Author, error) {
+	var authors []domain.Author
+	rows, err := r.db.QueryContext(ctx, "SELECT id, name, surname FROM authors WHERE id = ANY($1)", pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var author domain.Author
+		if err := rows.Scan(&author.Id, &author.Name, &author.Surname); err != nil {
+			return nil, err
+		}
+		authors = append(authors, author)
+	}
+	return authors, rows.Err()
+}
+
+func (r *AuthorsRepository) GetArticles(ctx context.Context, id int, query domain.ArticleQuery) ([]domain.ArticleOutput, error) {
 	var articles []domain.ArticleOutput
-	rows, err := r.db.Query(`SELECT ar.id, CONCAT(au.name, ' ', au.surname) as author, ar.title, ar.content, ar.created_at
-							 FROM authors au INNER JOIN articles ar on ar.author_id = au.id WHERE au.id = $1`, id)
+	selectQuery, fields := selectArticles(query)
+	rows, err := r.db.QueryContext(ctx, selectQuery+" WHERE ar.author_id = $1", id)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
+
 	for rows.Next() {
-		var article domain.ArticleOutput
-		if err := rows.Scan(&article.Id, &article.Author, &article.Title, &article.Content, &article.CreatedAt); err != nil {
+		article, err := scanArticle(rows, fields)
+		if err != nil {
 			return nil, err
 		}
 		articles = append(articles, article)
 	}
-	return articles, nil
+	return articles, rows.Err()
+}
+
+// GetArticlesOfAuthors returns the articles of all the authors with one query, the author id is always read.
+func (r *AuthorsRepository) GetArticlesOfAuthors(ctx context.Context, ids []int, query domain.ArticleQuery) ([]domain.ArticleOutput, error) {
+	if len(query.Fields) > 0 {
+		query.Fields = append(query.Fields[:len(query.Fields):len(query.Fields)], "author_id")
+	}
+
+	var articles []domain.ArticleOutput
+	selectQuery, fields := selectArticles(query)
+	rows, err := r.db.QueryContext(ctx, selectQuery+" WHERE ar.author_id = ANY($1)", pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		article, err := scanArticle(rows, fields)
+		if err != nil {
+			return nil, err
+		}
+		articles = append(articles, article)
+	}
+	return articles, rows.Err()
 }
 
 func (r *AuthorsRepository) Update(ctx context.Context, id int, input domain.UpdateAuthorInput) error {
@@ -84,15 +129,19 @@ func (r *AuthorsRepository) Update(ctx context.Context, id int, input domain.Upd
 		argId++
 	}
 
+	if len(setValues) == 0 {
+		return errNothingToUpdate
+	}
+
 	setQuery := strings.Join(setValues, ", ")
 	query := fmt.Sprintf("UPDATE authors SET %s WHERE id = $%d", setQuery, argId)
 	args = append(args, id)
 
-	_, err := r.db.Exec(query, args...)
-	return err
+	res, err := conn(ctx, r.db).ExecContext(ctx, query, args...)
+	return affectedOne(res, err, "author not found")
 }
 
 func (r *AuthorsRepository) Delete(ctx context.Context, id int) error {
-	_, err := r.db.Exec("DELETE FROM authors WHERE id = $1", id)
-	return err
+	res, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM authors WHERE id = $1", id)
+	return affectedOne(res, err, "author not found")
 }
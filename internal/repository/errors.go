@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+	"github.com/xopxe23/news-server/internal/domain"
+)
+
+const (
+	foreignKeyViolation = "23503"
+	uniqueViolation     = "23505"
+)
+
+func isViolation(err error, code pq.ErrorCode) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == code
+}
+
+// notFound replaces sql.ErrNoRows with a not found error carrying the message.
+func notFound(err error, message string) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.NotFound(message)
+	}
+	return err
+}
+
+// affectedOne returns a not found error if the statement changed no rows.
+func affectedOne(res sql.Result, err error, message string) error {
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.NotFound(message)
+	}
+	return nil
+}
+
+var errNothingToUpdate = domain.InvalidInput("nothing to update", nil)
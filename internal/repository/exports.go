@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/xopxe23/news-server/internal/domain"
+)
+
+const exportColumns = "id, user_id, status, error, created_at, completed_at, expires_at"
+
+type ExportsRepository struct {
+	db *sql.DB
+}
+
+func NewExportsRepository(db *sql.DB) *ExportsRepository {
+	return &ExportsRepository{db: db}
+}
+
+// Create queues an export for the user. A pending export of the user is returned instead of queueing another one.
+func (r *ExportsRepository) Create(ctx context.Context, userId int) (domain.DataExport, error) {
+	export, err := scanExport(r.db.QueryRowContext(ctx, "SELECT "+exportColumns+
+		" FROM data_exports WHERE user_id = $1 AND status = $2", userId, domain.ExportPending))
+	if err != sql.ErrNoRows {
+		return export, err
+	}
+
+	return scanExport(r.db.QueryRowContext(ctx, "INSERT INTO data_exports (user_id) VALUES ($1) RETURNING "+exportColumns, userId))
+}
+
+func (r *ExportsRepository) GetById(ctx context.Context, userId, exportId int) (domain.DataExport, error) {
+	export, err := scanExport(r.db.QueryRowContext(ctx, "SELECT "+exportColumns+
+		" FROM data_exports WHERE id = $1 AND user_id = $2", exportId, userId))
+	return export, notFound(err, "export not found")
+}
+
+// GetArchive returns the archive of a ready export which hasn't expired yet.
+func (r *ExportsRepository) GetArchive(ctx context.Context, userId, exportId int, now time.Time) ([]byte, error) {
+	var archive []byte
+	err := r.db.QueryRowContext(ctx, `SELECT archive FROM data_exports
+		WHERE id = $1 AND user_id = $2 AND status = $3 AND expires_at > $4`,
+		exportId, userId, domain.ExportReady, now).Scan(&archive)
+	return archive, notFound(err, "export not found")
+}
+
+// ClaimPending picks up to limit pending exports and hides them from other workers for the lease duration.
+func (r *ExportsRepository) ClaimPending(ctx context.Context, limit int, lease time.Duration) ([]domain.DataExport, error) {
+	query := `UPDATE data_exports SET claimed_until = now() + $3 * interval '1 millisecond'
+			  WHERE id IN (
+				  SELECT id FROM data_exports
+				  WHERE status = $1 AND (claimed_until IS NULL OR claimed_until <= now())
+				  ORDER BY id LIMIT $2
+				  FOR UPDATE SKIP LOCKED)
+			  RETURNING ` + exportColumns
+	rows, err := r.db.QueryContext(ctx, query, domain.ExportPending, limit, lease.Milliseconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var exports []domain.DataExport
+	for rows.Next() {
+		export, err := scanExport(rows)
+		if err != nil {
+			return nil, err
+		}
+		exports = append(exports, export)
+	}
+	return exports, rows.Err()
+}
+
+func (r *ExportsRepository) Complete(ctx context.Context, exportId int, archive []byte, expiresAt time.Time) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE data_exports SET status = $2, archive = $3, completed_at = now(), expires_at = $4
+		WHERE id = $1`, exportId, domain.ExportReady, archive, expiresAt)
+	return affectedOne(res, err, "export not found")
+}
+
+func (r *ExportsRepository) Fail(ctx context.Context, exportId int, reason string) error {
+	res, err := r.db.ExecContext(ctx, "UPDATE data_exports SET status = $2, error = $3, completed_at = now() WHERE id = $1",
+		exportId, domain.ExportFailed, reason)
+	return affectedOne(res, err, "export not found")
+}
+
+// DeleteExpired removes archives which can't be downloaded anymore.
+func (r *ExportsRepository) DeleteExpired(ctx context.Context, now time.Time) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM data_exports WHERE expires_at <= $1", now)
+	return err
+}
+
+func scanExport(row rowScanner) (domain.DataExport, error) {
+	var (
+		export                 domain.DataExport
+		completedAt, expiresAt sql.NullTime
+	)
+	err := row.Scan(&export.Id, &export.UserId, &export.Status, &export.Error, &export.CreatedAt, &completedAt, &expiresAt)
+	if completedAt.Valid {
+		export.CompletedAt = &completedAt.Time
+	}
+	if expiresAt.Valid {
+		export.ExpiresAt = &expiresAt.Time
+	}
+	return export, err
+}
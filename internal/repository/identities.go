@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/xopxe23/news-server/internal/domain"
+)
+
+type IdentitiesRepository struct {
+	db *sql.DB
+}
+
+func NewIdentitiesRepository(db *sql.DB) *IdentitiesRepository {
+	return &IdentitiesRepository{db: db}
+}
+
+// GetUserId returns the user the external identity is linked to.
+func (r *IdentitiesRepository) GetUserId(ctx context.Context, issuer, subject string) (int, error) {
+	var userId int
+	err := r.db.QueryRowContext(ctx, "SELECT user_id FROM user_identities WHERE issuer = $1 AND subject = $2",
+		issuer, subject).Scan(&userId)
+	return userId, notFound(err, "identity not found")
+}
+
+func (r *IdentitiesRepository) Link(ctx context.Context, userId int, issuer, subject string) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, "INSERT INTO user_identities (user_id, issuer, subject) VALUES ($1, $2, $3)",
+		userId, issuer, subject)
+	if isViolation(err, uniqueViolation) {
+		return domain.Conflict("identity is already linked")
+	}
+	return err
+}
+
+func (r *IdentitiesRepository) GetByUser(ctx context.Context, userId int) ([]domain.LinkedIdentity, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT issuer, subject, created_at FROM user_identities WHERE user_id = $1 ORDER BY id", userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	identities := make([]domain.LinkedIdentity, 0)
+	for rows.Next() {
+		var identity domain.LinkedIdentity
+		if err := rows.Scan(&identity.Issuer, &identity.Subject, &identity.CreatedAt); err != nil {
+			return nil, err
+		}
+		identities = append(identities, identity)
+	}
+	return identities, rows.Err()
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/xopxe23/news-server/internal/domain"
+)
+
+type ImpersonationsRepository struct {
+	db *sql.DB
+}
+
+func NewImpersonationsRepository(db *sql.DB) *ImpersonationsRepository {
+	return &ImpersonationsRepository{db: db}
+}
+
+func (r *ImpersonationsRepository) Create(ctx context.Context, adminId, userId int, expiresAt time.Time) (int, error) {
+	var id int
+	err := r.db.QueryRowContext(ctx, "INSERT INTO impersonations (admin_id, user_id, expires_at) VALUES ($1, $2, $3) RETURNING id",
+		adminId, userId, expiresAt).Scan(&id)
+	return id, err
+}
+
+func (r *ImpersonationsRepository) GetById(ctx context.Context, id int) (domain.Impersonation, error) {
+	var (
+		impersonation domain.Impersonation
+		adminId       sql.NullInt64
+		endedAt       sql.NullTime
+	)
+	err := r.db.QueryRowContext(ctx, "SELECT id, admin_id, user_id, expires_at, ended_at FROM impersonations WHERE id = $1", id).
+		Scan(&impersonation.Id, &adminId, &impersonation.UserId, &impersonation.ExpiresAt, &endedAt)
+	impersonation.AdminId = int(adminId.Int64)
+	if endedAt.Valid {
+		impersonation.EndedAt = &endedAt.Time
+	}
+	return impersonation, notFound(err, "impersonation not found")
+}
+
+// End stops the impersonation, its tokens are refused from now on.
+func (r *ImpersonationsRepository) End(ctx context.Context, id int, at time.Time) error {
+	res, err := r.db.ExecContext(ctx, "UPDATE impersonations SET ended_at = $2 WHERE id = $1 AND ended_at IS NULL", id, at)
+	return affectedOne(res, err, "impersonation not found")
+}
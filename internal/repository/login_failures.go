@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/xopxe23/news-server/internal/domain"
+)
+
+type LoginFailuresRepository struct {
+	db *sql.DB
+}
+
+func NewLoginFailuresRepository(db *sql.DB) *LoginFailuresRepository {
+	return &LoginFailuresRepository{db: db}
+}
+
+// Get returns the failures recorded for key, which are zero if there are none.
+func (r *LoginFailuresRepository) Get(ctx context.Context, key string) (domain.LoginFailures, error) {
+	var (
+		failures    domain.LoginFailures
+		lockedUntil sql.NullTime
+	)
+	err := r.db.QueryRowContext(ctx, "SELECT failures, last_failure_at, locked_until FROM login_failures WHERE key = $1", key).
+		Scan(&failures.Failures, &failures.LastFailureAt, &lockedUntil)
+	if err == sql.ErrNoRows {
+		return domain.LoginFailures{}, nil
+	}
+	failures.LockedUntil = lockedUntil.Time
+	return failures, err
+}
+
+// RecordFailure counts a failed attempt. Failures older than windowStart are forgotten.
+func (r *LoginFailuresRepository) RecordFailure(ctx context.Context, key string, now, windowStart time.Time) (domain.LoginFailures, error) {
+	var (
+		failures    domain.LoginFailures
+		lockedUntil sql.NullTime
+	)
+	err := r.db.QueryRowContext(ctx, `INSERT INTO login_failures (key, failures, last_failure_at) VALUES ($1, 1, $2)
+		ON CONFLICT (key) DO UPDATE SET
+			failures = CASE WHEN login_failures.last_failure_at < $3 THEN 1 ELSE login_failures.failures + 1 END,
+			last_failure_at = $2
+		RETURNING failures, last_failure_at, locked_until`, key, now, windowStart).
+		Scan(&failures.Failures, &failures.LastFailureAt, &lockedUntil)
+	failures.LockedUntil = lockedUntil.Time
+	return failures, err
+}
+
+func (r *LoginFailuresRepository) Lock(ctx context.Context, key string, until time.Time) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE login_failures SET locked_until = $2 WHERE key = $1", key, until)
+	return err
+}
+
+// Reset forgets the failures recorded for key, which also lifts its lock.
+func (r *LoginFailuresRepository) Reset(ctx context.Context, key string) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM login_failures WHERE key = $1", key)
+	return err
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/xopxe23/news-server/internal/domain"
+)
+
+const mediaColumns = "m.id, m.owner_id, m.storage_key, m.thumbnail_key, m.content_type, m.size, m.width, m.height, m.created_at"
+
+type MediaRepository struct {
+	db *sql.DB
+}
+
+func NewMediaRepository(db *sql.DB) *MediaRepository {
+	return &MediaRepository{db: db}
+}
+
+func (r *MediaRepository) Create(ctx context.Context, media domain.Media) (domain.Media, error) {
+	return scanMedia(r.db.QueryRowContext(ctx, `INSERT INTO media AS m
+			(owner_id, storage_key, thumbnail_key, content_type, size, width, height)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING `+mediaColumns,
+		media.OwnerId, media.StorageKey, media.ThumbnailKey, media.ContentType, media.Size, media.Width, media.Height))
+}
+
+func (r *MediaRepository) GetById(ctx context.Context, mediaId int) (domain.Media, error) {
+	media, err := scanMedia(r.db.QueryRowContext(ctx, "SELECT "+mediaColumns+" FROM media m WHERE m.id = $1", mediaId))
+	return media, notFound(err, "media not found")
+}
+
+// Delete removes the media, it is detached from all articles.
+func (r *MediaRepository) Delete(ctx context.Context, mediaId int) error {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM media WHERE id = $1", mediaId)
+	return affectedOne(res, err, "media not found")
+}
+
+// Attach adds the media to the end of the article media. For attached media only the caption and the credit change.
+func (r *MediaRepository) Attach(ctx context.Context, articleId int, input domain.AttachMediaInput) error {
+	_, err := r.db.ExecContext(ctx, `INSERT INTO article_media (article_id, media_id, caption, credit, position)
+		VALUES ($1, $2, $3, $4, (SELECT COALESCE(MAX(position), 0) + 1 FROM article_media WHERE article_id = $1))
+		ON CONFLICT (article_id, media_id) DO UPDATE SET caption = EXCLUDED.caption, credit = EXCLUDED.credit`,
+		articleId, input.MediaId, input.Caption, input.Credit)
+	if isViolation(err, foreignKeyViolation) {
+		return domain.NotFound("article or media not found")
+	}
+	return err
+}
+
+func (r *MediaRepository) Detach(ctx context.Context, articleId, mediaId int) error {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM article_media WHERE article_id = $1 AND media_id = $2", articleId, mediaId)
+	return affectedOne(res, err, "media is not attached to the article")
+}
+
+func (r *MediaRepository) GetByArticle(ctx context.Context, articleId int) ([]domain.ArticleMedia, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+mediaColumns+`, am.caption, am.credit, am.position
+		FROM article_media am INNER JOIN media m ON am.media_id = m.id
+		WHERE am.article_id = $1 ORDER BY am.position`, articleId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attached := make([]domain.ArticleMedia, 0)
+	for rows.Next() {
+		var (
+			item    domain.ArticleMedia
+			ownerId sql.NullInt64
+		)
+		err := rows.Scan(&item.Id, &ownerId, &item.StorageKey, &item.ThumbnailKey, &item.ContentType, &item.Size,
+			&item.Width, &item.Height, &item.CreatedAt, &item.Caption, &item.Credit, &item.Position)
+		if err != nil {
+			return nil, err
+		}
+		item.OwnerId = int(ownerId.Int64)
+		attached = append(attached, item)
+	}
+	return attached, rows.Err()
+}
+
+func scanMedia(row rowScanner) (domain.Media, error) {
+	var (
+		media   domain.Media
+		ownerId sql.NullInt64
+	)
+	err := row.Scan(&media.Id, &ownerId, &media.StorageKey, &media.ThumbnailKey, &media.ContentType, &media.Size,
+		&media.Width, &media.Height, &media.CreatedAt)
+	media.OwnerId = int(ownerId.Int64)
+	return media, err
+}
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/xopxe23/news-server/internal/domain"
+)
+
+type OAuthRepository struct {
+	db *sql.DB
+}
+
+func NewOAuthRepository(db *sql.DB) *OAuthRepository {
+	return &OAuthRepository{db: db}
+}
+
+// CreateClient stores the client, public clients have no secret hash.
+func (r *OAuthRepository) CreateClient(ctx context.Context, client domain.OAuthClient, secretHash string) (domain.OAuthClient, error) {
+	hash := sql.NullString{String: secretHash, Valid: secretHash != ""}
+	err := r.db.QueryRowContext(ctx, `INSERT INTO oauth_clients (client_id, secret_hash, name, redirect_uris, scopes, owner_id)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at`,
+		client.ClientId, hash, client.Name, pq.Array(client.RedirectURIs), pq.Array([]string(client.Scopes)), client.OwnerId).
+		Scan(&client.Id, &client.CreatedAt)
+	return client, err
+}
+
+// GetClient returns the client and the hash of its secret, empty for public clients.
+func (r *OAuthRepository) GetClient(ctx context.Context, clientId string) (domain.OAuthClient, string, error) {
+	var hash sql.NullString
+	client, err := scanOAuthClient(r.db.QueryRowContext(ctx, `SELECT id, client_id, name, redirect_uris, scopes, owner_id, created_at, secret_hash
+		FROM oauth_clients WHERE client_id = $1`, clientId), &hash)
+	return client, hash.String, notFound(err, "client not found")
+}
+
+func (r *OAuthRepository) GetClientsByOwner(ctx context.Context, ownerId int) ([]domain.OAuthClient, error) {
+	clients := make([]domain.OAuthClient, 0)
+	rows, err := r.db.QueryContext(ctx, `SELECT id, client_id, name, redirect_uris, scopes, owner_id, created_at, secret_hash
+		FROM oauth_clients WHERE owner_id = $1 ORDER BY id`, ownerId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var hash sql.NullString
+		client, err := scanOAuthClient(rows, &hash)
+		if err != nil {
+			return nil, err
+		}
+		clients = append(clients, client)
+	}
+	return clients, rows.Err()
+}
+
+// DeleteClient removes the client together with its codes and tokens.
+func (r *OAuthRepository) DeleteClient(ctx context.Context, ownerId int, clientId string) error {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM oauth_clients WHERE client_id = $1 AND owner_id = $2", clientId, ownerId)
+	return affectedOne(res, err, "client not found")
+}
+
+func (r *OAuthRepository) CreateCode(ctx context.Context, hash string, code domain.AuthorizationCode) error {
+	_, err := r.db.ExecContext(ctx, `INSERT INTO oauth_codes (code_hash, client_id, user_id, redirect_uri, scopes, code_challenge, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		hash, code.ClientId, code.UserId, code.RedirectURI, pq.Array([]string(code.Scopes)), code.CodeChallenge, code.ExpiresAt)
+	return err
+}
+
+// TakeCode returns the code and deletes it, so a code can be exchanged only once.
+func (r *OAuthRepository) TakeCode(ctx context.Context, hash string) (domain.AuthorizationCode, error) {
+	var code domain.AuthorizationCode
+	err := r.db.QueryRowContext(ctx, `DELETE FROM oauth_codes WHERE code_hash = $1
+		RETURNING client_id, user_id, redirect_uri, scopes, code_challenge, expires_at`, hash).
+		Scan(&code.ClientId, &code.UserId, &code.RedirectURI, pq.Array((*[]string)(&code.Scopes)), &code.CodeChallenge, &code.ExpiresAt)
+	return code, notFound(err, "code not found")
+}
+
+func (r *OAuthRepository) CreateToken(ctx context.Context, hash string, token domain.OAuthToken) (domain.OAuthToken, error) {
+	err := r.db.QueryRowContext(ctx, `INSERT INTO oauth_tokens (token_hash, client_id, user_id, scopes, expires_at)
+		VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at`,
+		hash, token.ClientId, token.UserId, pq.Array([]string(token.Scopes)), token.ExpiresAt).Scan(&token.Id, &token.CreatedAt)
+	return token, err
+}
+
+func (r *OAuthRepository) GetToken(ctx context.Context, hash string) (domain.OAuthToken, error) {
+	var (
+		token     domain.OAuthToken
+		revokedAt sql.NullTime
+	)
+	err := r.db.QueryRowContext(ctx, `SELECT id, client_id, user_id, scopes, expires_at, revoked_at, created_at
+		FROM oauth_tokens WHERE token_hash = $1`, hash).
+		Scan(&token.Id, &token.ClientId, &token.UserId, pq.Array((*[]string)(&token.Scopes)), &token.ExpiresAt, &revokedAt, &token.CreatedAt)
+	if revokedAt.Valid {
+		token.RevokedAt = &revokedAt.Time
+	}
+	return token, notFound(err, "token not found")
+}
+
+// RevokeToken revokes the token if it was issued to the client. Unknown tokens are ignored.
+func (r *OAuthRepository) RevokeToken(ctx context.Context, hash string, clientId int, at time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE oauth_tokens SET revoked_at = $3
+		WHERE token_hash = $1 AND client_id = $2 AND revoked_at IS NULL`, hash, clientId, at)
+	return err
+}
+
+func scanOAuthClient(row rowScanner, secretHash *sql.NullString) (domain.OAuthClient, error) {
+	var client domain.OAuthClient
+	err := row.Scan(&client.Id, &client.ClientId, &client.Name, pq.Array(&client.RedirectURIs),
+		pq.Array((*[]string)(&client.Scopes)), &client.OwnerId, &client.CreatedAt, secretHash)
+	client.Confidential = secretHash.Valid
+	return client, err
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/xopxe23/news-server/internal/domain"
+)
+
+type OutboxRepository struct {
+	db *sql.DB
+}
+
+func NewOutboxRepository(db *sql.DB) *OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+// Add stores the event. Call it within the transaction of the state change the event describes.
+func (r *OutboxRepository) Add(ctx context.Context, event domain.Event) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `INSERT INTO outbox (event_type, aggregate_id, payload, idempotency_key, created_at)
+		VALUES ($1, $2, $3, $4, $5)`, event.Type, event.AggregateId, string(event.Payload), event.IdempotencyKey, event.CreatedAt)
+	return err
+}
+
+// AnonymizeUser strips personal data from the events about the user, leaving only the id.
+// Audit records refer to users by id, so they stay consistent.
+func (r *OutboxRepository) AnonymizeUser(ctx context.Context, userId int) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `UPDATE outbox SET payload = jsonb_build_object('id', aggregate_id)
+		WHERE aggregate_id = $1 AND event_type LIKE 'user.%'`, userId)
+	return err
+}
+
+// ClaimUnpublished picks up to limit events which are due to be published and hides them
+// from other relays for the lease duration.
+func (r *OutboxRepository) ClaimUnpublished(ctx context.Context, limit int, lease time.Duration) ([]domain.Event, error) {
+	var events []domain.Event
+	query := `UPDATE outbox SET next_attempt_at = now() + $2 * interval '1 millisecond'
+			  WHERE id IN (
+				  SELECT id FROM outbox
+				  WHERE published_at IS NULL AND next_attempt_at <= now()
+				  ORDER BY id LIMIT $1
+				  FOR UPDATE SKIP LOCKED)
+			  RETURNING id, event_type, aggregate_id, payload, idempotency_key, attempts, created_at`
+	rows, err := r.db.QueryContext(ctx, query, limit, lease.Milliseconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e domain.Event
+		if err := rows.Scan(&e.Id, &e.Type, &e.AggregateId, (*[]byte)(&e.Payload), &e.IdempotencyKey,
+			&e.Attempts, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+func (r *OutboxRepository) MarkPublished(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE outbox SET published_at = now(), last_error = '' WHERE id = $1", id)
+	return err
+}
+
+func (r *OutboxRepository) MarkFailed(ctx context.Context, id int64, reason string, retryIn time.Duration) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE outbox
+		SET attempts = attempts + 1, last_error = $2, next_attempt_at = now() + $3 * interval '1 millisecond'
+		WHERE id = $1`, id, reason, retryIn.Milliseconds())
+	return err
+}
+
+func (r *OutboxRepository) IsProcessed(ctx context.Context, subscriber, key string) (bool, error) {
+	var exists bool
+	err := r.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM processed_events WHERE subscriber = $1 AND idempotency_key = $2)",
+		subscriber, key).Scan(&exists)
+	return exists, err
+}
+
+func (r *OutboxRepository) MarkProcessed(ctx context.Context, subscriber, key string) error {
+	_, err := r.db.ExecContext(ctx, `INSERT INTO processed_events (subscriber, idempotency_key) VALUES ($1, $2)
+		ON CONFLICT DO NOTHING`, subscriber, key)
+	return err
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/xopxe23/news-server/internal/domain"
+)
+
+// sweepInterval is how often stores forget buckets which have refilled completely.
+const sweepInterval = time.Minute
+
+// RateLimitsRepository keeps token buckets in Postgres, so that all instances share them.
+type RateLimitsRepository struct {
+	db *sql.DB
+
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+func NewRateLimitsRepository(db *sql.DB) *RateLimitsRepository {
+	return &RateLimitsRepository{db: db}
+}
+
+func (r *RateLimitsRepository) Take(ctx context.Context, key string, limit domain.RateLimit, now time.Time) (domain.RateLimitResult, error) {
+	if err := r.sweep(ctx, now); err != nil {
+		return domain.RateLimitResult{}, err
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return domain.RateLimitResult{}, err
+	}
+	defer tx.Rollback()
+
+	bucket := limit.NewBucket(now)
+	if _, err := tx.ExecContext(ctx, `INSERT INTO rate_limits (key, tokens, updated_at, full_at) VALUES ($1, $2, $3, $3)
+		ON CONFLICT (key) DO NOTHING`, key, bucket.Tokens, now); err != nil {
+		return domain.RateLimitResult{}, err
+	}
+
+	if err := tx.QueryRowContext(ctx, "SELECT tokens, updated_at FROM rate_limits WHERE key = $1 FOR UPDATE", key).
+		Scan(&bucket.Tokens, &bucket.UpdatedAt); err != nil {
+		return domain.RateLimitResult{}, err
+	}
+
+	bucket, result := limit.Take(bucket, now)
+	if _, err := tx.ExecContext(ctx, "UPDATE rate_limits SET tokens = $2, updated_at = $3, full_at = $4 WHERE key = $1",
+		key, bucket.Tokens, bucket.UpdatedAt, now.Add(result.Reset)); err != nil {
+		return domain.RateLimitResult{}, err
+	}
+	return result, tx.Commit()
+}
+
+func (r *RateLimitsRepository) sweep(ctx context.Context, now time.Time) error {
+	r.mu.Lock()
+	if now.Sub(r.lastSweep) < sweepInterval {
+		r.mu.Unlock()
+		return nil
+	}
+	r.lastSweep = now
+	r.mu.Unlock()
+
+	_, err := r.db.ExecContext(ctx, "DELETE FROM rate_limits WHERE full_at < $1", now)
+	return err
+}
+
+type rateLimitEntry struct {
+	bucket domain.TokenBucket
+	fullAt time.Time
+}
+
+// MemoryRateLimits keeps token buckets in memory. Limits are per instance.
+type MemoryRateLimits struct {
+	mu        sync.Mutex
+	buckets   map[string]rateLimitEntry
+	lastSweep time.Time
+}
+
+func NewMemoryRateLimits() *MemoryRateLimits {
+	return &MemoryRateLimits{buckets: make(map[string]rateLimitEntry)}
+}
+
+func (m *MemoryRateLimits) Take(ctx context.Context, key string, limit domain.RateLimit, now time.Time) (domain.RateLimitResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if now.Sub(m.lastSweep) >= sweepInterval {
+		for k, e := range m.buckets {
+			if e.fullAt.Before(now) {
+				delete(m.buckets, k)
+			}
+		}
+		m.lastSweep = now
+	}
+
+	entry, ok := m.buckets[key]
+	if !ok {
+		entry.bucket = limit.NewBucket(now)
+	}
+
+	bucket, result := limit.Take(entry.bucket, now)
+	m.buckets[key] = rateLimitEntry{bucket: bucket, fullAt: now.Add(result.Reset)}
+	return result, nil
+}
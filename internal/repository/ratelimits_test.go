@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/xopxe23/news-server/internal/domain"
+)
+
+type rateLimitStore interface {
+	Take(ctx context.Context, key string, limit domain.RateLimit, now time.Time) (domain.RateLimitResult, error)
+}
+
+// testRateLimitStore checks the store keeps the bucket of every key between calls.
+func testRateLimitStore(t *testing.T, store rateLimitStore, prefix string) {
+	// a token per second with bursts of two
+	limit := domain.RateLimit{Limit: 60, Period: time.Minute, Burst: 2}
+	start := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	steps := []struct {
+		key        string
+		at         time.Duration
+		allowed    bool
+		remaining  int
+		retryAfter time.Duration
+		reset      time.Duration
+	}{
+		{"a", 0, true, 1, 0, time.Second},
+		{"a", 0, true, 0, 0, 2 * time.Second},
+		{"a", 0, false, 0, time.Second, 2 * time.Second},
+		{"b", 0, true, 1, 0, time.Second},
+		{"a", 250 * time.Millisecond, false, 0, 750 * time.Millisecond, 1750 * time.Millisecond},
+		{"a", time.Second, true, 0, 0, 2 * time.Second},
+		{"a", time.Hour, true, 1, 0, time.Second},
+	}
+	for i, step := range steps {
+		result, err := store.Take(context.Background(), prefix+step.key, limit, start.Add(step.at))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := domain.RateLimitResult{
+			Allowed:    step.allowed,
+			Limit:      2,
+			Remaining:  step.remaining,
+			Reset:      step.reset,
+			RetryAfter: step.retryAfter,
+		}
+		if result != want {
+			t.Fatalf("step %d, key %s: got %+v, want %+v", i, step.key, result, want)
+		}
+	}
+}
+
+func TestMemoryRateLimits(t *testing.T) {
+	testRateLimitStore(t, NewMemoryRateLimits(), "")
+}
+
+func TestMemoryRateLimitsSweep(t *testing.T) {
+	store := NewMemoryRateLimits()
+	limit := domain.RateLimit{Limit: 1, Period: time.Second}
+	now := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	if _, err := store.Take(context.Background(), "a", limit, now); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Take(context.Background(), "b", limit, now.Add(sweepInterval-time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	// a is full again, b is not yet
+	if _, err := store.Take(context.Background(), "c", limit, now.Add(sweepInterval)); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := store.buckets["a"]; ok {
+		t.Error("the full bucket of a must be forgotten")
+	}
+	if _, ok := store.buckets["b"]; !ok {
+		t.Error("the bucket of b must be kept until it is full")
+	}
+}
+
+// TestRateLimitsRepository needs a migrated database, pass its URL in TEST_DATABASE_URL.
+func TestRateLimitsRepository(t *testing.T) {
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	prefix := fmt.Sprintf("test-%d:", time.Now().UnixNano())
+	defer db.Exec("DELETE FROM rate_limits WHERE key LIKE $1", prefix+"%")
+
+	testRateLimitStore(t, NewRateLimitsRepository(db), prefix)
+}
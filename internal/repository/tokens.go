@@ -25,8 +25,33 @@ func (r *TokensRepository) GetToken(ctx context.Context, token string) (domain.R
 	var t domain.RefreshSession
 	err := r.db.QueryRow("SELECT * FROM refresh_tokens WHERE token = $1", token).Scan(&t.Id, &t.UserId, &t.Token, &t.ExpiresAt)
 	if err != nil {
-		return t, err
+		return t, notFound(err, "session not found")
 	}
 	_, err = r.db.Exec("DELETE FROM refresh_tokens WHERE user_id = $1", t.UserId)
 	return t, err
 }
+
+// DeleteByUser ends all sessions of the user.
+func (r *TokensRepository) DeleteByUser(ctx context.Context, userId int) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM refresh_tokens WHERE user_id = $1", userId)
+	return err
+}
+
+// GetByUser returns the active sessions of the user.
+func (r *TokensRepository) GetByUser(ctx context.Context, userId int) ([]domain.Session, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT id, expires_at FROM refresh_tokens WHERE user_id = $1 AND expires_at > now() ORDER BY id", userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := make([]domain.Session, 0)
+	for rows.Next() {
+		var session domain.Session
+		if err := rows.Scan(&session.Id, &session.ExpiresAt); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/xopxe23/news-server/internal/domain"
+)
+
+type TwoFactorRepository struct {
+	db *sql.DB
+}
+
+func NewTwoFactorRepository(db *sql.DB) *TwoFactorRepository {
+	return &TwoFactorRepository{db: db}
+}
+
+func (r *TwoFactorRepository) Get(ctx context.Context, userId int) (domain.TOTP, error) {
+	var (
+		totp   domain.TOTP
+		secret sql.NullString
+	)
+	err := r.db.QueryRowContext(ctx, "SELECT totp_secret, totp_enabled, totp_last_step FROM users WHERE id = $1", userId).
+		Scan(&secret, &totp.Enabled, &totp.LastStep)
+	totp.Secret = secret.String
+	return totp, notFound(err, "user not found")
+}
+
+// SetSecret starts a new enrollment, two-factor authentication stays off until Enable.
+func (r *TwoFactorRepository) SetSecret(ctx context.Context, userId int, secret string) error {
+	res, err := r.db.ExecContext(ctx, "UPDATE users SET totp_secret = $2, totp_enabled = false, totp_last_step = 0 WHERE id = $1",
+		userId, secret)
+	return affectedOne(res, err, "user not found")
+}
+
+func (r *TwoFactorRepository) Enable(ctx context.Context, userId int) error {
+	res, err := conn(ctx, r.db).ExecContext(ctx, "UPDATE users SET totp_enabled = true WHERE id = $1", userId)
+	return affectedOne(res, err, "user not found")
+}
+
+func (r *TwoFactorRepository) Disable(ctx context.Context, userId int) error {
+	res, err := conn(ctx, r.db).ExecContext(ctx, "UPDATE users SET totp_secret = NULL, totp_enabled = false, totp_last_step = 0 WHERE id = $1",
+		userId)
+	if err := affectedOne(res, err, "user not found"); err != nil {
+		return err
+	}
+
+	_, err = conn(ctx, r.db).ExecContext(ctx, "DELETE FROM recovery_codes WHERE user_id = $1", userId)
+	return err
+}
+
+// UseStep remembers the time step of an accepted code. It fails with a conflict if
+// a code of this or a later step was already used, so a code works only once.
+func (r *TwoFactorRepository) UseStep(ctx context.Context, userId int, step int64) error {
+	res, err := r.db.ExecContext(ctx, "UPDATE users SET totp_last_step = $2 WHERE id = $1 AND totp_last_step < $2", userId, step)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.Conflict("code was already used")
+	}
+	return nil
+}
+
+// ReplaceRecoveryCodes drops the recovery codes of the user and stores the given hashes instead.
+func (r *TwoFactorRepository) ReplaceRecoveryCodes(ctx context.Context, userId int, hashes []string) error {
+	if _, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM recovery_codes WHERE user_id = $1", userId); err != nil {
+		return err
+	}
+
+	for _, hash := range hashes {
+		if _, err := conn(ctx, r.db).ExecContext(ctx, "INSERT INTO recovery_codes (user_id, code_hash) VALUES ($1, $2)",
+			userId, hash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UseRecoveryCode marks the unused recovery code with the given hash as used.
+func (r *TwoFactorRepository) UseRecoveryCode(ctx context.Context, userId int, hash string) error {
+	res, err := r.db.ExecContext(ctx, "UPDATE recovery_codes SET used_at = now() WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL",
+		userId, hash)
+	return affectedOne(res, err, "recovery code not found")
+}
@@ -3,6 +3,9 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/xopxe23/news-server/internal/domain"
 )
@@ -15,34 +18,182 @@ func NewUsersRepository(db *sql.DB) *UsersRepository {
 	return &UsersRepository{db: db}
 }
 
-func (r *UsersRepository) Create(ctx context.Context, user domain.User) error {
-	_, err := r.db.Exec("INSERT INTO users (name, email, password_hash) values ($1, $2, $3)",
-		user.Name, user.Email, user.Password)
-	return err
+func (r *UsersRepository) Create(ctx context.Context, user domain.User) (int, error) {
+	var id int
+	err := conn(ctx, r.db).QueryRowContext(ctx, `INSERT INTO users (name, email, password_hash, email_verified)
+		values ($1, $2, $3, $4) RETURNING id`,
+		user.Name, user.Email, user.Password, user.EmailVerified).Scan(&id)
+	if isViolation(err, uniqueViolation) {
+		return 0, domain.Conflict("user with this email already exists")
+	}
+	return id, err
 }
 
 func (r *UsersRepository) GetByCredentials(ctx context.Context, email, password string) (domain.User, error) {
 	var user domain.User
 	err := r.db.QueryRow("SELECT id, name, email FROM users WHERE email = $1 and password_hash = $2",
 		email, password).Scan(&user.Id, &user.Name, &user.Email)
-	return user, err
+	return user, notFound(err, "user not found")
+}
+
+func (r *UsersRepository) GetById(ctx context.Context, userId int) (domain.User, error) {
+	var (
+		user                    domain.User
+		avatarURL, pendingEmail sql.NullString
+		deletionScheduledAt     sql.NullTime
+	)
+	err := r.db.QueryRowContext(ctx, `SELECT id, name, email, role, avatar_url, pending_email, deletion_scheduled_at
+		FROM users WHERE id = $1`, userId).
+		Scan(&user.Id, &user.Name, &user.Email, &user.Role, &avatarURL, &pendingEmail, &deletionScheduledAt)
+	user.AvatarURL, user.PendingEmail = avatarURL.String, pendingEmail.String
+	if deletionScheduledAt.Valid {
+		user.DeletionScheduledAt = &deletionScheduledAt.Time
+	}
+	return user, notFound(err, "user not found")
+}
+
+func (r *UsersRepository) Update(ctx context.Context, userId int, input domain.UpdateProfileInput) error {
+	setValues := make([]string, 0)
+	args := make([]interface{}, 0)
+	argId := 1
+
+	if input.Name != nil {
+		setValues = append(setValues, fmt.Sprintf("name = $%d", argId))
+		args = append(args, *input.Name)
+		argId++
+	}
+	if input.AvatarURL != nil {
+		setValues = append(setValues, fmt.Sprintf("avatar_url = $%d", argId))
+		args = append(args, sql.NullString{String: *input.AvatarURL, Valid: *input.AvatarURL != ""})
+		argId++
+	}
+
+	if len(setValues) == 0 {
+		return errNothingToUpdate
+	}
+
+	setQuery := strings.Join(setValues, ", ")
+	query := fmt.Sprintf("UPDATE users SET %s WHERE id = $%d", setQuery, argId)
+	args = append(args, userId)
+
+	res, err := r.db.ExecContext(ctx, query, args...)
+	return affectedOne(res, err, "user not found")
+}
+
+func (r *UsersRepository) UpdatePassword(ctx context.Context, userId int, password string) error {
+	res, err := conn(ctx, r.db).ExecContext(ctx, "UPDATE users SET password_hash = $2 WHERE id = $1", userId, password)
+	return affectedOne(res, err, "user not found")
+}
+
+// ReclaimAccount marks the email as verified and drops every way into the account somebody could have set up
+// before: the password, two-factor authentication, API keys, OAuth tokens and sessions issued before at.
+// Refresh sessions are kept by the sessions repository. Call it within a transaction.
+func (r *UsersRepository) ReclaimAccount(ctx context.Context, userId int, at time.Time) error {
+	db := conn(ctx, r.db)
+	res, err := db.ExecContext(ctx, `UPDATE users SET password_hash = '', email_verified = true,
+			totp_secret = NULL, totp_enabled = false, password_reset_hash = NULL, password_reset_expires_at = NULL,
+			sessions_revoked_at = $2
+		WHERE id = $1`, userId, at)
+	if err := affectedOne(res, err, "user not found"); err != nil {
+		return err
+	}
+
+	if _, err := db.ExecContext(ctx, "DELETE FROM recovery_codes WHERE user_id = $1", userId); err != nil {
+		return err
+	}
+	if _, err := db.ExecContext(ctx, "DELETE FROM api_keys WHERE user_id = $1", userId); err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx, "UPDATE oauth_tokens SET revoked_at = $2 WHERE user_id = $1 AND revoked_at IS NULL", userId, at)
+	return err
+}
+
+// SetPendingEmail remembers the new email until it is confirmed with the token, replacing an earlier request.
+func (r *UsersRepository) SetPendingEmail(ctx context.Context, userId int, email, tokenHash string, expiresAt time.Time) error {
+	res, err := conn(ctx, r.db).ExecContext(ctx, `UPDATE users SET pending_email = $2, email_token_hash = $3, email_token_expires_at = $4
+		WHERE id = $1`, userId, email, tokenHash, expiresAt)
+	return affectedOne(res, err, "user not found")
 }
 
-func (r *UsersRepository) GetBookmarks(ctx context.Context, userId int) ([]domain.ArticleOutput, error) {
+// ConfirmEmail makes the pending email of the token the user's email.
+func (r *UsersRepository) ConfirmEmail(ctx context.Context, tokenHash string, now time.Time) (domain.EmailChange, error) {
+	var change domain.EmailChange
+	err := conn(ctx, r.db).QueryRowContext(ctx, `UPDATE users u SET email = u.pending_email, pending_email = NULL,
+			email_token_hash = NULL, email_token_expires_at = NULL, email_verified = true
+		FROM users old WHERE old.id = u.id AND u.email_token_hash = $1 AND u.email_token_expires_at > $2
+		RETURNING u.id, old.email, u.email`, tokenHash, now).
+		Scan(&change.UserId, &change.OldEmail, &change.NewEmail)
+	if isViolation(err, uniqueViolation) {
+		return domain.EmailChange{}, domain.Conflict("user with this email already exists")
+	}
+	return change, notFound(err, "invalid or expired token")
+}
+
+// ScheduleDeletion sets when the account is deleted, nil cancels the deletion.
+func (r *UsersRepository) ScheduleDeletion(ctx context.Context, userId int, at *time.Time) error {
+	res, err := conn(ctx, r.db).ExecContext(ctx, "UPDATE users SET deletion_scheduled_at = $2 WHERE id = $1", userId, at)
+	return affectedOne(res, err, "user not found")
+}
+
+// GetDueDeletions returns up to limit users whose deletion time has come.
+func (r *UsersRepository) GetDueDeletions(ctx context.Context, now time.Time, limit int) ([]domain.User, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT id, email FROM users
+		WHERE deletion_scheduled_at <= $1 ORDER BY deletion_scheduled_at LIMIT $2`, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []domain.User
+	for rows.Next() {
+		var user domain.User
+		if err := rows.Scan(&user.Id, &user.Email); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+// Delete removes the user if the deletion is still scheduled. Everything the user owns goes with them.
+func (r *UsersRepository) Delete(ctx context.Context, userId int, now time.Time) error {
+	res, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM users WHERE id = $1 AND deletion_scheduled_at <= $2", userId, now)
+	return affectedOne(res, err, "user not found")
+}
+
+func (r *UsersRepository) GetByEmail(ctx context.Context, email string) (domain.User, error) {
+	var user domain.User
+	err := conn(ctx, r.db).QueryRowContext(ctx, "SELECT id, name, email, role, email_verified FROM users WHERE email = $1", email).
+		Scan(&user.Id, &user.Name, &user.Email, &user.Role, &user.EmailVerified)
+	return user, notFound(err, "user not found")
+}
+
+func (r *UsersRepository) GetRole(ctx context.Context, userId int) (string, error) {
+	var role string
+	err := r.db.QueryRowContext(ctx, "SELECT role FROM users WHERE id = $1", userId).Scan(&role)
+	return role, notFound(err, "user not found")
+}
+
+func (r *UsersRepository) SetRole(ctx context.Context, userId int, role string) error {
+	res, err := r.db.ExecContext(ctx, "UPDATE users SET role = $1 WHERE id = $2", role, userId)
+	return affectedOne(res, err, "user not found")
+}
+
+func (r *UsersRepository) GetBookmarks(ctx context.Context, userId int, articleQuery domain.ArticleQuery) ([]domain.ArticleOutput, error) {
 	var articles []domain.ArticleOutput
-	query := `SELECT ar.id, CONCAT(au.name, ' ', au.surname) as author, ar.title, ar.content, ar.created_at 
-			  FROM articles ar INNER JOIN authors au ON ar.author_id = au.id 
-			  INNER JOIN bookmarks bm ON ar.id = bm.article_id WHERE bm.user_id = $1;`
-	rows, err := r.db.Query(query, userId)
+	query, fields := selectArticles(articleQuery)
+	rows, err := r.db.QueryContext(ctx, query+" INNER JOIN bookmarks bm ON ar.id = bm.article_id WHERE bm.user_id = $1", userId)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
+
 	for rows.Next() {
-		var article domain.ArticleOutput
-		if err := rows.Scan(&article.Id, &article.Author, &article.Title, &article.Content, &article.CreatedAt); err != nil {
+		article, err := scanArticle(rows, fields)
+		if err != nil {
 			return nil, err
 		}
 		articles = append(articles, article)
 	}
-	return articles, nil
+	return articles, rows.Err()
 }
@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/xopxe23/news-server/internal/domain"
+)
+
+// List returns a page of users matching the filter, ordered by id, and the number of all matching users.
+func (r *UsersRepository) List(ctx context.Context, filter domain.UserFilter) ([]domain.AdminUser, int, error) {
+	conditions := make([]string, 0)
+	args := make([]interface{}, 0)
+	argId := 1
+
+	if filter.Query != "" {
+		conditions = append(conditions, fmt.Sprintf("(name ILIKE $%d OR email ILIKE $%d)", argId, argId))
+		args = append(args, "%"+escapeLike(filter.Query)+"%")
+		argId++
+	}
+	if filter.Role != "" {
+		conditions = append(conditions, fmt.Sprintf("role = $%d", argId))
+		args = append(args, filter.Role)
+		argId++
+	}
+	if filter.Disabled != nil {
+		if *filter.Disabled {
+			conditions = append(conditions, "disabled_at IS NOT NULL")
+		} else {
+			conditions = append(conditions, "disabled_at IS NULL")
+		}
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM users"+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := fmt.Sprintf(`SELECT id, name, email, role, disabled_at, deletion_scheduled_at FROM users%s
+		ORDER BY id LIMIT $%d OFFSET $%d`, where, argId, argId+1)
+	rows, err := r.db.QueryContext(ctx, query, append(args, filter.Limit, filter.Offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	users := make([]domain.AdminUser, 0)
+	for rows.Next() {
+		user, err := scanAdminUser(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		users = append(users, user)
+	}
+	return users, total, rows.Err()
+}
+
+func (r *UsersRepository) GetAdminUser(ctx context.Context, userId int) (domain.AdminUser, error) {
+	user, err := scanAdminUser(r.db.QueryRowContext(ctx, `SELECT id, name, email, role, disabled_at, deletion_scheduled_at
+		FROM users WHERE id = $1`, userId))
+	return user, notFound(err, "user not found")
+}
+
+func (r *UsersRepository) CountBookmarks(ctx context.Context, userId int) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM bookmarks WHERE user_id = $1", userId).Scan(&count)
+	return count, err
+}
+
+func (r *UsersRepository) GetStatus(ctx context.Context, userId int) (domain.AccountStatus, error) {
+	var (
+		status                        domain.AccountStatus
+		disabledAt, sessionsRevokedAt sql.NullTime
+	)
+	err := r.db.QueryRowContext(ctx, "SELECT disabled_at, sessions_revoked_at FROM users WHERE id = $1", userId).
+		Scan(&disabledAt, &sessionsRevokedAt)
+	if disabledAt.Valid {
+		status.DisabledAt = &disabledAt.Time
+	}
+	if sessionsRevokedAt.Valid {
+		status.SessionsRevokedAt = &sessionsRevokedAt.Time
+	}
+	return status, notFound(err, "user not found")
+}
+
+// SetDisabled disables the user at the given time, nil enables them again.
+func (r *UsersRepository) SetDisabled(ctx context.Context, userId int, at *time.Time) error {
+	res, err := conn(ctx, r.db).ExecContext(ctx, "UPDATE users SET disabled_at = $2 WHERE id = $1", userId, at)
+	return affectedOne(res, err, "user not found")
+}
+
+// RevokeSessions invalidates access tokens of the user issued before the given time.
+func (r *UsersRepository) RevokeSessions(ctx context.Context, userId int, at time.Time) error {
+	res, err := conn(ctx, r.db).ExecContext(ctx, "UPDATE users SET sessions_revoked_at = $2 WHERE id = $1", userId, at)
+	return affectedOne(res, err, "user not found")
+}
+
+// SetPasswordReset remembers the hash of a password reset token, replacing an earlier one.
+func (r *UsersRepository) SetPasswordReset(ctx context.Context, userId int, tokenHash string, expiresAt time.Time) error {
+	res, err := conn(ctx, r.db).ExecContext(ctx, `UPDATE users SET password_reset_hash = $2, password_reset_expires_at = $3
+		WHERE id = $1`, userId, tokenHash, expiresAt)
+	return affectedOne(res, err, "user not found")
+}
+
+// ResetPassword sets the password of the user the token was issued to. The token works once.
+func (r *UsersRepository) ResetPassword(ctx context.Context, tokenHash, password string, now time.Time) (domain.PasswordReset, error) {
+	var reset domain.PasswordReset
+	err := conn(ctx, r.db).QueryRowContext(ctx, `UPDATE users SET password_hash = $2,
+			password_reset_hash = NULL, password_reset_expires_at = NULL, email_verified = true
+		WHERE password_reset_hash = $1 AND password_reset_expires_at > $3
+		RETURNING id, email`, tokenHash, password, now).Scan(&reset.UserId, &reset.Email)
+	return reset, notFound(err, "invalid or expired token")
+}
+
+func scanAdminUser(row rowScanner) (domain.AdminUser, error) {
+	var (
+		user                            domain.AdminUser
+		disabledAt, deletionScheduledAt sql.NullTime
+	)
+	err := row.Scan(&user.Id, &user.Name, &user.Email, &user.Role, &disabledAt, &deletionScheduledAt)
+	if disabledAt.Valid {
+		user.DisabledAt = &disabledAt.Time
+	}
+	if deletionScheduledAt.Valid {
+		user.DeletionScheduledAt = &deletionScheduledAt.Time
+	}
+	return user, err
+}
+
+// escapeLike makes s match literally in LIKE patterns.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/xopxe23/news-server/internal/domain"
+)
+
+type WebhooksRepository struct {
+	db *sql.DB
+}
+
+func NewWebhooksRepository(db *sql.DB) *WebhooksRepository {
+	return &WebhooksRepository{db: db}
+}
+
+func (r *WebhooksRepository) Create(ctx context.Context, webhook domain.Webhook) (int, error) {
+	var id int
+	err := r.db.QueryRowContext(ctx, "INSERT INTO webhooks (url, secret, events) VALUES ($1, $2, $3) RETURNING id",
+		webhook.URL, webhook.Secret, pq.Array(webhook.Events)).Scan(&id)
+	return id, err
+}
+
+func (r *WebhooksRepository) GetAll(ctx context.Context) ([]domain.Webhook, error) {
+	var webhooks []domain.Webhook
+	rows, err := r.db.QueryContext(ctx, "SELECT id, url, secret, events, active, created_at FROM webhooks ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var webhook domain.Webhook
+		if err := rows.Scan(&webhook.Id, &webhook.URL, &webhook.Secret, pq.Array(&webhook.Events),
+			&webhook.Active, &webhook.CreatedAt); err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	return webhooks, rows.Err()
+}
+
+func (r *WebhooksRepository) GetById(ctx context.Context, id int) (domain.Webhook, error) {
+	var webhook domain.Webhook
+	err := r.db.QueryRowContext(ctx, "SELECT id, url, secret, events, active, created_at FROM webhooks WHERE id = $1",
+		id).Scan(&webhook.Id, &webhook.URL, &webhook.Secret, pq.Array(&webhook.Events), &webhook.Active, &webhook.CreatedAt)
+	return webhook, notFound(err, "webhook not found")
+}
+
+func (r *WebhooksRepository) Update(ctx context.Context, id int, input domain.UpdateWebhookInput) error {
+	setValues := make([]string, 0)
+	args := make([]interface{}, 0)
+	argId := 1
+
+	if input.URL != nil {
+		setValues = append(setValues, fmt.Sprintf("url = $%d", argId))
+		args = append(args, *input.URL)
+		argId++
+	}
+	if input.Secret != nil {
+		setValues = append(setValues, fmt.Sprintf("secret = $%d", argId))
+		args = append(args, *input.Secret)
+		argId++
+	}
+	if input.Events != nil {
+		setValues = append(setValues, fmt.Sprintf("events = $%d", argId))
+		args = append(args, pq.Array(*input.Events))
+		argId++
+	}
+	if input.Active != nil {
+		setValues = append(setValues, fmt.Sprintf("active = $%d", argId))
+		args = append(args, *input.Active)
+		argId++
+	}
+
+	if len(setValues) == 0 {
+		return errNothingToUpdate
+	}
+
+	setQuery := strings.Join(setValues, ", ")
+	query := fmt.Sprintf("UPDATE webhooks SET %s WHERE id = $%d", setQuery, argId)
+	args = append(args, id)
+
+	res, err := r.db.ExecContext(ctx, query, args...)
+	return affectedOne(res, err, "webhook not found")
+}
+
+func (r *WebhooksRepository) Delete(ctx context.Context, id int) error {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM webhooks WHERE id = $1", id)
+	return affectedOne(res, err, "webhook not found")
+}
+
+// Enqueue schedules a delivery of the event for every active webhook subscribed to it.
+// An event already queued for a webhook under the same idempotency key is skipped.
+func (r *WebhooksRepository) Enqueue(ctx context.Context, key, event string, payload []byte) error {
+	_, err := r.db.ExecContext(ctx, `INSERT INTO webhook_deliveries (webhook_id, idempotency_key, event, payload)
+		SELECT id, $1, $2::text, $3::jsonb FROM webhooks
+		WHERE active AND (cardinality(events) = 0 OR $2::text = ANY(events))
+		ON CONFLICT (webhook_id, idempotency_key) DO NOTHING`, key, event, string(payload))
+	return err
+}
+
+// ClaimDue picks up to limit pending deliveries whose time has come and pushes their
+// next attempt forward by lease, so that other workers skip them while they are in flight.
+func (r *WebhooksRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]domain.PendingDelivery, error) {
+	var deliveries []domain.PendingDelivery
+	query := `UPDATE webhook_deliveries d SET next_attempt_at = now() + $2 * interval '1 millisecond'
+			  FROM webhooks w
+			  WHERE w.id = d.webhook_id AND d.id IN (
+				  SELECT id FROM webhook_deliveries
+				  WHERE status = 'pending' AND next_attempt_at <= now()
+				  ORDER BY next_attempt_at LIMIT $1
+				  FOR UPDATE SKIP LOCKED)
+			  RETURNING d.id, d.webhook_id, d.event, d.payload, d.attempts, w.url, w.secret`
+	rows, err := r.db.QueryContext(ctx, query, limit, lease.Milliseconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var d domain.PendingDelivery
+		if err := rows.Scan(&d.Id, &d.WebhookId, &d.Event, (*[]byte)(&d.Payload), &d.Attempts, &d.URL, &d.Secret); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+func (r *WebhooksRepository) MarkDelivered(ctx context.Context, id, responseCode int) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE webhook_deliveries
+		SET status = 'delivered', attempts = attempts + 1, response_code = $2, last_error = '', delivered_at = now()
+		WHERE id = $1`, id, responseCode)
+	return err
+}
+
+// MarkFailed records a failed attempt and schedules the next one after retryIn.
+func (r *WebhooksRepository) MarkFailed(ctx context.Context, id, responseCode int, reason string, retryIn time.Duration) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE webhook_deliveries
+		SET attempts = attempts + 1, response_code = $2, last_error = $3, next_attempt_at = now() + $4 * interval '1 millisecond'
+		WHERE id = $1`, id, responseCode, reason, retryIn.Milliseconds())
+	return err
+}
+
+// MarkDead records the last failed attempt and moves the delivery to the dead-letter state.
+func (r *WebhooksRepository) MarkDead(ctx context.Context, id, responseCode int, reason string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE webhook_deliveries
+		SET status = 'dead', attempts = attempts + 1, response_code = $2, last_error = $3
+		WHERE id = $1`, id, responseCode, reason)
+	return err
+}
+
+// Redeliver puts a delivery back into the queue with a fresh attempts counter.
+func (r *WebhooksRepository) Redeliver(ctx context.Context, webhookId, deliveryId int) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE webhook_deliveries
+		SET status = 'pending', attempts = 0, next_attempt_at = now(), delivered_at = NULL
+		WHERE id = $1 AND webhook_id = $2`, deliveryId, webhookId)
+	return affectedOne(res, err, "delivery not found")
+}
+
+func (r *WebhooksRepository) GetDeliveries(ctx context.Context, webhookId int) ([]domain.WebhookDelivery, error) {
+	var deliveries []domain.WebhookDelivery
+	query := `SELECT id, webhook_id, event, payload, status, attempts, next_attempt_at, last_error, response_code, created_at, delivered_at
+			  FROM webhook_deliveries WHERE webhook_id = $1 ORDER BY id DESC LIMIT 100`
+	rows, err := r.db.QueryContext(ctx, query, webhookId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var d domain.WebhookDelivery
+		if err := rows.Scan(&d.Id, &d.WebhookId, &d.Event, (*[]byte)(&d.Payload), &d.Status, &d.Attempts, &d.NextAttemptAt,
+			&d.LastError, &d.ResponseCode, &d.CreatedAt, &d.DeliveredAt); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
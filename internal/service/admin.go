@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/xopxe23/news-server/internal/domain"
+)
+
+// passwordResetTTL is how long the link resetting a password works.
+const passwordResetTTL = time.Hour
+
+func (s *UsersService) ListUsers(ctx context.Context, filter domain.UserFilter) (domain.UsersPage, error) {
+	if filter.Limit == 0 {
+		filter.Limit = domain.DefaultPageLimit
+	}
+	if err := filter.Validate(); err != nil {
+		return domain.UsersPage{}, err
+	}
+
+	users, total, err := s.repo.List(ctx, filter)
+	if err != nil {
+		return domain.UsersPage{}, err
+	}
+	return domain.UsersPage{Users: users, Total: total, Limit: filter.Limit, Offset: filter.Offset}, nil
+}
+
+func (s *UsersService) GetUserDetails(ctx context.Context, userId int) (domain.AdminUserDetails, error) {
+	user, err := s.repo.GetAdminUser(ctx, userId)
+	if err != nil {
+		return domain.AdminUserDetails{}, err
+	}
+	bookmarks, err := s.repo.CountBookmarks(ctx, userId)
+	if err != nil {
+		return domain.AdminUserDetails{}, err
+	}
+	sessions, err := s.sessionsRepo.GetByUser(ctx, userId)
+	if err != nil {
+		return domain.AdminUserDetails{}, err
+	}
+	return domain.AdminUserDetails{AdminUser: user, BookmarksCount: bookmarks, Sessions: sessions}, nil
+}
+
+// DisableUser blocks the user: their tokens and API keys stop working at once and they can't sign in
+// until EnableUser is called. Tokens issued before disabling don't come back to life after it.
+func (s *UsersService) DisableUser(ctx context.Context, userId int) error {
+	if userId == domain.ActorFrom(ctx) {
+		return domain.InvalidInput("you can't disable yourself", nil)
+	}
+
+	now := time.Now()
+	return s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.SetDisabled(ctx, userId, &now); err != nil {
+			return err
+		}
+		return s.endSessions(ctx, userId, now)
+	})
+}
+
+func (s *UsersService) EnableUser(ctx context.Context, userId int) error {
+	return s.repo.SetDisabled(ctx, userId, nil)
+}
+
+// LogoutUser ends all sessions of the user, access tokens issued so far stop working too.
+// API keys and OAuth tokens aren't sessions and stay valid.
+func (s *UsersService) LogoutUser(ctx context.Context, userId int) error {
+	return s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		return s.endSessions(ctx, userId, time.Now())
+	})
+}
+
+func (s *UsersService) endSessions(ctx context.Context, userId int, at time.Time) error {
+	if err := s.repo.RevokeSessions(ctx, userId, at); err != nil {
+		return err
+	}
+	return s.sessionsRepo.DeleteByUser(ctx, userId)
+}
+
+// RequestPasswordReset sends the user a link to set a new password, see ResetPassword.
+// The current password keeps working until then.
+func (s *UsersService) RequestPasswordReset(ctx context.Context, userId int) error {
+	user, err := s.repo.GetById(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	token, err := randomToken(32)
+	if err != nil {
+		return err
+	}
+	sealed, err := sealToken(s.hmacSecret, token)
+	if err != nil {
+		return err
+	}
+	expiresAt := time.Now().Add(passwordResetTTL)
+
+	return s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.SetPasswordReset(ctx, userId, hashSecret(token), expiresAt); err != nil {
+			return err
+		}
+
+		event, err := domain.NewEvent(domain.EventUserPasswordResetRequested, userId, map[string]interface{}{
+			"id":           userId,
+			"email":        user.Email,
+			"sealed_token": sealed,
+			"expires_at":   expiresAt.UTC(),
+		})
+		if err != nil {
+			return err
+		}
+		return s.outbox.Add(ctx, event)
+	})
+}
+
+// ResetPassword sets a new password with the token from the reset link, ends all sessions of the user
+// and lifts their sign-in lock.
+func (s *UsersService) ResetPassword(ctx context.Context, input domain.ResetPasswordInput) error {
+	if err := input.Validate(); err != nil {
+		return err
+	}
+
+	password, err := s.hasher.Hash(input.Password)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var reset domain.PasswordReset
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		reset, err = s.repo.ResetPassword(ctx, hashSecret(input.Token), password, now)
+		if err != nil {
+			return err
+		}
+		if err := s.endSessions(ctx, reset.UserId, now); err != nil {
+			return err
+		}
+
+		event, err := domain.NewEvent(domain.EventUserPasswordReset, reset.UserId, map[string]interface{}{
+			"id": reset.UserId,
+		})
+		if err != nil {
+			return err
+		}
+		return s.outbox.Add(ctx, event)
+	})
+	if err != nil {
+		return err
+	}
+	return s.guard.Unlock(ctx, reset.Email)
+}
+
+// CheckActive fails if the user is disabled.
+func (s *UsersService) CheckActive(ctx context.Context, userId int) error {
+	return s.checkAccess(ctx, userId, time.Time{})
+}
+
+// checkAccess fails if the user is disabled or, for a token issued at issuedAt, if their sessions
+// were ended after it. A zero issuedAt skips the latter.
+func (s *UsersService) checkAccess(ctx context.Context, userId int, issuedAt time.Time) error {
+	status, err := s.repo.GetStatus(ctx, userId)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.Unauthorized("user not found")
+		}
+		return err
+	}
+	if status.DisabledAt != nil {
+		return domain.Unauthorized("account is disabled")
+	}
+	// issued at is precise to a second, tokens of the second the sessions ended in are let through,
+	// or a user signing in right after that would get a token which never works
+	if !issuedAt.IsZero() && status.SessionsRevokedAt != nil && issuedAt.Unix() < status.SessionsRevokedAt.Unix() {
+		return domain.Unauthorized("session ended")
+	}
+	return nil
+}
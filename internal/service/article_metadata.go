@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/sirupsen/logrus"
+	"github.com/xopxe23/news-server/internal/domain"
+	"github.com/xopxe23/news-server/pkg/markup"
+)
+
+const (
+	// excerptLength is the longest excerpt in characters, the ellipsis aside.
+	excerptLength = 200
+	// Cyrillic words are longer on average, so they are read slower than Latin ones.
+	latinWordsPerMinute    = 230
+	cyrillicWordsPerMinute = 180
+
+	// metadataBatchSize is how many articles BackfillMetadata reads at once.
+	metadataBatchSize = 100
+)
+
+// BackfillMetadata computes the metadata of the articles stored before it existed. It returns when all of them
+// are done, so it runs once per start.
+func (s *ArticlesService) BackfillMetadata(ctx context.Context) {
+	log := logrus.WithField("method", "ArticlesService.BackfillMetadata")
+
+	done := 0
+	for ctx.Err() == nil {
+		articles, err := s.articlesRepo.GetStaleMetadata(ctx, metadataBatchSize)
+		if err != nil {
+			log.Error(err)
+			return
+		}
+		if len(articles) == 0 {
+			break
+		}
+
+		for _, article := range articles {
+			if err := s.articlesRepo.SetMetadata(ctx, article.Id, contentMetadata(article.ContentHTML)); err != nil {
+				log.Error(err)
+				return
+			}
+		}
+		done += len(articles)
+	}
+
+	if done > 0 {
+		log.Infof("computed the metadata of %d articles", done)
+	}
+}
+
+// contentMetadata computes the metadata from the rendered content.
+func contentMetadata(html string) domain.ArticleMetadata {
+	text := markup.Text(html)
+	total, cyrillic := countWords(text)
+
+	metadata := domain.ArticleMetadata{
+		Excerpt:   excerpt(text),
+		WordCount: total,
+	}
+	if total > 0 {
+		minutes := float64(cyrillic)/cyrillicWordsPerMinute + float64(total-cyrillic)/latinWordsPerMinute
+		metadata.ReadingTime = int(math.Ceil(minutes))
+	}
+	return metadata
+}
+
+// countWords counts runs of letters and digits, a single apostrophe or hyphen between them doesn't split a word,
+// so "don't" and "из-за" are one word each. Words with any Cyrillic letter are counted as Cyrillic too.
+func countWords(text string) (total, cyrillic int) {
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		if !isWordRune(runes[i]) {
+			i++
+			continue
+		}
+
+		isCyrillic := false
+		for i < len(runes) {
+			if isWordRune(runes[i]) {
+				isCyrillic = isCyrillic || unicode.Is(unicode.Cyrillic, runes[i])
+				i++
+				continue
+			}
+			if isWordJoiner(runes[i]) && i+1 < len(runes) && isWordRune(runes[i+1]) {
+				i++
+				continue
+			}
+			break
+		}
+
+		total++
+		if isCyrillic {
+			cyrillic++
+		}
+	}
+	return total, cyrillic
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func isWordJoiner(r rune) bool {
+	return r == '\'' || r == '’' || r == '-'
+}
+
+// excerpt cuts the text at the last space which keeps it within excerptLength characters.
+// A single word longer than that is cut in the middle.
+func excerpt(text string) string {
+	runes := []rune(text)
+	if len(runes) <= excerptLength {
+		return text
+	}
+
+	cut := string(runes[:excerptLength+1])
+	if i := strings.LastIndexByte(cut, ' '); i >= 0 {
+		return cut[:i] + "…"
+	}
+	return string(runes[:excerptLength]) + "…"
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/xopxe23/news-server/internal/domain"
+)
+
+func TestCountWords(t *testing.T) {
+	tests := []struct {
+		text     string
+		total    int
+		cyrillic int
+	}{
+		{"", 0, 0},
+		{"из-за", 1, 1},
+		{"don't", 1, 0},
+		{"don’t stop", 2, 0},
+		{"Go и Rust", 3, 1},
+		{"приветhello", 1, 1},
+		{"2023 год", 2, 1},
+		{"rock-n-roll", 1, 0},
+		{"a - b", 2, 0},
+		{"end- -start", 2, 0},
+		{"'quoted' «цитата»", 2, 1},
+		{"e-mail, e‑mail", 3, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			total, cyrillic := countWords(tt.text)
+			if total != tt.total || cyrillic != tt.cyrillic {
+				t.Errorf("got %d words, %d Cyrillic, want %d, %d", total, cyrillic, tt.total, tt.cyrillic)
+			}
+		})
+	}
+}
+
+func TestExcerpt(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"short", "Короткий текст.", "Короткий текст."},
+		{"exactly the limit", strings.Repeat("я", excerptLength), strings.Repeat("я", excerptLength)},
+		{"cut at a space", strings.Repeat("слово ", 40), strings.TrimSpace(strings.Repeat("слово ", 33)) + "…"},
+		{"space right after the limit", strings.Repeat("я", excerptLength) + " хвост", strings.Repeat("я", excerptLength) + "…"},
+		{"single long word", strings.Repeat("я", excerptLength+50), strings.Repeat("я", excerptLength) + "…"},
+		{"long word after a short one", "а " + strings.Repeat("я", excerptLength), "а…"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := excerpt(tt.text)
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			if !utf8.ValidString(got) {
+				t.Error("the excerpt is not valid UTF-8")
+			}
+			if n := utf8.RuneCountInString(strings.TrimSuffix(got, "…")); n > excerptLength {
+				t.Errorf("got %d characters, want at most %d", n, excerptLength)
+			}
+		})
+	}
+}
+
+func TestContentMetadataReadingTime(t *testing.T) {
+	paragraph := func(word string, n int) string {
+		return "<p>" + strings.Repeat(word+" ", n) + "</p>"
+	}
+
+	tests := []struct {
+		name    string
+		html    string
+		words   int
+		minutes int
+	}{
+		{"empty", "", 0, 0},
+		{"markup only", "<p><img src=\"a.png\"></p>", 0, 0},
+		{"one Latin minute", paragraph("word", latinWordsPerMinute), latinWordsPerMinute, 1},
+		{"a Latin word over", paragraph("word", latinWordsPerMinute+1), latinWordsPerMinute + 1, 2},
+		{"one Cyrillic minute", paragraph("слово", cyrillicWordsPerMinute), cyrillicWordsPerMinute, 1},
+		{"a Cyrillic word over", paragraph("слово", cyrillicWordsPerMinute+1), cyrillicWordsPerMinute + 1, 2},
+		{
+			"half a minute of each",
+			paragraph("слово", cyrillicWordsPerMinute/2) + paragraph("word", latinWordsPerMinute/2),
+			cyrillicWordsPerMinute/2 + latinWordsPerMinute/2,
+			1,
+		},
+		{"a single word", "<p>Привет</p>", 1, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metadata := contentMetadata(tt.html)
+			if metadata.WordCount != tt.words || metadata.ReadingTime != tt.minutes {
+				t.Errorf("got %d words, %d minutes, want %d, %d", metadata.WordCount, metadata.ReadingTime, tt.words, tt.minutes)
+			}
+		})
+	}
+}
+
+func TestContentMetadataDecodesText(t *testing.T) {
+	metadata := contentMetadata("<p>Tom &amp; Jerry</p><p>don&#39;t&nbsp;stop</p>")
+	if metadata.Excerpt != "Tom & Jerry don't stop" || metadata.WordCount != 4 {
+		t.Errorf("got %q of %d words", metadata.Excerpt, metadata.WordCount)
+	}
+}
+
+// fakeArticlesRepository keeps the stale articles in memory, the other calls panic on the nil interface.
+type fakeArticlesRepository struct {
+	ArticlesRepository
+	stale    []domain.Article
+	metadata map[int]domain.ArticleMetadata
+}
+
+func (r *fakeArticlesRepository) GetStaleMetadata(ctx context.Context, limit int) ([]domain.Article, error) {
+	if len(r.stale) < limit {
+		limit = len(r.stale)
+	}
+	return append([]domain.Article(nil), r.stale[:limit]...), nil
+}
+
+func (r *fakeArticlesRepository) SetMetadata(ctx context.Context, id int, metadata domain.ArticleMetadata) error {
+	r.metadata[id] = metadata
+	for i, article := range r.stale {
+		if article.Id == id {
+			r.stale = append(r.stale[:i], r.stale[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func TestBackfillMetadata(t *testing.T) {
+	repo := &fakeArticlesRepository{metadata: map[int]domain.ArticleMetadata{}}
+	for id := 1; id <= metadataBatchSize+1; id++ {
+		repo.stale = append(repo.stale, domain.Article{Id: id, ContentHTML: "<p>из-за don't</p>"})
+	}
+
+	NewArticlesService(nil, repo, nil, nil).BackfillMetadata(context.Background())
+
+	if len(repo.stale) != 0 || len(repo.metadata) != metadataBatchSize+1 {
+		t.Fatalf("%d articles left stale, %d computed", len(repo.stale), len(repo.metadata))
+	}
+	want := domain.ArticleMetadata{Excerpt: "из-за don't", WordCount: 2, ReadingTime: 1}
+	if repo.metadata[1] != want {
+		t.Errorf("got %+v, want %+v", repo.metadata[1], want)
+	}
+}
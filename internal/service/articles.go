@@ -5,22 +5,27 @@ import (
 	"time"
 
 	"github.com/xopxe23/news-server/internal/domain"
+	"github.com/xopxe23/news-server/pkg/markup"
 )
 
 type ArticlesRepository interface {
 	Create(сtx context.Context, input domain.Article) (int, error)
-	GetAll(ctx context.Context) ([]domain.ArticleOutput, error)
+	GetAll(ctx context.Context, query domain.ArticleQuery) ([]domain.ArticleOutput, error)
 	GetById(ctx context.Context, id int) (domain.ArticleOutput, error)
 	AddInBookmars(ctx context.Context, id, userId int) error
 	Update(ctx context.Context, id int, input domain.UpdateArticleInput) error
 	Delete(ctx context.Context, id int) error
+	GetStaleMetadata(ctx context.Context, limit int) ([]domain.Article, error)
+	SetMetadata(ctx context.Context, id int, metadata domain.ArticleMetadata) error
 }
 
 type AuthorsRepository interface {
 	Create(сtx context.Context, author domain.Author) (int, error)
 	GetAll(ctx context.Context) ([]domain.Author, error)
 	GetById(ctx context.Context, id int) (domain.Author, error)
-	GetArticles(ctx context.Context, id int) ([]domain.ArticleOutput, error)
+	GetByIds(ctx context.Context, ids []int) ([]domain.Author, error)
+	GetArticles(ctx context.Context, id int, query domain.ArticleQuery) ([]domain.ArticleOutput, error)
+	GetArticlesOfAuthors(ctx context.Context, ids []int, query domain.ArticleQuery) ([]domain.ArticleOutput, error)
 	Update(ctx context.Context, id int, input domain.UpdateAuthorInput) error
 	Delete(ctx context.Context, id int) error
 }
@@ -28,17 +33,31 @@ type AuthorsRepository interface {
 type ArticlesService struct {
 	authorsRepo  AuthorsRepository
 	articlesRepo ArticlesRepository
+	transactor   Transactor
+	outbox       Outbox
 }
 
-func NewArticlesService(authorsRepo AuthorsRepository, articlesRepo ArticlesRepository) *ArticlesService {
+func NewArticlesService(authorsRepo AuthorsRepository, articlesRepo ArticlesRepository, transactor Transactor, outbox Outbox) *ArticlesService {
 	return &ArticlesService{
 		authorsRepo:  authorsRepo,
 		articlesRepo: articlesRepo,
+		transactor:   transactor,
+		outbox:       outbox,
 	}
 }
 
 func (s *ArticlesService) CreateAuthor(ctx context.Context, author domain.Author) (int, error) {
-	return s.authorsRepo.Create(ctx, author)
+	var id int
+	err := s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if id, err = s.authorsRepo.Create(ctx, author); err != nil {
+			return err
+		}
+
+		author.Id = id
+		return s.addEvent(ctx, domain.EventAuthorCreated, id, author)
+	})
+	return id, err
 }
 
 func (s *ArticlesService) GetAllAuthors(ctx context.Context) ([]domain.Author, error) {
@@ -49,32 +68,83 @@ func (s *ArticlesService) GetAuthorById(ctx context.Context, id int) (domain.Aut
 	return s.authorsRepo.GetById(ctx, id)
 }
 
-func (s *ArticlesService) GetAuthorArticles(ctx context.Context, id int) ([]domain.ArticleOutput, error) {
-	return s.authorsRepo.GetArticles(ctx, id)
+func (s *ArticlesService) GetAuthorsByIds(ctx context.Context, ids []int) ([]domain.Author, error) {
+	return s.authorsRepo.GetByIds(ctx, ids)
+}
+
+func (s *ArticlesService) GetAuthorArticles(ctx context.Context, id int, query domain.ArticleQuery) ([]domain.ArticleOutput, error) {
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+	return s.authorsRepo.GetArticles(ctx, id, query)
+}
+
+// GetArticlesOfAuthors returns the articles of several authors at once, so callers can batch GetAuthorArticles.
+func (s *ArticlesService) GetArticlesOfAuthors(ctx context.Context, ids []int, query domain.ArticleQuery) ([]domain.ArticleOutput, error) {
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+	return s.authorsRepo.GetArticlesOfAuthors(ctx, ids, query)
 }
 
 func (s *ArticlesService) UpdateAuthor(ctx context.Context, id int, input domain.UpdateAuthorInput) error {
-	return s.authorsRepo.Update(ctx, id, input)
+	return s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.authorsRepo.Update(ctx, id, input); err != nil {
+			return err
+		}
+
+		author, err := s.authorsRepo.GetById(ctx, id)
+		if err != nil {
+			return err
+		}
+		return s.addEvent(ctx, domain.EventAuthorUpdated, id, author)
+	})
 }
 
 func (s *ArticlesService) DeleteAuthor(ctx context.Context, id int) error {
-	return s.authorsRepo.Delete(ctx, id)
+	return s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.authorsRepo.Delete(ctx, id); err != nil {
+			return err
+		}
+		return s.addEvent(ctx, domain.EventAuthorDeleted, id, map[string]int{"id": id})
+	})
 }
 
 func (s *ArticlesService) CreateArticle(ctx context.Context, input domain.Article) (int, error) {
 	if input.CreatedAt.IsZero() {
 		input.CreatedAt = time.Now()
 	}
+	if input.ContentFormat == "" {
+		input.ContentFormat = domain.ContentPlain
+	}
 
 	if err := input.Validate(); err != nil {
 		return 0, err
 	}
-
-	return s.articlesRepo.Create(ctx, input)
-}
-
-func (s *ArticlesService) GetAllArticles(ctx context.Context) ([]domain.ArticleOutput, error) {
-	return s.articlesRepo.GetAll(ctx)
+	input.ContentHTML = renderContent(input.ContentFormat, input.Content)
+	input.Metadata = contentMetadata(input.ContentHTML)
+
+	var id int
+	err := s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if id, err = s.articlesRepo.Create(ctx, input); err != nil {
+			return err
+		}
+
+		article, err := s.articlesRepo.GetById(ctx, id)
+		if err != nil {
+			return err
+		}
+		return s.addEvent(ctx, domain.EventArticleCreated, id, article)
+	})
+	return id, err
+}
+
+func (s *ArticlesService) GetAllArticles(ctx context.Context, query domain.ArticleQuery) ([]domain.ArticleOutput, error) {
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+	return s.articlesRepo.GetAll(ctx, query)
 }
 
 func (s *ArticlesService) GetArticleById(ctx context.Context, articleId int) (domain.ArticleOutput, error) {
@@ -86,9 +156,63 @@ func (s *ArticlesService) AddArticleInBookmarks(ctx context.Context, articleId,
 }
 
 func (s *ArticlesService) UpdateArticle(ctx context.Context, articleId int, input domain.UpdateArticleInput) error {
-	return s.articlesRepo.Update(ctx, articleId, input)
+	if input.ContentFormat != nil && !domain.ValidContentFormat(*input.ContentFormat) {
+		return domain.InvalidInput("content_format must be one of: plain markdown", nil)
+	}
+
+	return s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		// the cached HTML and the metadata are computed from both the content and its format, the one not being changed is read
+		if input.Content != nil || input.ContentFormat != nil {
+			current, err := s.articlesRepo.GetById(ctx, articleId)
+			if err != nil {
+				return err
+			}
+			format, content := current.ContentFormat, current.Content
+			if input.ContentFormat != nil {
+				format = *input.ContentFormat
+			}
+			if input.Content != nil {
+				content = *input.Content
+			}
+			html := renderContent(format, content)
+			metadata := contentMetadata(html)
+			input.ContentHTML = &html
+			input.Metadata = &metadata
+		}
+
+		if err := s.articlesRepo.Update(ctx, articleId, input); err != nil {
+			return err
+		}
+
+		article, err := s.articlesRepo.GetById(ctx, articleId)
+		if err != nil {
+			return err
+		}
+		return s.addEvent(ctx, domain.EventArticleUpdated, articleId, article)
+	})
 }
 
 func (s *ArticlesService) DeleteArticle(ctx context.Context, articleId int) error {
-	return s.articlesRepo.Delete(ctx, articleId)
+	return s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.articlesRepo.Delete(ctx, articleId); err != nil {
+			return err
+		}
+		return s.addEvent(ctx, domain.EventArticleDeleted, articleId, map[string]int{"id": articleId})
+	})
+}
+
+// renderContent renders the content of the format to HTML which is safe to embed into pages.
+func renderContent(format, content string) string {
+	if format == domain.ContentMarkdown {
+		return markup.Markdown(content)
+	}
+	return markup.PlainText(content)
+}
+
+func (s *ArticlesService) addEvent(ctx context.Context, eventType string, aggregateId int, data interface{}) error {
+	event, err := domain.NewEvent(eventType, aggregateId, data)
+	if err != nil {
+		return err
+	}
+	return s.outbox.Add(ctx, event)
 }
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+	audit "github.com/xopxe23/auditlog/pkg/domain"
+	"github.com/xopxe23/news-server/internal/domain"
+)
+
+type AuditClient interface {
+	SendLogRequest(ctx context.Context, req domain.AuditLogItem) error
+}
+
+// AuditSink is where audit records end up.
+type AuditSink interface {
+	Record(ctx context.Context, record domain.AuditRecord) error
+}
+
+// NewAuditHandler returns an event handler reporting user registrations, lockouts, email changes, password resets
+// and deletions to the audit sink. Registrations, email changes and password resets are made by the users themselves,
+// lockouts and deletions by the system.
+func NewAuditHandler(sink AuditSink) EventHandler {
+	return func(ctx context.Context, event domain.Event) error {
+		record := domain.AuditRecord{
+			Entity:    domain.AuditEntityUser,
+			EntityId:  event.AggregateId,
+			Timestamp: event.CreatedAt,
+		}
+
+		switch event.Type {
+		case domain.EventUserRegistered:
+			record.Action = domain.AuditActionRegister
+			record.ActorId = event.AggregateId
+		case domain.EventUserLocked:
+			record.Action = domain.AuditActionLock
+		case domain.EventUserEmailChanged:
+			record.Action = domain.AuditActionChangeEmail
+			record.ActorId = event.AggregateId
+		case domain.EventUserPasswordReset:
+			record.Action = domain.AuditActionChangePassword
+			record.ActorId = event.AggregateId
+		case domain.EventUserDeleted:
+			record.Action = domain.AuditActionDelete
+		default:
+			return nil
+		}
+		return sink.Record(ctx, record)
+	}
+}
+
+// LogAuditSink writes audit records to the application log, it is used without the audit service
+// and next to it.
+type LogAuditSink struct{}
+
+func NewLogAuditSink() *LogAuditSink {
+	return &LogAuditSink{}
+}
+
+func (s *LogAuditSink) Record(ctx context.Context, record domain.AuditRecord) error {
+	entry := logrus.WithFields(logrus.Fields{
+		"audit":      record.Action,
+		"entity":     record.Entity,
+		"entity_id":  record.EntityId,
+		"actor_id":   record.ActorId,
+		"request_id": record.RequestId,
+	})
+	if record.ImpersonatorId != 0 {
+		entry = entry.WithField("impersonator_id", record.ImpersonatorId)
+	}
+	if record.Details != "" {
+		entry = entry.WithField("details", record.Details)
+	}
+	entry.Info()
+	return nil
+}
+
+var (
+	auditActions = map[string]string{
+		domain.AuditActionRegister:       audit.ACTION_REGISTER,
+		domain.AuditActionSignIn:         audit.ACTION_LOGIN,
+		domain.AuditActionRefresh:        audit.ACTION_LOGIN,
+		domain.AuditActionCreate:         audit.ACTION_CREATE,
+		domain.AuditActionUpdate:         audit.ACTION_UPDATE,
+		domain.AuditActionDelete:         audit.ACTION_DELETE,
+		domain.AuditActionAddBookmark:    audit.ACTION_UPDATE,
+		domain.AuditActionChangeRole:     audit.ACTION_UPDATE,
+		domain.AuditActionLock:           audit.ACTION_UPDATE,
+		domain.AuditActionUnlock:         audit.ACTION_UPDATE,
+		domain.AuditActionEnableMFA:      audit.ACTION_UPDATE,
+		domain.AuditActionDisableMFA:     audit.ACTION_UPDATE,
+		domain.AuditActionChangePassword: audit.ACTION_UPDATE,
+		domain.AuditActionChangeEmail:    audit.ACTION_UPDATE,
+		domain.AuditActionRequestExport:  audit.ACTION_GET,
+		domain.AuditActionScheduleDelete: audit.ACTION_UPDATE,
+		domain.AuditActionCancelDelete:   audit.ACTION_UPDATE,
+		domain.AuditActionDisable:        audit.ACTION_UPDATE,
+		domain.AuditActionEnable:         audit.ACTION_UPDATE,
+		domain.AuditActionForceLogout:    audit.ACTION_UPDATE,
+		domain.AuditActionResetPassword:  audit.ACTION_UPDATE,
+		// the audit service has no notion of impersonation, starting one is the closest to a login
+		domain.AuditActionStartImpersonation: audit.ACTION_LOGIN,
+		domain.AuditActionStopImpersonation:  audit.ACTION_UPDATE,
+		domain.AuditActionImpersonatedAccess: audit.ACTION_GET,
+		domain.AuditActionRedeliver:          audit.ACTION_UPDATE,
+		domain.AuditActionGrantConsent:       audit.ACTION_UPDATE,
+	}
+
+	// the enum of the audit service only knows about users and books, so news content, media and webhooks
+	// are reported as books, API keys and OAuth clients as users they belong to, the entity name tells them apart
+	auditEntities = map[string]string{
+		domain.AuditEntityUser:        audit.ENTITY_USER,
+		domain.AuditEntityAuthor:      audit.ENTITY_BOOK,
+		domain.AuditEntityArticle:     audit.ENTITY_BOOK,
+		domain.AuditEntityAPIKey:      audit.ENTITY_USER,
+		domain.AuditEntityMedia:       audit.ENTITY_BOOK,
+		domain.AuditEntityWebhook:     audit.ENTITY_BOOK,
+		domain.AuditEntityOAuthClient: audit.ENTITY_USER,
+	}
+)
+
+type auditClientSink struct {
+	client AuditClient
+}
+
+// NewAuditClientSink returns a sink forwarding records to the audit service. Only the details of the record
+// stay out, the rest travels in the extension fields of the LogRequest.
+func NewAuditClientSink(client AuditClient) AuditSink {
+	return &auditClientSink{client: client}
+}
+
+func (s *auditClientSink) Record(ctx context.Context, record domain.AuditRecord) error {
+	return s.client.SendLogRequest(ctx, domain.AuditLogItem{
+		Action:         auditActions[record.Action],
+		Entity:         auditEntities[record.Entity],
+		EntityId:       int64(record.EntityId),
+		Timestamp:      record.Timestamp,
+		ActionName:     record.Action,
+		EntityName:     record.Entity,
+		ActorId:        int64(record.ActorId),
+		ImpersonatorId: int64(record.ImpersonatorId),
+		RequestId:      record.RequestId,
+	})
+}
+
+type multiSink []AuditSink
+
+// NewMultiSink returns a sink writing every record to all the given sinks.
+func NewMultiSink(sinks ...AuditSink) AuditSink {
+	return multiSink(sinks)
+}
+
+func (m multiSink) Record(ctx context.Context, record domain.AuditRecord) error {
+	var lastErr error
+	for _, sink := range m {
+		if err := sink.Record(ctx, record); err != nil {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
@@ -0,0 +1,285 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	audit "github.com/xopxe23/auditlog/pkg/domain"
+	"github.com/xopxe23/news-server/internal/domain"
+)
+
+// memorySink keeps the records in memory for assertions.
+type memorySink struct {
+	mu      sync.Mutex
+	records []domain.AuditRecord
+	err     error
+}
+
+func (s *memorySink) Record(ctx context.Context, record domain.AuditRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records = append(s.records, record)
+	return s.err
+}
+
+func (s *memorySink) Records() []domain.AuditRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]domain.AuditRecord(nil), s.records...)
+}
+
+// fakeArticles implements only the calls the tests make, the rest panic on the nil interface.
+type fakeArticles struct {
+	Articles
+	createErr error
+}
+
+func (f *fakeArticles) CreateArticle(ctx context.Context, input domain.Article) (int, error) {
+	if f.createErr != nil {
+		return 0, f.createErr
+	}
+	return 42, nil
+}
+
+func TestAuditedArticlesServiceRecordsMutations(t *testing.T) {
+	sink := &memorySink{}
+	svc := NewAuditedArticlesService(&fakeArticles{}, sink)
+
+	ctx := domain.WithActor(context.Background(), 7)
+	ctx = domain.WithImpersonator(ctx, 3)
+	ctx = domain.WithRequestId(ctx, "req-1")
+	if _, err := svc.CreateArticle(ctx, domain.Article{}); err != nil {
+		t.Fatal(err)
+	}
+
+	records := sink.Records()
+	if len(records) != 1 {
+		t.Fatalf("got %d records, want 1", len(records))
+	}
+	got := records[0]
+	if got.Action != domain.AuditActionCreate || got.Entity != domain.AuditEntityArticle || got.EntityId != 42 {
+		t.Errorf("got %s %s %d, want create article 42", got.Action, got.Entity, got.EntityId)
+	}
+	if got.ActorId != 7 || got.ImpersonatorId != 3 || got.RequestId != "req-1" {
+		t.Errorf("got actor %d, impersonator %d, request %q", got.ActorId, got.ImpersonatorId, got.RequestId)
+	}
+	if got.Timestamp.IsZero() {
+		t.Error("timestamp is not set")
+	}
+}
+
+func TestAuditedArticlesServiceSkipsFailures(t *testing.T) {
+	sink := &memorySink{}
+	svc := NewAuditedArticlesService(&fakeArticles{createErr: errors.New("boom")}, sink)
+
+	if _, err := svc.CreateArticle(context.Background(), domain.Article{}); err == nil {
+		t.Fatal("want the error of the wrapped service")
+	}
+	if records := sink.Records(); len(records) != 0 {
+		t.Errorf("got %d records for a failed call, want none", len(records))
+	}
+}
+
+func TestAuditedServiceIgnoresSinkErrors(t *testing.T) {
+	sink := &memorySink{err: errors.New("sink is down")}
+	svc := NewAuditedArticlesService(&fakeArticles{}, sink)
+
+	if _, err := svc.CreateArticle(context.Background(), domain.Article{}); err != nil {
+		t.Fatalf("sink errors must not fail the call, got %v", err)
+	}
+}
+
+// fakeWebhooks and fakeOAuth succeed on every call the tests make.
+type fakeWebhooks struct {
+	Webhooks
+}
+
+func (f *fakeWebhooks) CreateWebhook(ctx context.Context, input domain.WebhookInput) (int, error) {
+	return 4, nil
+}
+
+func (f *fakeWebhooks) GetAllWebhooks(ctx context.Context) ([]domain.Webhook, error) {
+	return nil, nil
+}
+
+func (f *fakeWebhooks) Redeliver(ctx context.Context, webhookId, deliveryId int) error {
+	return nil
+}
+
+type fakeOAuth struct {
+	OAuth
+}
+
+func (f *fakeOAuth) DeleteClient(ctx context.Context, ownerId int, clientId string) error {
+	return nil
+}
+
+func (f *fakeOAuth) Consent(ctx context.Context, userId int, input domain.ConsentInput) (string, error) {
+	return input.RedirectURI, nil
+}
+
+func TestAuditedWebhooksServiceRecordsMutations(t *testing.T) {
+	sink := &memorySink{}
+	svc := NewAuditedWebhooksService(&fakeWebhooks{}, sink)
+
+	ctx := domain.WithActor(context.Background(), 1)
+	if _, err := svc.CreateWebhook(ctx, domain.WebhookInput{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.GetAllWebhooks(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.Redeliver(ctx, 4, 10); err != nil {
+		t.Fatal(err)
+	}
+
+	records := sink.Records()
+	if len(records) != 2 {
+		t.Fatalf("got %d records, want 2", len(records))
+	}
+	for i, action := range []string{domain.AuditActionCreate, domain.AuditActionRedeliver} {
+		got := records[i]
+		if got.Action != action || got.Entity != domain.AuditEntityWebhook || got.EntityId != 4 || got.ActorId != 1 {
+			t.Errorf("got %+v, want %s of webhook 4 by user 1", got, action)
+		}
+	}
+}
+
+func TestAuditedOAuthServiceRecordsClients(t *testing.T) {
+	sink := &memorySink{}
+	svc := NewAuditedOAuthService(&fakeOAuth{}, sink)
+
+	ctx := domain.WithActor(context.Background(), 1)
+	if err := svc.DeleteClient(ctx, 1, "client-1"); err != nil {
+		t.Fatal(err)
+	}
+	denied := domain.ConsentInput{AuthorizeRequest: domain.AuthorizeRequest{ClientId: "client-2"}}
+	if _, err := svc.Consent(ctx, 1, denied); err != nil {
+		t.Fatal(err)
+	}
+	approved := domain.ConsentInput{AuthorizeRequest: domain.AuthorizeRequest{ClientId: "client-3"}, Approve: true}
+	if _, err := svc.Consent(ctx, 1, approved); err != nil {
+		t.Fatal(err)
+	}
+
+	records := sink.Records()
+	if len(records) != 2 {
+		t.Fatalf("got %d records, want 2, a denied consent grants nothing", len(records))
+	}
+	want := []struct{ action, clientId string }{
+		{domain.AuditActionDelete, "client-1"},
+		{domain.AuditActionGrantConsent, "client-3"},
+	}
+	for i, w := range want {
+		got := records[i]
+		if got.Action != w.action || got.Entity != domain.AuditEntityOAuthClient || got.Details != w.clientId {
+			t.Errorf("got %+v, want %s of OAuth client %s", got, w.action, w.clientId)
+		}
+	}
+}
+
+func TestAuditHandler(t *testing.T) {
+	tests := []struct {
+		eventType string
+		action    string
+		actorId   int
+	}{
+		{domain.EventUserRegistered, domain.AuditActionRegister, 5},
+		{domain.EventUserLocked, domain.AuditActionLock, 0},
+		{domain.EventUserEmailChanged, domain.AuditActionChangeEmail, 5},
+		{domain.EventUserPasswordReset, domain.AuditActionChangePassword, 5},
+		{domain.EventUserDeleted, domain.AuditActionDelete, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.eventType, func(t *testing.T) {
+			sink := &memorySink{}
+			at := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+			err := NewAuditHandler(sink)(context.Background(), domain.Event{Type: tt.eventType, AggregateId: 5, CreatedAt: at})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			records := sink.Records()
+			if len(records) != 1 {
+				t.Fatalf("got %d records, want 1", len(records))
+			}
+			want := domain.AuditRecord{
+				Action:    tt.action,
+				Entity:    domain.AuditEntityUser,
+				EntityId:  5,
+				ActorId:   tt.actorId,
+				Timestamp: at,
+			}
+			if records[0] != want {
+				t.Errorf("got %+v, want %+v", records[0], want)
+			}
+		})
+	}
+
+	sink := &memorySink{}
+	if err := NewAuditHandler(sink)(context.Background(), domain.Event{Type: domain.EventArticleCreated}); err != nil {
+		t.Fatal(err)
+	}
+	if len(sink.Records()) != 0 {
+		t.Error("events not about users must be skipped")
+	}
+}
+
+type fakeAuditClient struct {
+	items []domain.AuditLogItem
+}
+
+func (c *fakeAuditClient) SendLogRequest(ctx context.Context, req domain.AuditLogItem) error {
+	c.items = append(c.items, req)
+	return nil
+}
+
+func TestAuditClientSinkMapsRecords(t *testing.T) {
+	client := &fakeAuditClient{}
+	at := time.Now().UTC()
+	err := NewAuditClientSink(client).Record(context.Background(), domain.AuditRecord{
+		Action:         domain.AuditActionAddBookmark,
+		Entity:         domain.AuditEntityArticle,
+		EntityId:       9,
+		ActorId:        1,
+		ImpersonatorId: 2,
+		RequestId:      "req-1",
+		Timestamp:      at,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := domain.AuditLogItem{
+		Action:         audit.ACTION_UPDATE,
+		Entity:         audit.ENTITY_BOOK,
+		EntityId:       9,
+		Timestamp:      at,
+		ActionName:     domain.AuditActionAddBookmark,
+		EntityName:     domain.AuditEntityArticle,
+		ActorId:        1,
+		ImpersonatorId: 2,
+		RequestId:      "req-1",
+	}
+	if len(client.items) != 1 || client.items[0] != want {
+		t.Errorf("got %+v, want %+v", client.items, want)
+	}
+}
+
+func TestMultiSinkWritesToAllSinks(t *testing.T) {
+	failing := &memorySink{err: errors.New("down")}
+	working := &memorySink{}
+
+	err := NewMultiSink(failing, working).Record(context.Background(), domain.AuditRecord{Action: domain.AuditActionCreate})
+	if err == nil {
+		t.Error("want the error of the failing sink")
+	}
+	if len(failing.Records()) != 1 || len(working.Records()) != 1 {
+		t.Error("every sink must get the record")
+	}
+}
@@ -0,0 +1,692 @@
+package service
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/xopxe23/news-server/internal/domain"
+)
+
+type Articles interface {
+	CreateAuthor(ctx context.Context, author domain.Author) (int, error)
+	GetAllAuthors(ctx context.Context) ([]domain.Author, error)
+	GetAuthorById(ctx context.Context, authorId int) (domain.Author, error)
+	GetAuthorsByIds(ctx context.Context, ids []int) ([]domain.Author, error)
+	GetAuthorArticles(ctx context.Context, authorId int, query domain.ArticleQuery) ([]domain.ArticleOutput, error)
+	GetArticlesOfAuthors(ctx context.Context, ids []int, query domain.ArticleQuery) ([]domain.ArticleOutput, error)
+	UpdateAuthor(ctx context.Context, authorId int, input domain.UpdateAuthorInput) error
+	DeleteAuthor(ctx context.Context, authorId int) error
+
+	CreateArticle(ctx context.Context, input domain.Article) (int, error)
+	GetAllArticles(ctx context.Context, query domain.ArticleQuery) ([]domain.ArticleOutput, error)
+	GetArticleById(ctx context.Context, articleId int) (domain.ArticleOutput, error)
+	AddArticleInBookmarks(ctx context.Context, articleId, userId int) error
+	UpdateArticle(ctx context.Context, articleId int, input domain.UpdateArticleInput) error
+	DeleteArticle(ctx context.Context, articleId int) error
+}
+
+type Users interface {
+	SignUp(ctx context.Context, input domain.SignUpInput) error
+	SignIn(ctx context.Context, input domain.SignInInput) (domain.SignInResult, error)
+	VerifyMFA(ctx context.Context, input domain.MFAVerifyInput) (string, string, error)
+	RefreshTokens(ctx context.Context, token string) (string, string, error)
+	ParseToken(ctx context.Context, token string) (int, error)
+	GetBookmarks(ctx context.Context, userId int, query domain.ArticleQuery) ([]domain.ArticleOutput, error)
+	IsAdmin(ctx context.Context, userId int) (bool, error)
+	SetRole(ctx context.Context, userId int, input domain.SetRoleInput) error
+	BootstrapAdmin(ctx context.Context, email string) (int, bool, error)
+	UnlockUser(ctx context.Context, userId int) error
+	EnrollTOTP(ctx context.Context, userId int) (domain.TOTPEnrollment, error)
+	ConfirmTOTP(ctx context.Context, userId int, input domain.TOTPCodeInput) (domain.RecoveryCodes, error)
+	DisableTOTP(ctx context.Context, userId int, input domain.TOTPCodeInput) error
+	CreateAPIKey(ctx context.Context, userId int, input domain.CreateAPIKeyInput) (domain.CreatedAPIKey, error)
+	GetAPIKeys(ctx context.Context, userId int) ([]domain.APIKey, error)
+	RevokeAPIKey(ctx context.Context, userId, keyId int) error
+	AuthenticateAPIKey(ctx context.Context, raw string) (domain.APIKey, error)
+	StartOIDC(ctx context.Context) (domain.OIDCLogin, error)
+	FinishOIDC(ctx context.Context, callback domain.OIDCCallback) (domain.SignInResult, error)
+	GetProfile(ctx context.Context, userId int) (domain.Profile, error)
+	UpdateProfile(ctx context.Context, userId int, input domain.UpdateProfileInput) (domain.Profile, error)
+	ChangePassword(ctx context.Context, userId int, input domain.ChangePasswordInput) (string, string, error)
+	RequestEmailChange(ctx context.Context, userId int, input domain.ChangeEmailInput) error
+	ConfirmEmailChange(ctx context.Context, input domain.ConfirmEmailInput) error
+	ListUsers(ctx context.Context, filter domain.UserFilter) (domain.UsersPage, error)
+	GetUserDetails(ctx context.Context, userId int) (domain.AdminUserDetails, error)
+	DisableUser(ctx context.Context, userId int) error
+	EnableUser(ctx context.Context, userId int) error
+	LogoutUser(ctx context.Context, userId int) error
+	RequestPasswordReset(ctx context.Context, userId int) error
+	ResetPassword(ctx context.Context, input domain.ResetPasswordInput) error
+	CheckActive(ctx context.Context, userId int) error
+	ParseAccessToken(ctx context.Context, token string) (domain.Principal, error)
+	Impersonate(ctx context.Context, adminId, userId int) (domain.ImpersonationToken, error)
+	StopImpersonation(ctx context.Context, principal domain.Principal) error
+}
+
+type Privacy interface {
+	RequestExport(ctx context.Context, userId int) (domain.DataExport, error)
+	GetExport(ctx context.Context, userId, exportId int) (domain.DataExport, error)
+	GetExportArchive(ctx context.Context, userId, exportId int) ([]byte, error)
+	ScheduleDeletion(ctx context.Context, userId int, input domain.DeleteAccountInput) (domain.AccountDeletion, error)
+	CancelDeletion(ctx context.Context, userId int) error
+}
+
+type Media interface {
+	Upload(ctx context.Context, ownerId int, r io.Reader) (domain.Media, error)
+	GetMedia(ctx context.Context, mediaId int) (domain.Media, error)
+	OpenMedia(ctx context.Context, mediaId int, thumbnail bool) (domain.Media, io.ReadCloser, error)
+	DeleteMedia(ctx context.Context, userId, mediaId int) error
+	AttachMedia(ctx context.Context, articleId int, input domain.AttachMediaInput) error
+	DetachMedia(ctx context.Context, articleId, mediaId int) error
+	GetArticleMedia(ctx context.Context, articleId int) ([]domain.ArticleMedia, error)
+}
+
+type Webhooks interface {
+	CreateWebhook(ctx context.Context, input domain.WebhookInput) (int, error)
+	GetAllWebhooks(ctx context.Context) ([]domain.Webhook, error)
+	GetWebhookById(ctx context.Context, id int) (domain.Webhook, error)
+	UpdateWebhook(ctx context.Context, id int, input domain.UpdateWebhookInput) error
+	DeleteWebhook(ctx context.Context, id int) error
+	GetDeliveries(ctx context.Context, webhookId int) ([]domain.WebhookDelivery, error)
+	Redeliver(ctx context.Context, webhookId, deliveryId int) error
+}
+
+type OAuth interface {
+	RegisterClient(ctx context.Context, ownerId int, input domain.OAuthClientInput) (domain.RegisteredOAuthClient, error)
+	GetClients(ctx context.Context, ownerId int) ([]domain.OAuthClient, error)
+	DeleteClient(ctx context.Context, ownerId int, clientId string) error
+	Authorize(ctx context.Context, req domain.AuthorizeRequest) (domain.Consent, error)
+	Consent(ctx context.Context, userId int, input domain.ConsentInput) (string, error)
+	Token(ctx context.Context, req domain.TokenRequest) (domain.IssuedToken, error)
+	Revoke(ctx context.Context, clientId, clientSecret, token string) error
+	Introspect(ctx context.Context, clientId, clientSecret, token string) (domain.TokenIntrospection, error)
+	IsAccessToken(raw string) bool
+	AuthenticateToken(ctx context.Context, raw string) (domain.OAuthToken, error)
+}
+
+// record reports a successful operation to the sink. The actor, the impersonating admin and the request are taken from ctx.
+// Audit failures are logged and never fail the operation itself.
+func record(ctx context.Context, sink AuditSink, action, entity string, entityId int) {
+	recordAs(ctx, sink, domain.ActorFrom(ctx), action, entity, entityId)
+}
+
+func recordAs(ctx context.Context, sink AuditSink, actorId int, action, entity string, entityId int) {
+	send(ctx, sink, domain.AuditRecord{Action: action, Entity: entity, EntityId: entityId, ActorId: actorId})
+}
+
+// recordDetails is record for entities known by something else than their id, like OAuth clients.
+func recordDetails(ctx context.Context, sink AuditSink, action, entity string, entityId int, details string) {
+	send(ctx, sink, domain.AuditRecord{
+		Action:   action,
+		Entity:   entity,
+		EntityId: entityId,
+		ActorId:  domain.ActorFrom(ctx),
+		Details:  details,
+	})
+}
+
+func send(ctx context.Context, sink AuditSink, record domain.AuditRecord) {
+	record.ImpersonatorId = domain.ImpersonatorFrom(ctx)
+	record.RequestId = domain.RequestIdFrom(ctx)
+	record.Timestamp = time.Now().UTC()
+	if err := sink.Record(ctx, record); err != nil {
+		logrus.WithFields(logrus.Fields{
+			"method": "audit.record",
+			"action": record.Action,
+			"entity": record.Entity,
+		}).Error("failed to record audit: ", err)
+	}
+}
+
+// AuditedArticlesService records every mutating call of the wrapped service.
+// Like the other decorators it spells out every method instead of embedding the interface,
+// so a method added to the interface doesn't compile until it is decided whether to audit it.
+type AuditedArticlesService struct {
+	next Articles
+	sink AuditSink
+}
+
+func NewAuditedArticlesService(next Articles, sink AuditSink) *AuditedArticlesService {
+	return &AuditedArticlesService{next: next, sink: sink}
+}
+
+func (s *AuditedArticlesService) CreateAuthor(ctx context.Context, author domain.Author) (int, error) {
+	id, err := s.next.CreateAuthor(ctx, author)
+	if err == nil {
+		record(ctx, s.sink, domain.AuditActionCreate, domain.AuditEntityAuthor, id)
+	}
+	return id, err
+}
+
+func (s *AuditedArticlesService) GetAllAuthors(ctx context.Context) ([]domain.Author, error) {
+	return s.next.GetAllAuthors(ctx)
+}
+
+func (s *AuditedArticlesService) GetAuthorById(ctx context.Context, authorId int) (domain.Author, error) {
+	return s.next.GetAuthorById(ctx, authorId)
+}
+
+func (s *AuditedArticlesService) GetAuthorsByIds(ctx context.Context, ids []int) ([]domain.Author, error) {
+	return s.next.GetAuthorsByIds(ctx, ids)
+}
+
+func (s *AuditedArticlesService) GetAuthorArticles(ctx context.Context, authorId int, query domain.ArticleQuery) ([]domain.ArticleOutput, error) {
+	return s.next.GetAuthorArticles(ctx, authorId, query)
+}
+
+func (s *AuditedArticlesService) GetArticlesOfAuthors(ctx context.Context, ids []int, query domain.ArticleQuery) ([]domain.ArticleOutput, error) {
+	return s.next.GetArticlesOfAuthors(ctx, ids, query)
+}
+
+func (s *AuditedArticlesService) UpdateAuthor(ctx context.Context, authorId int, input domain.UpdateAuthorInput) error {
+	err := s.next.UpdateAuthor(ctx, authorId, input)
+	if err == nil {
+		record(ctx, s.sink, domain.AuditActionUpdate, domain.AuditEntityAuthor, authorId)
+	}
+	return err
+}
+
+func (s *AuditedArticlesService) DeleteAuthor(ctx context.Context, authorId int) error {
+	err := s.next.DeleteAuthor(ctx, authorId)
+	if err == nil {
+		record(ctx, s.sink, domain.AuditActionDelete, domain.AuditEntityAuthor, authorId)
+	}
+	return err
+}
+
+func (s *AuditedArticlesService) CreateArticle(ctx context.Context, input domain.Article) (int, error) {
+	id, err := s.next.CreateArticle(ctx, input)
+	if err == nil {
+		record(ctx, s.sink, domain.AuditActionCreate, domain.AuditEntityArticle, id)
+	}
+	return id, err
+}
+
+func (s *AuditedArticlesService) GetAllArticles(ctx context.Context, query domain.ArticleQuery) ([]domain.ArticleOutput, error) {
+	return s.next.GetAllArticles(ctx, query)
+}
+
+func (s *AuditedArticlesService) GetArticleById(ctx context.Context, articleId int) (domain.ArticleOutput, error) {
+	return s.next.GetArticleById(ctx, articleId)
+}
+
+func (s *AuditedArticlesService) AddArticleInBookmarks(ctx context.Context, articleId, userId int) error {
+	err := s.next.AddArticleInBookmarks(ctx, articleId, userId)
+	if err == nil {
+		record(ctx, s.sink, domain.AuditActionAddBookmark, domain.AuditEntityArticle, articleId)
+	}
+	return err
+}
+
+func (s *AuditedArticlesService) UpdateArticle(ctx context.Context, articleId int, input domain.UpdateArticleInput) error {
+	err := s.next.UpdateArticle(ctx, articleId, input)
+	if err == nil {
+		record(ctx, s.sink, domain.AuditActionUpdate, domain.AuditEntityArticle, articleId)
+	}
+	return err
+}
+
+func (s *AuditedArticlesService) DeleteArticle(ctx context.Context, articleId int) error {
+	err := s.next.DeleteArticle(ctx, articleId)
+	if err == nil {
+		record(ctx, s.sink, domain.AuditActionDelete, domain.AuditEntityArticle, articleId)
+	}
+	return err
+}
+
+// AuditedUsersService records sign-ins, token refreshes, role changes, unlocks, profile, password,
+// two-factor and API key changes of the wrapped service.
+// Registrations, lockouts and email changes are audited through the outbox, see NewAuditHandler.
+type AuditedUsersService struct {
+	next Users
+	sink AuditSink
+}
+
+func NewAuditedUsersService(next Users, sink AuditSink) *AuditedUsersService {
+	return &AuditedUsersService{next: next, sink: sink}
+}
+
+func (s *AuditedUsersService) SignUp(ctx context.Context, input domain.SignUpInput) error {
+	return s.next.SignUp(ctx, input)
+}
+
+// SignIn is audited once tokens are issued, which for users with two-factor authentication happens in VerifyMFA.
+func (s *AuditedUsersService) SignIn(ctx context.Context, input domain.SignInInput) (domain.SignInResult, error) {
+	result, err := s.next.SignIn(ctx, input)
+	if err == nil && result.AccessToken != "" {
+		s.recordSession(ctx, domain.AuditActionSignIn, result.AccessToken)
+	}
+	return result, err
+}
+
+func (s *AuditedUsersService) VerifyMFA(ctx context.Context, input domain.MFAVerifyInput) (string, string, error) {
+	accessToken, refreshToken, err := s.next.VerifyMFA(ctx, input)
+	if err == nil {
+		s.recordSession(ctx, domain.AuditActionSignIn, accessToken)
+	}
+	return accessToken, refreshToken, err
+}
+
+func (s *AuditedUsersService) RefreshTokens(ctx context.Context, token string) (string, string, error) {
+	accessToken, refreshToken, err := s.next.RefreshTokens(ctx, token)
+	if err == nil {
+		s.recordSession(ctx, domain.AuditActionRefresh, accessToken)
+	}
+	return accessToken, refreshToken, err
+}
+
+func (s *AuditedUsersService) ParseToken(ctx context.Context, token string) (int, error) {
+	return s.next.ParseToken(ctx, token)
+}
+
+func (s *AuditedUsersService) GetBookmarks(ctx context.Context, userId int, query domain.ArticleQuery) ([]domain.ArticleOutput, error) {
+	return s.next.GetBookmarks(ctx, userId, query)
+}
+
+func (s *AuditedUsersService) IsAdmin(ctx context.Context, userId int) (bool, error) {
+	return s.next.IsAdmin(ctx, userId)
+}
+
+func (s *AuditedUsersService) SetRole(ctx context.Context, userId int, input domain.SetRoleInput) error {
+	err := s.next.SetRole(ctx, userId, input)
+	if err == nil {
+		record(ctx, s.sink, domain.AuditActionChangeRole, domain.AuditEntityUser, userId)
+	}
+	return err
+}
+
+func (s *AuditedUsersService) BootstrapAdmin(ctx context.Context, email string) (int, bool, error) {
+	userId, changed, err := s.next.BootstrapAdmin(ctx, email)
+	if err == nil && changed {
+		record(ctx, s.sink, domain.AuditActionChangeRole, domain.AuditEntityUser, userId)
+	}
+	return userId, changed, err
+}
+
+func (s *AuditedUsersService) UnlockUser(ctx context.Context, userId int) error {
+	err := s.next.UnlockUser(ctx, userId)
+	if err == nil {
+		record(ctx, s.sink, domain.AuditActionUnlock, domain.AuditEntityUser, userId)
+	}
+	return err
+}
+
+func (s *AuditedUsersService) EnrollTOTP(ctx context.Context, userId int) (domain.TOTPEnrollment, error) {
+	return s.next.EnrollTOTP(ctx, userId)
+}
+
+func (s *AuditedUsersService) ConfirmTOTP(ctx context.Context, userId int, input domain.TOTPCodeInput) (domain.RecoveryCodes, error) {
+	codes, err := s.next.ConfirmTOTP(ctx, userId, input)
+	if err == nil {
+		record(ctx, s.sink, domain.AuditActionEnableMFA, domain.AuditEntityUser, userId)
+	}
+	return codes, err
+}
+
+func (s *AuditedUsersService) DisableTOTP(ctx context.Context, userId int, input domain.TOTPCodeInput) error {
+	err := s.next.DisableTOTP(ctx, userId, input)
+	if err == nil {
+		record(ctx, s.sink, domain.AuditActionDisableMFA, domain.AuditEntityUser, userId)
+	}
+	return err
+}
+
+func (s *AuditedUsersService) CreateAPIKey(ctx context.Context, userId int, input domain.CreateAPIKeyInput) (domain.CreatedAPIKey, error) {
+	key, err := s.next.CreateAPIKey(ctx, userId, input)
+	if err == nil {
+		record(ctx, s.sink, domain.AuditActionCreate, domain.AuditEntityAPIKey, key.Id)
+	}
+	return key, err
+}
+
+func (s *AuditedUsersService) GetAPIKeys(ctx context.Context, userId int) ([]domain.APIKey, error) {
+	return s.next.GetAPIKeys(ctx, userId)
+}
+
+func (s *AuditedUsersService) RevokeAPIKey(ctx context.Context, userId, keyId int) error {
+	err := s.next.RevokeAPIKey(ctx, userId, keyId)
+	if err == nil {
+		record(ctx, s.sink, domain.AuditActionDelete, domain.AuditEntityAPIKey, keyId)
+	}
+	return err
+}
+
+func (s *AuditedUsersService) AuthenticateAPIKey(ctx context.Context, raw string) (domain.APIKey, error) {
+	return s.next.AuthenticateAPIKey(ctx, raw)
+}
+
+func (s *AuditedUsersService) StartOIDC(ctx context.Context) (domain.OIDCLogin, error) {
+	return s.next.StartOIDC(ctx)
+}
+
+func (s *AuditedUsersService) FinishOIDC(ctx context.Context, callback domain.OIDCCallback) (domain.SignInResult, error) {
+	result, err := s.next.FinishOIDC(ctx, callback)
+	if err == nil && result.AccessToken != "" {
+		s.recordSession(ctx, domain.AuditActionSignIn, result.AccessToken)
+	}
+	return result, err
+}
+
+func (s *AuditedUsersService) GetProfile(ctx context.Context, userId int) (domain.Profile, error) {
+	return s.next.GetProfile(ctx, userId)
+}
+
+func (s *AuditedUsersService) UpdateProfile(ctx context.Context, userId int, input domain.UpdateProfileInput) (domain.Profile, error) {
+	profile, err := s.next.UpdateProfile(ctx, userId, input)
+	if err == nil {
+		record(ctx, s.sink, domain.AuditActionUpdate, domain.AuditEntityUser, userId)
+	}
+	return profile, err
+}
+
+func (s *AuditedUsersService) ChangePassword(ctx context.Context, userId int, input domain.ChangePasswordInput) (string, string, error) {
+	accessToken, refreshToken, err := s.next.ChangePassword(ctx, userId, input)
+	if err == nil {
+		record(ctx, s.sink, domain.AuditActionChangePassword, domain.AuditEntityUser, userId)
+	}
+	return accessToken, refreshToken, err
+}
+
+func (s *AuditedUsersService) RequestEmailChange(ctx context.Context, userId int, input domain.ChangeEmailInput) error {
+	return s.next.RequestEmailChange(ctx, userId, input)
+}
+
+func (s *AuditedUsersService) ConfirmEmailChange(ctx context.Context, input domain.ConfirmEmailInput) error {
+	return s.next.ConfirmEmailChange(ctx, input)
+}
+
+func (s *AuditedUsersService) ListUsers(ctx context.Context, filter domain.UserFilter) (domain.UsersPage, error) {
+	return s.next.ListUsers(ctx, filter)
+}
+
+func (s *AuditedUsersService) GetUserDetails(ctx context.Context, userId int) (domain.AdminUserDetails, error) {
+	return s.next.GetUserDetails(ctx, userId)
+}
+
+func (s *AuditedUsersService) DisableUser(ctx context.Context, userId int) error {
+	err := s.next.DisableUser(ctx, userId)
+	if err == nil {
+		record(ctx, s.sink, domain.AuditActionDisable, domain.AuditEntityUser, userId)
+	}
+	return err
+}
+
+func (s *AuditedUsersService) EnableUser(ctx context.Context, userId int) error {
+	err := s.next.EnableUser(ctx, userId)
+	if err == nil {
+		record(ctx, s.sink, domain.AuditActionEnable, domain.AuditEntityUser, userId)
+	}
+	return err
+}
+
+func (s *AuditedUsersService) LogoutUser(ctx context.Context, userId int) error {
+	err := s.next.LogoutUser(ctx, userId)
+	if err == nil {
+		record(ctx, s.sink, domain.AuditActionForceLogout, domain.AuditEntityUser, userId)
+	}
+	return err
+}
+
+func (s *AuditedUsersService) RequestPasswordReset(ctx context.Context, userId int) error {
+	err := s.next.RequestPasswordReset(ctx, userId)
+	if err == nil {
+		record(ctx, s.sink, domain.AuditActionResetPassword, domain.AuditEntityUser, userId)
+	}
+	return err
+}
+
+func (s *AuditedUsersService) ResetPassword(ctx context.Context, input domain.ResetPasswordInput) error {
+	return s.next.ResetPassword(ctx, input)
+}
+
+func (s *AuditedUsersService) CheckActive(ctx context.Context, userId int) error {
+	return s.next.CheckActive(ctx, userId)
+}
+
+func (s *AuditedUsersService) ParseAccessToken(ctx context.Context, token string) (domain.Principal, error) {
+	return s.next.ParseAccessToken(ctx, token)
+}
+
+func (s *AuditedUsersService) Impersonate(ctx context.Context, adminId, userId int) (domain.ImpersonationToken, error) {
+	token, err := s.next.Impersonate(ctx, adminId, userId)
+	if err == nil {
+		recordAs(ctx, s.sink, adminId, domain.AuditActionStartImpersonation, domain.AuditEntityUser, userId)
+	}
+	return token, err
+}
+
+// StopImpersonation is attributed to the admin, not to the user they acted as.
+func (s *AuditedUsersService) StopImpersonation(ctx context.Context, principal domain.Principal) error {
+	err := s.next.StopImpersonation(ctx, principal)
+	if err == nil {
+		recordAs(ctx, s.sink, principal.ImpersonatorId, domain.AuditActionStopImpersonation, domain.AuditEntityUser, principal.UserId)
+	}
+	return err
+}
+
+// recordSession audits a freshly issued session. These requests are anonymous,
+// so the actor is the owner of the new access token.
+func (s *AuditedUsersService) recordSession(ctx context.Context, action, accessToken string) {
+	userId, err := s.next.ParseToken(ctx, accessToken)
+	if err != nil {
+		logrus.WithField("method", "AuditedUsers.recordSession").Error(err)
+		return
+	}
+	recordAs(ctx, s.sink, userId, action, domain.AuditEntityUser, userId)
+}
+
+// AuditedPrivacyService records export requests and changes of scheduled account deletions.
+type AuditedPrivacyService struct {
+	next Privacy
+	sink AuditSink
+}
+
+func NewAuditedPrivacyService(next Privacy, sink AuditSink) *AuditedPrivacyService {
+	return &AuditedPrivacyService{next: next, sink: sink}
+}
+
+func (s *AuditedPrivacyService) RequestExport(ctx context.Context, userId int) (domain.DataExport, error) {
+	export, err := s.next.RequestExport(ctx, userId)
+	if err == nil {
+		record(ctx, s.sink, domain.AuditActionRequestExport, domain.AuditEntityUser, userId)
+	}
+	return export, err
+}
+
+func (s *AuditedPrivacyService) GetExport(ctx context.Context, userId, exportId int) (domain.DataExport, error) {
+	return s.next.GetExport(ctx, userId, exportId)
+}
+
+func (s *AuditedPrivacyService) GetExportArchive(ctx context.Context, userId, exportId int) ([]byte, error) {
+	return s.next.GetExportArchive(ctx, userId, exportId)
+}
+
+func (s *AuditedPrivacyService) ScheduleDeletion(ctx context.Context, userId int, input domain.DeleteAccountInput) (domain.AccountDeletion, error) {
+	deletion, err := s.next.ScheduleDeletion(ctx, userId, input)
+	if err == nil {
+		record(ctx, s.sink, domain.AuditActionScheduleDelete, domain.AuditEntityUser, userId)
+	}
+	return deletion, err
+}
+
+func (s *AuditedPrivacyService) CancelDeletion(ctx context.Context, userId int) error {
+	err := s.next.CancelDeletion(ctx, userId)
+	if err == nil {
+		record(ctx, s.sink, domain.AuditActionCancelDelete, domain.AuditEntityUser, userId)
+	}
+	return err
+}
+
+// AuditedMediaService records uploads and deletions of media. Attaching and detaching change the article.
+type AuditedMediaService struct {
+	next Media
+	sink AuditSink
+}
+
+func NewAuditedMediaService(next Media, sink AuditSink) *AuditedMediaService {
+	return &AuditedMediaService{next: next, sink: sink}
+}
+
+func (s *AuditedMediaService) Upload(ctx context.Context, ownerId int, r io.Reader) (domain.Media, error) {
+	media, err := s.next.Upload(ctx, ownerId, r)
+	if err == nil {
+		record(ctx, s.sink, domain.AuditActionCreate, domain.AuditEntityMedia, media.Id)
+	}
+	return media, err
+}
+
+func (s *AuditedMediaService) GetMedia(ctx context.Context, mediaId int) (domain.Media, error) {
+	return s.next.GetMedia(ctx, mediaId)
+}
+
+func (s *AuditedMediaService) OpenMedia(ctx context.Context, mediaId int, thumbnail bool) (domain.Media, io.ReadCloser, error) {
+	return s.next.OpenMedia(ctx, mediaId, thumbnail)
+}
+
+func (s *AuditedMediaService) DeleteMedia(ctx context.Context, userId, mediaId int) error {
+	err := s.next.DeleteMedia(ctx, userId, mediaId)
+	if err == nil {
+		record(ctx, s.sink, domain.AuditActionDelete, domain.AuditEntityMedia, mediaId)
+	}
+	return err
+}
+
+func (s *AuditedMediaService) AttachMedia(ctx context.Context, articleId int, input domain.AttachMediaInput) error {
+	err := s.next.AttachMedia(ctx, articleId, input)
+	if err == nil {
+		record(ctx, s.sink, domain.AuditActionUpdate, domain.AuditEntityArticle, articleId)
+	}
+	return err
+}
+
+func (s *AuditedMediaService) DetachMedia(ctx context.Context, articleId, mediaId int) error {
+	err := s.next.DetachMedia(ctx, articleId, mediaId)
+	if err == nil {
+		record(ctx, s.sink, domain.AuditActionUpdate, domain.AuditEntityArticle, articleId)
+	}
+	return err
+}
+
+func (s *AuditedMediaService) GetArticleMedia(ctx context.Context, articleId int) ([]domain.ArticleMedia, error) {
+	return s.next.GetArticleMedia(ctx, articleId)
+}
+
+// AuditedWebhooksService records changes of webhooks and manual redeliveries.
+type AuditedWebhooksService struct {
+	next Webhooks
+	sink AuditSink
+}
+
+func NewAuditedWebhooksService(next Webhooks, sink AuditSink) *AuditedWebhooksService {
+	return &AuditedWebhooksService{next: next, sink: sink}
+}
+
+func (s *AuditedWebhooksService) CreateWebhook(ctx context.Context, input domain.WebhookInput) (int, error) {
+	id, err := s.next.CreateWebhook(ctx, input)
+	if err == nil {
+		record(ctx, s.sink, domain.AuditActionCreate, domain.AuditEntityWebhook, id)
+	}
+	return id, err
+}
+
+func (s *AuditedWebhooksService) GetAllWebhooks(ctx context.Context) ([]domain.Webhook, error) {
+	return s.next.GetAllWebhooks(ctx)
+}
+
+func (s *AuditedWebhooksService) GetWebhookById(ctx context.Context, id int) (domain.Webhook, error) {
+	return s.next.GetWebhookById(ctx, id)
+}
+
+func (s *AuditedWebhooksService) UpdateWebhook(ctx context.Context, id int, input domain.UpdateWebhookInput) error {
+	err := s.next.UpdateWebhook(ctx, id, input)
+	if err == nil {
+		record(ctx, s.sink, domain.AuditActionUpdate, domain.AuditEntityWebhook, id)
+	}
+	return err
+}
+
+func (s *AuditedWebhooksService) DeleteWebhook(ctx context.Context, id int) error {
+	err := s.next.DeleteWebhook(ctx, id)
+	if err == nil {
+		record(ctx, s.sink, domain.AuditActionDelete, domain.AuditEntityWebhook, id)
+	}
+	return err
+}
+
+func (s *AuditedWebhooksService) GetDeliveries(ctx context.Context, webhookId int) ([]domain.WebhookDelivery, error) {
+	return s.next.GetDeliveries(ctx, webhookId)
+}
+
+func (s *AuditedWebhooksService) Redeliver(ctx context.Context, webhookId, deliveryId int) error {
+	err := s.next.Redeliver(ctx, webhookId, deliveryId)
+	if err == nil {
+		record(ctx, s.sink, domain.AuditActionRedeliver, domain.AuditEntityWebhook, webhookId)
+	}
+	return err
+}
+
+// AuditedOAuthService records registrations and deletions of OAuth clients and the consents users give them.
+// Token requests come from the clients themselves and are not audited.
+type AuditedOAuthService struct {
+	next OAuth
+	sink AuditSink
+}
+
+func NewAuditedOAuthService(next OAuth, sink AuditSink) *AuditedOAuthService {
+	return &AuditedOAuthService{next: next, sink: sink}
+}
+
+func (s *AuditedOAuthService) RegisterClient(ctx context.Context, ownerId int, input domain.OAuthClientInput) (domain.RegisteredOAuthClient, error) {
+	client, err := s.next.RegisterClient(ctx, ownerId, input)
+	if err == nil {
+		recordDetails(ctx, s.sink, domain.AuditActionCreate, domain.AuditEntityOAuthClient, client.Id, client.ClientId)
+	}
+	return client, err
+}
+
+func (s *AuditedOAuthService) GetClients(ctx context.Context, ownerId int) ([]domain.OAuthClient, error) {
+	return s.next.GetClients(ctx, ownerId)
+}
+
+func (s *AuditedOAuthService) DeleteClient(ctx context.Context, ownerId int, clientId string) error {
+	err := s.next.DeleteClient(ctx, ownerId, clientId)
+	if err == nil {
+		recordDetails(ctx, s.sink, domain.AuditActionDelete, domain.AuditEntityOAuthClient, 0, clientId)
+	}
+	return err
+}
+
+func (s *AuditedOAuthService) Authorize(ctx context.Context, req domain.AuthorizeRequest) (domain.Consent, error) {
+	return s.next.Authorize(ctx, req)
+}
+
+// Consent is audited only when the user approves, a denial grants nothing.
+func (s *AuditedOAuthService) Consent(ctx context.Context, userId int, input domain.ConsentInput) (string, error) {
+	redirectTo, err := s.next.Consent(ctx, userId, input)
+	if err == nil && input.Approve {
+		recordDetails(ctx, s.sink, domain.AuditActionGrantConsent, domain.AuditEntityOAuthClient, 0, input.ClientId)
+	}
+	return redirectTo, err
+}
+
+func (s *AuditedOAuthService) Token(ctx context.Context, req domain.TokenRequest) (domain.IssuedToken, error) {
+	return s.next.Token(ctx, req)
+}
+
+func (s *AuditedOAuthService) Revoke(ctx context.Context, clientId, clientSecret, token string) error {
+	return s.next.Revoke(ctx, clientId, clientSecret, token)
+}
+
+func (s *AuditedOAuthService) Introspect(ctx context.Context, clientId, clientSecret, token string) (domain.TokenIntrospection, error) {
+	return s.next.Introspect(ctx, clientId, clientSecret, token)
+}
+
+func (s *AuditedOAuthService) IsAccessToken(raw string) bool {
+	return s.next.IsAccessToken(raw)
+}
+
+func (s *AuditedOAuthService) AuthenticateToken(ctx context.Context, raw string) (domain.OAuthToken, error) {
+	return s.next.AuthenticateToken(ctx, raw)
+}
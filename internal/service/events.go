@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/xopxe23/news-server/internal/domain"
+)
+
+type Transactor interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type Outbox interface {
+	Add(ctx context.Context, event domain.Event) error
+}
+
+type OutboxRepository interface {
+	Outbox
+	ClaimUnpublished(ctx context.Context, limit int, lease time.Duration) ([]domain.Event, error)
+	MarkPublished(ctx context.Context, id int64) error
+	MarkFailed(ctx context.Context, id int64, reason string, retryIn time.Duration) error
+	IsProcessed(ctx context.Context, subscriber, key string) (bool, error)
+	MarkProcessed(ctx context.Context, subscriber, key string) error
+}
+
+type EventHandler func(ctx context.Context, event domain.Event) error
+
+type subscription struct {
+	name    string
+	handler EventHandler
+	events  map[string]bool
+}
+
+// EventBus keeps the in-process subscribers of domain events.
+type EventBus struct {
+	subscriptions []subscription
+}
+
+func NewEventBus() *EventBus {
+	return &EventBus{}
+}
+
+// Subscribe registers handler for the given event types. The name identifies the subscriber
+// when deduplicating deliveries, so it must be unique and stable across restarts.
+func (b *EventBus) Subscribe(name string, handler EventHandler, eventTypes ...string) {
+	events := make(map[string]bool, len(eventTypes))
+	for _, t := range eventTypes {
+		events[t] = true
+	}
+	b.subscriptions = append(b.subscriptions, subscription{name: name, handler: handler, events: events})
+}
+
+func (b *EventBus) subscribers(eventType string) []subscription {
+	var subs []subscription
+	for _, s := range b.subscriptions {
+		if s.events[eventType] {
+			subs = append(subs, s)
+		}
+	}
+	return subs
+}
+
+type RelayConfig struct {
+	PollInterval time.Duration
+	BatchSize    int
+	Lease        time.Duration
+	MaxBackoff   time.Duration
+}
+
+// OutboxRelay reads events from the outbox and hands them to the bus subscribers.
+// An event is retried until every subscriber has handled it, and a subscriber which
+// has handled an event is not called with it again.
+type OutboxRelay struct {
+	repo OutboxRepository
+	bus  *EventBus
+	cfg  RelayConfig
+}
+
+func NewOutboxRelay(repo OutboxRepository, bus *EventBus, cfg RelayConfig) *OutboxRelay {
+	return &OutboxRelay{repo: repo, bus: bus, cfg: cfg}
+}
+
+// Run relays events until ctx is cancelled.
+func (r *OutboxRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		r.relay(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *OutboxRelay) relay(ctx context.Context) {
+	events, err := r.repo.ClaimUnpublished(ctx, r.cfg.BatchSize, r.cfg.Lease)
+	if err != nil {
+		logrus.WithField("method", "OutboxRelay.relay").Error(err)
+		return
+	}
+
+	for _, event := range events {
+		if err := r.publish(ctx, event); err != nil {
+			logrus.WithFields(logrus.Fields{
+				"method": "OutboxRelay.relay",
+				"event":  event.Type,
+				"key":    event.IdempotencyKey,
+			}).Error(err)
+
+			if err := r.repo.MarkFailed(ctx, event.Id, err.Error(), r.backoff(event.Attempts+1)); err != nil {
+				logrus.WithField("method", "OutboxRelay.relay").Error(err)
+			}
+			continue
+		}
+
+		if err := r.repo.MarkPublished(ctx, event.Id); err != nil {
+			logrus.WithField("method", "OutboxRelay.relay").Error(err)
+		}
+	}
+}
+
+func (r *OutboxRelay) publish(ctx context.Context, event domain.Event) error {
+	var lastErr error
+	for _, sub := range r.bus.subscribers(event.Type) {
+		processed, err := r.repo.IsProcessed(ctx, sub.name, event.IdempotencyKey)
+		if err != nil {
+			return err
+		}
+		if processed {
+			continue
+		}
+
+		if err := sub.handler(ctx, event); err != nil {
+			lastErr = err
+			continue
+		}
+
+		if err := r.repo.MarkProcessed(ctx, sub.name, event.IdempotencyKey); err != nil {
+			return err
+		}
+	}
+	return lastErr
+}
+
+func (r *OutboxRelay) backoff(attempt int) time.Duration {
+	delay := r.cfg.PollInterval
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= r.cfg.MaxBackoff {
+			return r.cfg.MaxBackoff
+		}
+	}
+	return delay
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/xopxe23/news-server/internal/domain"
+)
+
+// impersonationTTL is how long an impersonation token works. Support sessions are meant to be short.
+const impersonationTTL = 15 * time.Minute
+
+type ImpersonationsRepository interface {
+	Create(ctx context.Context, adminId, userId int, expiresAt time.Time) (int, error)
+	GetById(ctx context.Context, id int) (domain.Impersonation, error)
+	End(ctx context.Context, id int, at time.Time) error
+}
+
+// Impersonate issues the admin an access token of the user. The token carries the admin's id,
+// it can't be refreshed and is refused by routes managing the account.
+func (s *UsersService) Impersonate(ctx context.Context, adminId, userId int) (domain.ImpersonationToken, error) {
+	if adminId == userId {
+		return domain.ImpersonationToken{}, domain.InvalidInput("you can't impersonate yourself", nil)
+	}
+
+	role, err := s.repo.GetRole(ctx, userId)
+	if err != nil {
+		return domain.ImpersonationToken{}, err
+	}
+	if role == domain.RoleAdmin {
+		return domain.ImpersonationToken{}, domain.Forbidden("admins can't be impersonated")
+	}
+	if err := s.CheckActive(ctx, userId); err != nil {
+		return domain.ImpersonationToken{}, domain.Conflict("the user is disabled")
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(impersonationTTL)
+	id, err := s.impersonations.Create(ctx, adminId, userId, expiresAt)
+	if err != nil {
+		return domain.ImpersonationToken{}, err
+	}
+
+	t := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": strconv.Itoa(userId),
+		"typ": tokenTypeAccess,
+		"imp": strconv.Itoa(adminId),
+		"sid": strconv.Itoa(id),
+		"iat": now.Unix(),
+		"exp": expiresAt.Unix(),
+	})
+	token, err := t.SignedString(s.hmacSecret)
+	if err != nil {
+		return domain.ImpersonationToken{}, err
+	}
+	return domain.ImpersonationToken{Token: token, ExpiresAt: expiresAt.UTC()}, nil
+}
+
+// StopImpersonation ends the impersonation the principal's token belongs to.
+func (s *UsersService) StopImpersonation(ctx context.Context, principal domain.Principal) error {
+	if !principal.Impersonated() {
+		return domain.Conflict("not impersonating")
+	}
+	return s.impersonations.End(ctx, principal.ImpersonationId, time.Now())
+}
+
+// impersonationOf returns the principal of an impersonation token if the impersonation is still going on
+// and the admin is still an active admin. Disabling the admin or taking the role away ends their impersonations
+// with their next request.
+func (s *UsersService) impersonationOf(ctx context.Context, userId int, claims jwt.MapClaims) (domain.Principal, error) {
+	adminId, err := intClaim(claims, "imp")
+	if err != nil {
+		return domain.Principal{}, err
+	}
+	id, err := intClaim(claims, "sid")
+	if err != nil {
+		return domain.Principal{}, err
+	}
+
+	impersonation, err := s.impersonations.GetById(ctx, id)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.Principal{}, domain.Unauthorized("invalid impersonation")
+		}
+		return domain.Principal{}, err
+	}
+	if impersonation.AdminId != adminId || impersonation.UserId != userId {
+		return domain.Principal{}, domain.Unauthorized("invalid impersonation")
+	}
+	if !impersonation.Active(time.Now()) {
+		return domain.Principal{}, domain.Unauthorized("impersonation ended")
+	}
+	if err := s.CheckActive(ctx, adminId); err != nil {
+		return domain.Principal{}, err
+	}
+	role, err := s.repo.GetRole(ctx, adminId)
+	if err != nil {
+		return domain.Principal{}, err
+	}
+	if role != domain.RoleAdmin {
+		return domain.Principal{}, domain.Unauthorized("impersonation ended")
+	}
+
+	return domain.Principal{UserId: userId, ImpersonatorId: adminId, ImpersonationId: id}, nil
+}
+
+func intClaim(claims jwt.MapClaims, name string) (int, error) {
+	raw, ok := claims[name].(string)
+	if !ok {
+		return 0, domain.Unauthorized("invalid " + name + " claim")
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, domain.Unauthorized("invalid " + name + " claim")
+	}
+	return n, nil
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/xopxe23/news-server/internal/domain"
+)
+
+// fakeAccounts knows the roles of users and disables nobody, the other calls panic on the nil interface.
+type fakeAccounts struct {
+	UsersRepository
+	roles map[int]string
+}
+
+func (r *fakeAccounts) GetRole(ctx context.Context, userId int) (string, error) {
+	role, ok := r.roles[userId]
+	if !ok {
+		return "", domain.NotFound("user not found")
+	}
+	return role, nil
+}
+
+func (r *fakeAccounts) GetStatus(ctx context.Context, userId int) (domain.AccountStatus, error) {
+	return domain.AccountStatus{}, nil
+}
+
+type fakeImpersonations struct {
+	items []domain.Impersonation
+}
+
+func (r *fakeImpersonations) Create(ctx context.Context, adminId, userId int, expiresAt time.Time) (int, error) {
+	id := len(r.items) + 1
+	r.items = append(r.items, domain.Impersonation{Id: id, AdminId: adminId, UserId: userId, ExpiresAt: expiresAt})
+	return id, nil
+}
+
+func (r *fakeImpersonations) GetById(ctx context.Context, id int) (domain.Impersonation, error) {
+	if id < 1 || id > len(r.items) {
+		return domain.Impersonation{}, domain.NotFound("impersonation not found")
+	}
+	return r.items[id-1], nil
+}
+
+func (r *fakeImpersonations) End(ctx context.Context, id int, at time.Time) error {
+	r.items[id-1].EndedAt = &at
+	return nil
+}
+
+func TestImpersonationEndsWhenAdminLosesRole(t *testing.T) {
+	accounts := &fakeAccounts{roles: map[int]string{1: domain.RoleAdmin, 2: domain.RoleReader}}
+	svc := NewUsersService(accounts, nil, nil, nil, nil, nil, nil, nil, nil, &fakeImpersonations{}, []byte("secret"))
+
+	token, err := svc.Impersonate(context.Background(), 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	principal, err := svc.ParseAccessToken(context.Background(), token.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if principal.UserId != 2 || principal.ImpersonatorId != 1 {
+		t.Errorf("got %+v, want user 2 impersonated by 1", principal)
+	}
+
+	accounts.roles[1] = domain.RoleReader
+	if _, err := svc.ParseAccessToken(context.Background(), token.Token); !errors.Is(err, domain.ErrUnauthorized) {
+		t.Errorf("got %v, want unauthorized once the admin is no longer an admin", err)
+	}
+}
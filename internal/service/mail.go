@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/xopxe23/news-server/internal/domain"
+)
+
+type Mailer interface {
+	Send(ctx context.Context, mail domain.Mail) error
+}
+
+// LogMailer writes mails to the application log instead of sending them. It is meant for development:
+// the body with the token is logged at the debug level only.
+type LogMailer struct{}
+
+func NewLogMailer() *LogMailer {
+	return &LogMailer{}
+}
+
+func (m *LogMailer) Send(ctx context.Context, mail domain.Mail) error {
+	entry := logrus.WithFields(logrus.Fields{
+		"mail_to": mail.To,
+		"subject": mail.Subject,
+	})
+	entry.Info("mail sent to the log")
+	entry.Debug(mail.Body)
+	return nil
+}
+
+// tokenMail is the payload of the events carrying a token for the mailer.
+type tokenMail struct {
+	Email       string    `json:"email"`
+	SealedToken string    `json:"sealed_token"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// NewMailHandler sends users the mails the events ask for.
+func NewMailHandler(mailer Mailer, secret []byte) EventHandler {
+	return func(ctx context.Context, event domain.Event) error {
+		var compose func(token string, expiresAt time.Time) (subject, body string)
+		switch event.Type {
+		case domain.EventUserEmailChangeRequested:
+			compose = emailChangeMail
+		case domain.EventUserPasswordResetRequested:
+			compose = passwordResetMail
+		default:
+			return nil
+		}
+
+		var payload tokenMail
+		if err := json.Unmarshal(event.Payload, &payload); err != nil {
+			return err
+		}
+		token, err := openToken(secret, payload.SealedToken)
+		if err != nil {
+			return err
+		}
+		// there is no point in sending a link which doesn't work anymore
+		if time.Now().After(payload.ExpiresAt) {
+			return nil
+		}
+
+		subject, body := compose(token, payload.ExpiresAt)
+		return mailer.Send(ctx, domain.Mail{To: payload.Email, Subject: subject, Body: body})
+	}
+}
+
+func emailChangeMail(token string, expiresAt time.Time) (string, string) {
+	return "Confirm your new email", fmt.Sprintf(
+		"Confirm the new email of your account with the token below at POST /v1/auth/email/confirm.\n\n%s\n\n"+
+			"The token works until %s. If you didn't ask to change the email, ignore this mail.\n",
+		token, expiresAt.UTC().Format(time.RFC1123))
+}
+
+func passwordResetMail(token string, expiresAt time.Time) (string, string) {
+	return "Reset your password", fmt.Sprintf(
+		"Set a new password for your account with the token below at POST /v1/auth/password/reset.\n\n%s\n\n"+
+			"The token works until %s. Your current password keeps working until then.\n",
+		token, expiresAt.UTC().Format(time.RFC1123))
+}
+
+// sealToken encrypts a token for the mailer, the outbox and its backups mustn't keep working tokens in plain text.
+func sealToken(secret []byte, token string) (string, error) {
+	aead, err := mailCipher(secret)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(aead.Seal(nonce, nonce, []byte(token), nil)), nil
+}
+
+func openToken(secret []byte, sealed string) (string, error) {
+	aead, err := mailCipher(secret)
+	if err != nil {
+		return "", err
+	}
+	data, err := base64.RawURLEncoding.DecodeString(sealed)
+	if err != nil {
+		return "", err
+	}
+	if len(data) < aead.NonceSize() {
+		return "", errors.New("sealed token is too short")
+	}
+	token, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(token), nil
+}
+
+// mailCipher derives its own key from the secret, so the sealed tokens don't share the key with signed tokens.
+func mailCipher(secret []byte) (cipher.AEAD, error) {
+	key := sha256.Sum256(append([]byte("mail-token:"), secret...))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
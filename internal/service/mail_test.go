@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xopxe23/news-server/internal/domain"
+)
+
+type memoryMailer struct {
+	mails []domain.Mail
+}
+
+func (m *memoryMailer) Send(ctx context.Context, mail domain.Mail) error {
+	m.mails = append(m.mails, mail)
+	return nil
+}
+
+func tokenMailEvent(t *testing.T, eventType string, secret []byte, token string, expiresAt time.Time) domain.Event {
+	sealed, err := sealToken(secret, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	event, err := domain.NewEvent(eventType, 1, map[string]interface{}{
+		"id":           1,
+		"email":        "user@example.com",
+		"sealed_token": sealed,
+		"expires_at":   expiresAt,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return event
+}
+
+func TestSealToken(t *testing.T) {
+	secret := []byte("secret")
+	sealed, err := sealToken(secret, "raw-token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(sealed, "raw-token") {
+		t.Error("the sealed token contains the raw token")
+	}
+
+	token, err := openToken(secret, sealed)
+	if err != nil || token != "raw-token" {
+		t.Errorf("got %q, %v, want the raw token", token, err)
+	}
+	if _, err := openToken([]byte("other secret"), sealed); err == nil {
+		t.Error("want an error for another secret")
+	}
+	tampered := "A" + sealed[1:]
+	if sealed[0] == 'A' {
+		tampered = "B" + sealed[1:]
+	}
+	if _, err := openToken(secret, tampered); err == nil {
+		t.Error("want an error for a tampered token")
+	}
+}
+
+func TestMailHandler(t *testing.T) {
+	secret := []byte("secret")
+	tests := []struct {
+		eventType string
+		subject   string
+	}{
+		{domain.EventUserEmailChangeRequested, "Confirm your new email"},
+		{domain.EventUserPasswordResetRequested, "Reset your password"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.eventType, func(t *testing.T) {
+			mailer := &memoryMailer{}
+			event := tokenMailEvent(t, tt.eventType, secret, "raw-token", time.Now().Add(time.Hour))
+			if strings.Contains(string(event.Payload), "raw-token") {
+				t.Fatal("the event payload contains the raw token")
+			}
+
+			if err := NewMailHandler(mailer, secret)(context.Background(), event); err != nil {
+				t.Fatal(err)
+			}
+			if len(mailer.mails) != 1 {
+				t.Fatalf("got %d mails, want 1", len(mailer.mails))
+			}
+			mail := mailer.mails[0]
+			if mail.To != "user@example.com" || mail.Subject != tt.subject || !strings.Contains(mail.Body, "raw-token") {
+				t.Errorf("got %+v", mail)
+			}
+		})
+	}
+}
+
+func TestMailHandlerSkipsExpiredTokens(t *testing.T) {
+	secret := []byte("secret")
+	mailer := &memoryMailer{}
+	event := tokenMailEvent(t, domain.EventUserEmailChangeRequested, secret, "raw-token", time.Now().Add(-time.Minute))
+
+	if err := NewMailHandler(mailer, secret)(context.Background(), event); err != nil {
+		t.Fatal(err)
+	}
+	if len(mailer.mails) != 0 {
+		t.Error("a mail with an expired token must not be sent")
+	}
+}
+
+func TestMailHandlerIgnoresOtherEvents(t *testing.T) {
+	mailer := &memoryMailer{}
+	err := NewMailHandler(mailer, []byte("secret"))(context.Background(), domain.Event{Type: domain.EventUserRegistered})
+	if err != nil || len(mailer.mails) != 0 {
+		t.Errorf("got %v and %d mails, want the event skipped", err, len(mailer.mails))
+	}
+}
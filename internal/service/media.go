@@ -0,0 +1,208 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+	"github.com/xopxe23/news-server/internal/domain"
+	"github.com/xopxe23/news-server/pkg/imaging"
+	"github.com/xopxe23/news-server/pkg/storage"
+)
+
+const thumbnailQuality = 85
+
+// mediaExtensions are the accepted image types by their sniffed content type.
+var mediaExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+type MediaRepository interface {
+	Create(ctx context.Context, media domain.Media) (domain.Media, error)
+	GetById(ctx context.Context, mediaId int) (domain.Media, error)
+	Delete(ctx context.Context, mediaId int) error
+	Attach(ctx context.Context, articleId int, input domain.AttachMediaInput) error
+	Detach(ctx context.Context, articleId, mediaId int) error
+	GetByArticle(ctx context.Context, articleId int) ([]domain.ArticleMedia, error)
+}
+
+// FileStorage keeps the uploaded files, see pkg/storage.
+type FileStorage interface {
+	Put(ctx context.Context, key, contentType string, data []byte) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+type MediaConfig struct {
+	// MaxSize is the largest accepted file in bytes.
+	MaxSize int64
+	// MaxPixels limits width×height of images, so a small file can't unpack into gigabytes of pixels.
+	MaxPixels int
+	// ThumbnailSize is the longest side of thumbnails in pixels.
+	ThumbnailSize int
+}
+
+// MediaService stores uploaded images with their thumbnails and attaches them to articles.
+type MediaService struct {
+	repo    MediaRepository
+	storage FileStorage
+	cfg     MediaConfig
+}
+
+func NewMediaService(repo MediaRepository, storage FileStorage, cfg MediaConfig) *MediaService {
+	return &MediaService{
+		repo:    repo,
+		storage: storage,
+		cfg:     cfg,
+	}
+}
+
+// Upload stores the image read from r. The type is sniffed from the content, the name and the type
+// the client claims are ignored.
+func (s *MediaService) Upload(ctx context.Context, ownerId int, r io.Reader) (domain.Media, error) {
+	data, err := io.ReadAll(io.LimitReader(r, s.cfg.MaxSize+1))
+	if err != nil {
+		return domain.Media{}, domain.InvalidInput("failed to read the file", err)
+	}
+	if int64(len(data)) > s.cfg.MaxSize {
+		return domain.Media{}, domain.NewError(domain.ErrTooLarge, fmt.Sprintf("file must not be larger than %d bytes", s.cfg.MaxSize), nil)
+	}
+	if len(data) == 0 {
+		return domain.Media{}, domain.InvalidInput("file is empty", nil)
+	}
+
+	contentType := http.DetectContentType(data)
+	extension, ok := mediaExtensions[contentType]
+	if !ok {
+		return domain.Media{}, domain.NewError(domain.ErrUnsupportedMediaType,
+			fmt.Sprintf("only JPEG, PNG and GIF images are accepted, got %s", contentType), nil)
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return domain.Media{}, domain.InvalidInput("image is corrupted", err)
+	}
+	if config.Width*config.Height > s.cfg.MaxPixels {
+		return domain.Media{}, domain.NewError(domain.ErrTooLarge,
+			fmt.Sprintf("image must not have more than %d pixels", s.cfg.MaxPixels), nil)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return domain.Media{}, domain.InvalidInput("image is corrupted", err)
+	}
+	var thumbnail bytes.Buffer
+	if err := jpeg.Encode(&thumbnail, imaging.Thumbnail(img, s.cfg.ThumbnailSize), &jpeg.Options{Quality: thumbnailQuality}); err != nil {
+		return domain.Media{}, err
+	}
+
+	prefix, err := randomToken(16)
+	if err != nil {
+		return domain.Media{}, err
+	}
+	media := domain.Media{
+		OwnerId:      ownerId,
+		ContentType:  contentType,
+		Size:         int64(len(data)),
+		Width:        config.Width,
+		Height:       config.Height,
+		StorageKey:   "media/" + prefix + "/original" + extension,
+		ThumbnailKey: "media/" + prefix + "/thumbnail.jpg",
+	}
+
+	if err := s.storage.Put(ctx, media.StorageKey, contentType, data); err != nil {
+		return domain.Media{}, err
+	}
+	if err := s.storage.Put(ctx, media.ThumbnailKey, "image/jpeg", thumbnail.Bytes()); err != nil {
+		s.deleteFiles(ctx, media)
+		return domain.Media{}, err
+	}
+
+	created, err := s.repo.Create(ctx, media)
+	if err != nil {
+		s.deleteFiles(ctx, media)
+		return domain.Media{}, err
+	}
+	return created, nil
+}
+
+func (s *MediaService) GetMedia(ctx context.Context, mediaId int) (domain.Media, error) {
+	return s.repo.GetById(ctx, mediaId)
+}
+
+// OpenMedia returns the media with its original file or, if thumbnail is set, with its thumbnail.
+// The caller closes the file.
+func (s *MediaService) OpenMedia(ctx context.Context, mediaId int, thumbnail bool) (domain.Media, io.ReadCloser, error) {
+	media, err := s.repo.GetById(ctx, mediaId)
+	if err != nil {
+		return domain.Media{}, nil, err
+	}
+
+	key := media.StorageKey
+	if thumbnail {
+		key = media.ThumbnailKey
+		media.ContentType = "image/jpeg"
+	}
+	file, err := s.storage.Get(ctx, key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return domain.Media{}, nil, domain.NewError(domain.ErrNotFound, "file not found", err)
+	}
+	if err != nil {
+		return domain.Media{}, nil, err
+	}
+	return media, file, nil
+}
+
+// DeleteMedia deletes media uploaded by the user and detaches it from all articles.
+func (s *MediaService) DeleteMedia(ctx context.Context, userId, mediaId int) error {
+	media, err := s.repo.GetById(ctx, mediaId)
+	if err != nil {
+		return err
+	}
+	if media.OwnerId != userId {
+		return domain.Forbidden("only the uploader can delete media")
+	}
+
+	if err := s.repo.Delete(ctx, mediaId); err != nil {
+		return err
+	}
+	// the media is gone for clients already, files left behind only take space
+	s.deleteFiles(ctx, media)
+	return nil
+}
+
+func (s *MediaService) AttachMedia(ctx context.Context, articleId int, input domain.AttachMediaInput) error {
+	if err := input.Validate(); err != nil {
+		return err
+	}
+	return s.repo.Attach(ctx, articleId, input)
+}
+
+func (s *MediaService) DetachMedia(ctx context.Context, articleId, mediaId int) error {
+	return s.repo.Detach(ctx, articleId, mediaId)
+}
+
+func (s *MediaService) GetArticleMedia(ctx context.Context, articleId int) ([]domain.ArticleMedia, error) {
+	return s.repo.GetByArticle(ctx, articleId)
+}
+
+func (s *MediaService) deleteFiles(ctx context.Context, media domain.Media) {
+	for _, key := range []string{media.StorageKey, media.ThumbnailKey} {
+		if err := s.storage.Delete(ctx, key); err != nil {
+			logrus.WithFields(logrus.Fields{
+				"method": "MediaService.deleteFiles",
+				"key":    key,
+			}).Error(err)
+		}
+	}
+}
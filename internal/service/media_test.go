@@ -0,0 +1,165 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/xopxe23/news-server/internal/domain"
+	"github.com/xopxe23/news-server/pkg/storage"
+)
+
+// fakeMediaRepository implements only the calls the tests make, the rest panic on the nil interface.
+type fakeMediaRepository struct {
+	MediaRepository
+	created []domain.Media
+}
+
+func (r *fakeMediaRepository) Create(ctx context.Context, media domain.Media) (domain.Media, error) {
+	media.Id = len(r.created) + 1
+	r.created = append(r.created, media)
+	return media, nil
+}
+
+type memoryFile struct {
+	contentType string
+	data        []byte
+}
+
+type memoryStorage struct {
+	files map[string]memoryFile
+}
+
+func newMemoryStorage() *memoryStorage {
+	return &memoryStorage{files: make(map[string]memoryFile)}
+}
+
+func (s *memoryStorage) Put(ctx context.Context, key, contentType string, data []byte) error {
+	s.files[key] = memoryFile{contentType: contentType, data: data}
+	return nil
+}
+
+func (s *memoryStorage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	f, ok := s.files[key]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	return io.NopCloser(bytes.NewReader(f.data)), nil
+}
+
+func (s *memoryStorage) Delete(ctx context.Context, key string) error {
+	delete(s.files, key)
+	return nil
+}
+
+func testMediaConfig() MediaConfig {
+	return MediaConfig{MaxSize: 1 << 20, MaxPixels: 1000 * 1000, ThumbnailSize: 32}
+}
+
+func encodeImage(t *testing.T, encode func(io.Writer, image.Image) error, width, height int) []byte {
+	t.Helper()
+
+	img := image.NewPaletted(image.Rect(0, 0, width, height), color.Palette{color.White, color.Black})
+	var buf bytes.Buffer
+	if err := encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestMediaUploadSniffsImages(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        []byte
+		contentType string
+		extension   string
+	}{
+		{"png", encodeImage(t, png.Encode, 64, 48), "image/png", ".png"},
+		{"jpeg", encodeImage(t, func(w io.Writer, img image.Image) error { return jpeg.Encode(w, img, nil) }, 64, 48), "image/jpeg", ".jpg"},
+		{"gif", encodeImage(t, func(w io.Writer, img image.Image) error { return gif.Encode(w, img, nil) }, 64, 48), "image/gif", ".gif"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, files := &fakeMediaRepository{}, newMemoryStorage()
+			media, err := NewMediaService(repo, files, testMediaConfig()).Upload(context.Background(), 7, bytes.NewReader(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if media.ContentType != tt.contentType || media.Width != 64 || media.Height != 48 || media.OwnerId != 7 ||
+				media.Size != int64(len(tt.data)) {
+				t.Errorf("got %+v", media)
+			}
+			if !strings.HasSuffix(media.StorageKey, "/original"+tt.extension) {
+				t.Errorf("got key %s, want the %s extension", media.StorageKey, tt.extension)
+			}
+			original := files.files[media.StorageKey]
+			if original.contentType != tt.contentType || !bytes.Equal(original.data, tt.data) {
+				t.Errorf("got the original stored as %s", original.contentType)
+			}
+
+			thumbnail := files.files[media.ThumbnailKey]
+			config, format, err := image.DecodeConfig(bytes.NewReader(thumbnail.data))
+			if err != nil || format != "jpeg" || thumbnail.contentType != "image/jpeg" {
+				t.Fatalf("got thumbnail %s %s, %v, want a JPEG", thumbnail.contentType, format, err)
+			}
+			if config.Width != 32 || config.Height != 24 {
+				t.Errorf("got thumbnail %d×%d, want 32×24", config.Width, config.Height)
+			}
+		})
+	}
+}
+
+func TestMediaUploadRejectsFiles(t *testing.T) {
+	pngHeader := encodeImage(t, png.Encode, 8, 8)[:16]
+
+	tests := []struct {
+		name string
+		data []byte
+		cfg  func(*MediaConfig)
+		kind error
+	}{
+		{name: "empty", data: nil, kind: domain.ErrValidation},
+		{name: "text", data: []byte("just some text, whatever the file is called"), kind: domain.ErrUnsupportedMediaType},
+		{name: "html", data: []byte("<html><script>alert(1)</script></html>"), kind: domain.ErrUnsupportedMediaType},
+		{name: "svg", data: []byte(`<svg xmlns="http://www.w3.org/2000/svg"><script>alert(1)</script></svg>`), kind: domain.ErrUnsupportedMediaType},
+		{name: "truncated png", data: pngHeader, kind: domain.ErrValidation},
+		{
+			name: "too large",
+			data: encodeImage(t, png.Encode, 8, 8),
+			cfg:  func(cfg *MediaConfig) { cfg.MaxSize = 16 },
+			kind: domain.ErrTooLarge,
+		},
+		{
+			name: "too many pixels",
+			data: encodeImage(t, png.Encode, 200, 200),
+			cfg:  func(cfg *MediaConfig) { cfg.MaxPixels = 100 * 100 },
+			kind: domain.ErrTooLarge,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testMediaConfig()
+			if tt.cfg != nil {
+				tt.cfg(&cfg)
+			}
+			repo, files := &fakeMediaRepository{}, newMemoryStorage()
+
+			_, err := NewMediaService(repo, files, cfg).Upload(context.Background(), 7, bytes.NewReader(tt.data))
+			if !errors.Is(err, tt.kind) {
+				t.Errorf("got %v, want %v", err, tt.kind)
+			}
+			if len(repo.created) != 0 || len(files.files) != 0 {
+				t.Error("nothing must be stored")
+			}
+		})
+	}
+}
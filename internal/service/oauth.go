@@ -0,0 +1,341 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xopxe23/news-server/internal/domain"
+	"github.com/xopxe23/news-server/pkg/oidc"
+)
+
+const oauthTokenPrefix = "nso_"
+
+type OAuthRepository interface {
+	CreateClient(ctx context.Context, client domain.OAuthClient, secretHash string) (domain.OAuthClient, error)
+	GetClient(ctx context.Context, clientId string) (domain.OAuthClient, string, error)
+	GetClientsByOwner(ctx context.Context, ownerId int) ([]domain.OAuthClient, error)
+	DeleteClient(ctx context.Context, ownerId int, clientId string) error
+	CreateCode(ctx context.Context, hash string, code domain.AuthorizationCode) error
+	TakeCode(ctx context.Context, hash string) (domain.AuthorizationCode, error)
+	CreateToken(ctx context.Context, hash string, token domain.OAuthToken) (domain.OAuthToken, error)
+	GetToken(ctx context.Context, hash string) (domain.OAuthToken, error)
+	RevokeToken(ctx context.Context, hash string, clientId int, at time.Time) error
+}
+
+type OAuthConfig struct {
+	AccessTokenTTL time.Duration
+	CodeTTL        time.Duration
+}
+
+// OAuthService lets third-party applications act on behalf of users, see RFC 6749.
+// Clients get opaque access tokens limited to the scopes the user agreed to.
+type OAuthService struct {
+	repo OAuthRepository
+	cfg  OAuthConfig
+}
+
+func NewOAuthService(repo OAuthRepository, cfg OAuthConfig) *OAuthService {
+	return &OAuthService{repo: repo, cfg: cfg}
+}
+
+// RegisterClient registers an application of the user. The secret of confidential clients
+// is returned only here.
+func (s *OAuthService) RegisterClient(ctx context.Context, ownerId int, input domain.OAuthClientInput) (domain.RegisteredOAuthClient, error) {
+	if err := input.Validate(); err != nil {
+		return domain.RegisteredOAuthClient{}, err
+	}
+
+	clientId, err := randomToken(16)
+	if err != nil {
+		return domain.RegisteredOAuthClient{}, err
+	}
+
+	var secret, secretHash string
+	if input.Confidential {
+		if secret, err = randomToken(32); err != nil {
+			return domain.RegisteredOAuthClient{}, err
+		}
+		secretHash = hashSecret(secret)
+	}
+
+	client, err := s.repo.CreateClient(ctx, domain.OAuthClient{
+		ClientId:     clientId,
+		Name:         input.Name,
+		RedirectURIs: input.RedirectURIs,
+		Scopes:       input.Scopes,
+		Confidential: input.Confidential,
+		OwnerId:      ownerId,
+	}, secretHash)
+	if err != nil {
+		return domain.RegisteredOAuthClient{}, err
+	}
+	return domain.RegisteredOAuthClient{OAuthClient: client, ClientSecret: secret}, nil
+}
+
+func (s *OAuthService) GetClients(ctx context.Context, ownerId int) ([]domain.OAuthClient, error) {
+	return s.repo.GetClientsByOwner(ctx, ownerId)
+}
+
+func (s *OAuthService) DeleteClient(ctx context.Context, ownerId int, clientId string) error {
+	return s.repo.DeleteClient(ctx, ownerId, clientId)
+}
+
+// Authorize checks the authorization request and returns what the user is asked to agree to.
+func (s *OAuthService) Authorize(ctx context.Context, req domain.AuthorizeRequest) (domain.Consent, error) {
+	if err := req.Validate(); err != nil {
+		return domain.Consent{}, err
+	}
+
+	client, _, err := s.repo.GetClient(ctx, req.ClientId)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.Consent{}, domain.InvalidInput("unknown client", err)
+		}
+		return domain.Consent{}, err
+	}
+
+	// the redirect URI is compared exactly, so codes can't be sent anywhere else
+	registered := false
+	for _, uri := range client.RedirectURIs {
+		if uri == req.RedirectURI {
+			registered = true
+			break
+		}
+	}
+	if !registered {
+		return domain.Consent{}, domain.InvalidInput("redirect_uri isn't registered for the client", nil)
+	}
+
+	scopes, err := requestedScopes(client, req.Scope)
+	if err != nil {
+		return domain.Consent{}, err
+	}
+
+	return domain.Consent{
+		ClientId:    client.ClientId,
+		ClientName:  client.Name,
+		Scopes:      scopes,
+		RedirectURI: req.RedirectURI,
+	}, nil
+}
+
+// Consent records the answer of the user and returns where to send them: back to the client
+// with an authorization code, or with the access_denied error.
+func (s *OAuthService) Consent(ctx context.Context, userId int, input domain.ConsentInput) (string, error) {
+	consent, err := s.Authorize(ctx, input.AuthorizeRequest)
+	if err != nil {
+		return "", err
+	}
+
+	params := url.Values{}
+	if input.State != "" {
+		params.Set("state", input.State)
+	}
+	if !input.Approve {
+		params.Set("error", "access_denied")
+		return withQuery(consent.RedirectURI, params), nil
+	}
+
+	client, _, err := s.repo.GetClient(ctx, input.ClientId)
+	if err != nil {
+		return "", err
+	}
+
+	code, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+	if err := s.repo.CreateCode(ctx, hashSecret(code), domain.AuthorizationCode{
+		ClientId:      client.Id,
+		UserId:        userId,
+		RedirectURI:   consent.RedirectURI,
+		Scopes:        consent.Scopes,
+		CodeChallenge: input.CodeChallenge,
+		ExpiresAt:     time.Now().Add(s.cfg.CodeTTL),
+	}); err != nil {
+		return "", err
+	}
+
+	params.Set("code", code)
+	return withQuery(consent.RedirectURI, params), nil
+}
+
+// Token implements the token endpoint for the authorization code and client credentials grants.
+func (s *OAuthService) Token(ctx context.Context, req domain.TokenRequest) (domain.IssuedToken, error) {
+	client, err := s.authenticateClient(ctx, req.ClientId, req.ClientSecret)
+	if err != nil {
+		return domain.IssuedToken{}, err
+	}
+
+	switch req.GrantType {
+	case domain.GrantAuthorizationCode:
+		code, err := s.repo.TakeCode(ctx, hashSecret(req.Code))
+		if err != nil {
+			if errors.Is(err, domain.ErrNotFound) {
+				return domain.IssuedToken{}, domain.NewOAuthError("invalid_grant", "unknown or used code")
+			}
+			return domain.IssuedToken{}, err
+		}
+		if code.ClientId != client.Id || code.RedirectURI != req.RedirectURI || !time.Now().Before(code.ExpiresAt) {
+			return domain.IssuedToken{}, domain.NewOAuthError("invalid_grant", "code is expired or was issued for another request")
+		}
+		if subtle.ConstantTimeCompare([]byte(oidc.CodeChallenge(req.CodeVerifier)), []byte(code.CodeChallenge)) != 1 {
+			return domain.IssuedToken{}, domain.NewOAuthError("invalid_grant", "code verifier doesn't match")
+		}
+		return s.issueToken(ctx, client, code.UserId, code.Scopes)
+
+	case domain.GrantClientCredentials:
+		if !client.Confidential {
+			return domain.IssuedToken{}, domain.NewOAuthError("unauthorized_client", "public clients can't use client credentials")
+		}
+		scopes, err := requestedScopes(client, req.Scope)
+		if err != nil {
+			return domain.IssuedToken{}, domain.NewOAuthError("invalid_scope", err.Error())
+		}
+		return s.issueToken(ctx, client, client.OwnerId, scopes)
+	}
+
+	return domain.IssuedToken{}, domain.NewOAuthError("unsupported_grant_type", "")
+}
+
+// Revoke revokes a token of the client, see RFC 7009. Unknown tokens aren't an error.
+func (s *OAuthService) Revoke(ctx context.Context, clientId, clientSecret, token string) error {
+	client, err := s.authenticateClient(ctx, clientId, clientSecret)
+	if err != nil {
+		return err
+	}
+	return s.repo.RevokeToken(ctx, hashSecret(token), client.Id, time.Now())
+}
+
+// Introspect describes a token of the client, see RFC 7662. Tokens of other clients are reported inactive.
+func (s *OAuthService) Introspect(ctx context.Context, clientId, clientSecret, token string) (domain.TokenIntrospection, error) {
+	client, err := s.authenticateClient(ctx, clientId, clientSecret)
+	if err != nil {
+		return domain.TokenIntrospection{}, err
+	}
+
+	t, err := s.repo.GetToken(ctx, hashSecret(token))
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.TokenIntrospection{Active: false}, nil
+		}
+		return domain.TokenIntrospection{}, err
+	}
+	if t.ClientId != client.Id || !t.Active(time.Now()) {
+		return domain.TokenIntrospection{Active: false}, nil
+	}
+
+	return domain.TokenIntrospection{
+		Active:    true,
+		Scope:     strings.Join(t.Scopes, " "),
+		ClientId:  client.ClientId,
+		Subject:   strconv.Itoa(t.UserId),
+		TokenType: "Bearer",
+		ExpiresAt: t.ExpiresAt.Unix(),
+		IssuedAt:  t.CreatedAt.Unix(),
+	}, nil
+}
+
+// IsAccessToken tells OAuth access tokens apart from the tokens users get on sign-in.
+func (s *OAuthService) IsAccessToken(raw string) bool {
+	return strings.HasPrefix(raw, oauthTokenPrefix)
+}
+
+// AuthenticateToken returns the access token if it is active.
+func (s *OAuthService) AuthenticateToken(ctx context.Context, raw string) (domain.OAuthToken, error) {
+	token, err := s.repo.GetToken(ctx, hashSecret(raw))
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.OAuthToken{}, domain.Unauthorized("invalid token")
+		}
+		return domain.OAuthToken{}, err
+	}
+	if !token.Active(time.Now()) {
+		return domain.OAuthToken{}, domain.Unauthorized("token is expired or revoked")
+	}
+	return token, nil
+}
+
+func (s *OAuthService) issueToken(ctx context.Context, client domain.OAuthClient, userId int, scopes domain.Scopes) (domain.IssuedToken, error) {
+	raw, err := randomToken(32)
+	if err != nil {
+		return domain.IssuedToken{}, err
+	}
+	raw = oauthTokenPrefix + raw
+
+	if _, err := s.repo.CreateToken(ctx, hashSecret(raw), domain.OAuthToken{
+		ClientId:  client.Id,
+		UserId:    userId,
+		Scopes:    scopes,
+		ExpiresAt: time.Now().Add(s.cfg.AccessTokenTTL),
+	}); err != nil {
+		return domain.IssuedToken{}, err
+	}
+
+	return domain.IssuedToken{
+		AccessToken: raw,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(s.cfg.AccessTokenTTL.Seconds()),
+		Scope:       strings.Join(scopes, " "),
+	}, nil
+}
+
+// authenticateClient checks the secret of confidential clients. Public clients are identified by id only,
+// PKCE protects their codes instead.
+func (s *OAuthService) authenticateClient(ctx context.Context, clientId, clientSecret string) (domain.OAuthClient, error) {
+	if clientId == "" {
+		return domain.OAuthClient{}, domain.NewOAuthError("invalid_client", "client authentication is required")
+	}
+
+	client, secretHash, err := s.repo.GetClient(ctx, clientId)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.OAuthClient{}, domain.NewOAuthError("invalid_client", "unknown client")
+		}
+		return domain.OAuthClient{}, err
+	}
+	if client.Confidential && subtle.ConstantTimeCompare([]byte(hashSecret(clientSecret)), []byte(secretHash)) != 1 {
+		return domain.OAuthClient{}, domain.NewOAuthError("invalid_client", "invalid client secret")
+	}
+	return client, nil
+}
+
+// requestedScopes parses the space separated scope parameter. No scopes mean all scopes of the client.
+func requestedScopes(client domain.OAuthClient, scope string) (domain.Scopes, error) {
+	requested := domain.Scopes(strings.Fields(scope))
+	if len(requested) == 0 {
+		return client.Scopes, nil
+	}
+	for _, s := range requested {
+		if s != domain.ScopeRead && s != domain.ScopeWrite {
+			return nil, domain.InvalidInput("unknown scope "+s, nil)
+		}
+	}
+	if !client.Scopes.Contains(requested) {
+		return nil, domain.InvalidInput("the client isn't allowed to request these scopes", nil)
+	}
+	return requested, nil
+}
+
+func withQuery(uri string, params url.Values) string {
+	sep := "?"
+	if strings.Contains(uri, "?") {
+		sep = "&"
+	}
+	return uri + sep + params.Encode()
+}
+
+func randomToken(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
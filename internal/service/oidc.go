@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/xopxe23/news-server/internal/domain"
+	"github.com/xopxe23/news-server/pkg/oidc"
+)
+
+const (
+	tokenTypeOIDCState = "oidc_state"
+
+	// oidcStateTTL is how long the user has to sign in at the identity provider.
+	oidcStateTTL = 10 * time.Minute
+)
+
+type OIDCProvider interface {
+	AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error)
+	Exchange(ctx context.Context, code, codeVerifier, nonce string) (oidc.Claims, error)
+}
+
+type IdentitiesRepository interface {
+	GetUserId(ctx context.Context, issuer, subject string) (int, error)
+	Link(ctx context.Context, userId int, issuer, subject string) error
+	GetByUser(ctx context.Context, userId int) ([]domain.LinkedIdentity, error)
+}
+
+// OIDC signs users in with an external OpenID Connect identity provider.
+type OIDC struct {
+	provider   OIDCProvider
+	identities IdentitiesRepository
+}
+
+func NewOIDC(provider OIDCProvider, identities IdentitiesRepository) *OIDC {
+	return &OIDC{provider: provider, identities: identities}
+}
+
+// StartOIDC begins the authorization code flow with PKCE. State, nonce and the code verifier travel
+// in a signed state token, so nothing has to be stored until the user comes back.
+func (s *UsersService) StartOIDC(ctx context.Context) (domain.OIDCLogin, error) {
+	if s.oidc == nil {
+		return domain.OIDCLogin{}, domain.NotFound("sign-in with an identity provider is not configured")
+	}
+
+	state, err := oidc.RandomString()
+	if err != nil {
+		return domain.OIDCLogin{}, err
+	}
+	nonce, err := oidc.RandomString()
+	if err != nil {
+		return domain.OIDCLogin{}, err
+	}
+	verifier, err := oidc.NewCodeVerifier()
+	if err != nil {
+		return domain.OIDCLogin{}, err
+	}
+
+	url, err := s.oidc.provider.AuthCodeURL(ctx, state, nonce, oidc.CodeChallenge(verifier))
+	if err != nil {
+		return domain.OIDCLogin{}, err
+	}
+
+	t := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"typ":      tokenTypeOIDCState,
+		"state":    state,
+		"nonce":    nonce,
+		"verifier": verifier,
+		"iat":      time.Now().Unix(),
+		"exp":      time.Now().Add(oidcStateTTL).Unix(),
+	})
+	stateToken, err := t.SignedString(s.hmacSecret)
+	if err != nil {
+		return domain.OIDCLogin{}, err
+	}
+	return domain.OIDCLogin{URL: url, StateToken: stateToken}, nil
+}
+
+// FinishOIDC exchanges the code from the callback and signs in the user the identity belongs to.
+// Unknown identities are linked to the user with the same email, or a new user is registered,
+// as long as the provider has verified the email.
+func (s *UsersService) FinishOIDC(ctx context.Context, callback domain.OIDCCallback) (domain.SignInResult, error) {
+	if s.oidc == nil {
+		return domain.SignInResult{}, domain.NotFound("sign-in with an identity provider is not configured")
+	}
+
+	claims, err := s.parseClaims(callback.StateToken, tokenTypeOIDCState)
+	if err != nil {
+		return domain.SignInResult{}, err
+	}
+	state, _ := claims["state"].(string)
+	nonce, _ := claims["nonce"].(string)
+	verifier, _ := claims["verifier"].(string)
+	if state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(callback.State)) != 1 {
+		return domain.SignInResult{}, domain.Unauthorized("state mismatch")
+	}
+
+	idClaims, err := s.oidc.provider.Exchange(ctx, callback.Code, verifier, nonce)
+	if err != nil {
+		return domain.SignInResult{}, domain.NewError(domain.ErrUnauthorized, "sign-in with the identity provider failed", err)
+	}
+
+	userId, err := s.userForIdentity(ctx, domain.ExternalIdentity{
+		Issuer:        idClaims.Issuer,
+		Subject:       idClaims.Subject,
+		Email:         idClaims.Email,
+		EmailVerified: bool(idClaims.EmailVerified),
+		Name:          idClaims.Name,
+	})
+	if err != nil {
+		return domain.SignInResult{}, err
+	}
+
+	mfa, err := s.twoFactor.Enabled(ctx, userId)
+	if err != nil {
+		return domain.SignInResult{}, err
+	}
+	if mfa {
+		challenge, err := s.newChallengeToken(userId)
+		return domain.SignInResult{ChallengeToken: challenge}, err
+	}
+
+	accessToken, refreshToken, err := s.generateTokens(ctx, userId)
+	return domain.SignInResult{AccessToken: accessToken, RefreshToken: refreshToken}, err
+}
+
+func (s *UsersService) userForIdentity(ctx context.Context, identity domain.ExternalIdentity) (int, error) {
+	userId, err := s.oidc.identities.GetUserId(ctx, identity.Issuer, identity.Subject)
+	if err == nil || !errors.Is(err, domain.ErrNotFound) {
+		return userId, err
+	}
+
+	// an unverified email could belong to somebody else
+	if identity.Email == "" || !identity.EmailVerified {
+		return 0, domain.Forbidden("the identity provider hasn't verified the email")
+	}
+
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		user, err := s.repo.GetByEmail(ctx, identity.Email)
+		switch {
+		case err == nil:
+			userId = user.Id
+			// anybody could have signed up with the email, the identity provider is the first to prove who owns it
+			if !user.EmailVerified {
+				if err := s.reclaimAccount(ctx, userId); err != nil {
+					return err
+				}
+			}
+		case errors.Is(err, domain.ErrNotFound):
+			if userId, err = s.registerIdentity(ctx, identity); err != nil {
+				return err
+			}
+		default:
+			return err
+		}
+		return s.oidc.identities.Link(ctx, userId, identity.Issuer, identity.Subject)
+	})
+	return userId, err
+}
+
+// reclaimAccount hands an account with an unverified email over to the owner of the email. The password,
+// two-factor authentication, API keys, OAuth tokens and sessions are dropped, the user can set a new password
+// with a password reset.
+func (s *UsersService) reclaimAccount(ctx context.Context, userId int) error {
+	if err := s.repo.ReclaimAccount(ctx, userId, time.Now()); err != nil {
+		return err
+	}
+	return s.sessionsRepo.DeleteByUser(ctx, userId)
+}
+
+// registerIdentity creates a user without a password, they can sign in only through the identity provider.
+func (s *UsersService) registerIdentity(ctx context.Context, identity domain.ExternalIdentity) (int, error) {
+	name := identity.Name
+	if name == "" {
+		name = strings.SplitN(identity.Email, "@", 2)[0]
+	}
+
+	id, err := s.repo.Create(ctx, domain.User{Name: name, Email: identity.Email, EmailVerified: true})
+	if err != nil {
+		return 0, err
+	}
+
+	event, err := domain.NewEvent(domain.EventUserRegistered, id, map[string]interface{}{
+		"id":    id,
+		"name":  name,
+		"email": identity.Email,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return id, s.outbox.Add(ctx, event)
+}
@@ -0,0 +1,227 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/xopxe23/news-server/internal/domain"
+	"github.com/xopxe23/news-server/pkg/oidc"
+)
+
+type fakeOIDCProvider struct {
+	exchanged bool
+}
+
+func (p *fakeOIDCProvider) AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error) {
+	return "https://idp.example.com/authorize?state=" + state, nil
+}
+
+func (p *fakeOIDCProvider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (oidc.Claims, error) {
+	p.exchanged = true
+	return oidc.Claims{}, errors.New("unexpected exchange")
+}
+
+type fakeIdentities struct {
+	links map[string]int
+}
+
+func (r *fakeIdentities) GetUserId(ctx context.Context, issuer, subject string) (int, error) {
+	if id, ok := r.links[issuer+" "+subject]; ok {
+		return id, nil
+	}
+	return 0, domain.NotFound("identity not found")
+}
+
+func (r *fakeIdentities) Link(ctx context.Context, userId int, issuer, subject string) error {
+	r.links[issuer+" "+subject] = userId
+	return nil
+}
+
+func (r *fakeIdentities) GetByUser(ctx context.Context, userId int) ([]domain.LinkedIdentity, error) {
+	return nil, nil
+}
+
+// fakeUsers implements only the calls the tests make, the rest panic on the nil interface.
+type fakeUsers struct {
+	UsersRepository
+	users     map[string]domain.User
+	reclaimed []int
+}
+
+func (r *fakeUsers) GetByEmail(ctx context.Context, email string) (domain.User, error) {
+	if user, ok := r.users[email]; ok {
+		return user, nil
+	}
+	return domain.User{}, domain.NotFound("user not found")
+}
+
+func (r *fakeUsers) Create(ctx context.Context, user domain.User) (int, error) {
+	user.Id = len(r.users) + 100
+	r.users[user.Email] = user
+	return user.Id, nil
+}
+
+func (r *fakeUsers) ReclaimAccount(ctx context.Context, userId int, at time.Time) error {
+	r.reclaimed = append(r.reclaimed, userId)
+	return nil
+}
+
+type fakeSessions struct {
+	SessionsRepository
+	deleted []int
+}
+
+func (r *fakeSessions) DeleteByUser(ctx context.Context, userId int) error {
+	r.deleted = append(r.deleted, userId)
+	return nil
+}
+
+type fakeTransactor struct{}
+
+func (fakeTransactor) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+type fakeOutbox struct {
+	events []domain.Event
+}
+
+func (o *fakeOutbox) Add(ctx context.Context, event domain.Event) error {
+	o.events = append(o.events, event)
+	return nil
+}
+
+type oidcFixture struct {
+	svc        *UsersService
+	provider   *fakeOIDCProvider
+	identities *fakeIdentities
+	users      *fakeUsers
+	sessions   *fakeSessions
+	outbox     *fakeOutbox
+}
+
+func newOIDCFixture(users ...domain.User) oidcFixture {
+	f := oidcFixture{
+		provider:   &fakeOIDCProvider{},
+		identities: &fakeIdentities{links: make(map[string]int)},
+		users:      &fakeUsers{users: make(map[string]domain.User)},
+		sessions:   &fakeSessions{},
+		outbox:     &fakeOutbox{},
+	}
+	for _, user := range users {
+		f.users.users[user.Email] = user
+	}
+	f.svc = &UsersService{
+		repo:         f.users,
+		transactor:   fakeTransactor{},
+		outbox:       f.outbox,
+		sessionsRepo: f.sessions,
+		oidc:         NewOIDC(f.provider, f.identities),
+		hmacSecret:   []byte("secret"),
+	}
+	return f
+}
+
+func TestFinishOIDCStateMismatch(t *testing.T) {
+	f := newOIDCFixture()
+	login, err := f.svc.StartOIDC(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = f.svc.FinishOIDC(context.Background(), domain.OIDCCallback{
+		Code:       "code",
+		State:      "forged-state",
+		StateToken: login.StateToken,
+	})
+	if !errors.Is(err, domain.ErrUnauthorized) {
+		t.Errorf("got %v, want unauthorized", err)
+	}
+	if f.provider.exchanged {
+		t.Error("the code must not be exchanged when the state doesn't match")
+	}
+}
+
+func TestUserForIdentity(t *testing.T) {
+	identity := domain.ExternalIdentity{
+		Issuer:        "https://idp.example.com",
+		Subject:       "subject-1",
+		Email:         "user@example.com",
+		EmailVerified: true,
+	}
+
+	t.Run("linked identity", func(t *testing.T) {
+		f := newOIDCFixture()
+		f.identities.links[identity.Issuer+" "+identity.Subject] = 7
+
+		userId, err := f.svc.userForIdentity(context.Background(), identity)
+		if err != nil || userId != 7 {
+			t.Errorf("got %d, %v, want 7", userId, err)
+		}
+	})
+
+	t.Run("unverified email at the provider", func(t *testing.T) {
+		f := newOIDCFixture(domain.User{Id: 7, Email: identity.Email, EmailVerified: true})
+		unverified := identity
+		unverified.EmailVerified = false
+
+		if _, err := f.svc.userForIdentity(context.Background(), unverified); !errors.Is(err, domain.ErrForbidden) {
+			t.Errorf("got %v, want forbidden", err)
+		}
+		if len(f.identities.links) != 0 {
+			t.Error("the identity must not be linked")
+		}
+	})
+
+	t.Run("user with a verified email", func(t *testing.T) {
+		f := newOIDCFixture(domain.User{Id: 7, Email: identity.Email, EmailVerified: true})
+
+		userId, err := f.svc.userForIdentity(context.Background(), identity)
+		if err != nil || userId != 7 {
+			t.Fatalf("got %d, %v, want 7", userId, err)
+		}
+		if f.identities.links[identity.Issuer+" "+identity.Subject] != 7 {
+			t.Error("the identity is not linked")
+		}
+		if len(f.users.reclaimed) != 0 || len(f.sessions.deleted) != 0 {
+			t.Error("an account with a verified email must be kept as is")
+		}
+	})
+
+	t.Run("user with an unverified email", func(t *testing.T) {
+		// somebody signed up with the email before its owner came with the identity provider
+		f := newOIDCFixture(domain.User{Id: 7, Email: identity.Email})
+
+		userId, err := f.svc.userForIdentity(context.Background(), identity)
+		if err != nil || userId != 7 {
+			t.Fatalf("got %d, %v, want 7", userId, err)
+		}
+		if len(f.users.reclaimed) != 1 || f.users.reclaimed[0] != 7 {
+			t.Errorf("got reclaimed %v, want the account reclaimed", f.users.reclaimed)
+		}
+		if len(f.sessions.deleted) != 1 || f.sessions.deleted[0] != 7 {
+			t.Errorf("got sessions deleted for %v, want the sessions dropped", f.sessions.deleted)
+		}
+	})
+
+	t.Run("new user", func(t *testing.T) {
+		f := newOIDCFixture()
+
+		userId, err := f.svc.userForIdentity(context.Background(), identity)
+		if err != nil {
+			t.Fatal(err)
+		}
+		user := f.users.users[identity.Email]
+		if user.Id != userId || !user.EmailVerified || user.Name != "user" {
+			t.Errorf("got %+v", user)
+		}
+		if f.identities.links[identity.Issuer+" "+identity.Subject] != userId {
+			t.Error("the identity is not linked")
+		}
+		if len(f.outbox.events) != 1 || f.outbox.events[0].Type != domain.EventUserRegistered {
+			t.Errorf("got events %+v, want user registered", f.outbox.events)
+		}
+	})
+}
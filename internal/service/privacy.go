@@ -0,0 +1,276 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/xopxe23/news-server/internal/domain"
+)
+
+// exportLease is how long a claimed export stays hidden from other workers.
+const exportLease = 5 * time.Minute
+
+type ExportsRepository interface {
+	Create(ctx context.Context, userId int) (domain.DataExport, error)
+	GetById(ctx context.Context, userId, exportId int) (domain.DataExport, error)
+	GetArchive(ctx context.Context, userId, exportId int, now time.Time) ([]byte, error)
+	ClaimPending(ctx context.Context, limit int, lease time.Duration) ([]domain.DataExport, error)
+	Complete(ctx context.Context, exportId int, archive []byte, expiresAt time.Time) error
+	Fail(ctx context.Context, exportId int, reason string) error
+	DeleteExpired(ctx context.Context, now time.Time) error
+}
+
+// PrivacyOutbox is the outbox which can also forget what its events told about a user.
+type PrivacyOutbox interface {
+	Outbox
+	AnonymizeUser(ctx context.Context, userId int) error
+}
+
+type PasswordVerifier interface {
+	VerifyPassword(ctx context.Context, userId int, password string) error
+}
+
+// UserData are the repositories holding data of users, exports are assembled from them.
+type UserData struct {
+	Users      UsersRepository
+	Sessions   SessionsRepository
+	APIKeys    APIKeysRepository
+	OAuth      OAuthRepository
+	Identities IdentitiesRepository
+}
+
+type PrivacyConfig struct {
+	PollInterval time.Duration
+	BatchSize    int
+	// ExportTTL is how long a ready archive can be downloaded.
+	ExportTTL time.Duration
+	// DeletionGrace is how long a scheduled account deletion can be cancelled.
+	DeletionGrace time.Duration
+}
+
+// PrivacyService exports the data of users and deletes their accounts. Both happen in the background, see Run.
+type PrivacyService struct {
+	exports    ExportsRepository
+	data       UserData
+	outbox     PrivacyOutbox
+	transactor Transactor
+	passwords  PasswordVerifier
+	guard      *SignInGuard
+	cfg        PrivacyConfig
+}
+
+func NewPrivacyService(exports ExportsRepository, data UserData, outbox PrivacyOutbox, transactor Transactor,
+	passwords PasswordVerifier, guard *SignInGuard, cfg PrivacyConfig) *PrivacyService {
+	return &PrivacyService{
+		exports:    exports,
+		data:       data,
+		outbox:     outbox,
+		transactor: transactor,
+		passwords:  passwords,
+		guard:      guard,
+		cfg:        cfg,
+	}
+}
+
+// RequestExport queues an export of the user's data. While one is pending, it is returned instead.
+func (s *PrivacyService) RequestExport(ctx context.Context, userId int) (domain.DataExport, error) {
+	return s.exports.Create(ctx, userId)
+}
+
+func (s *PrivacyService) GetExport(ctx context.Context, userId, exportId int) (domain.DataExport, error) {
+	return s.exports.GetById(ctx, userId, exportId)
+}
+
+// GetExportArchive returns the zip archive of a ready export.
+func (s *PrivacyService) GetExportArchive(ctx context.Context, userId, exportId int) ([]byte, error) {
+	return s.exports.GetArchive(ctx, userId, exportId, time.Now())
+}
+
+// ScheduleDeletion deletes the account after the grace period, unless CancelDeletion is called before.
+func (s *PrivacyService) ScheduleDeletion(ctx context.Context, userId int, input domain.DeleteAccountInput) (domain.AccountDeletion, error) {
+	if err := input.Validate(); err != nil {
+		return domain.AccountDeletion{}, err
+	}
+	if err := s.passwords.VerifyPassword(ctx, userId, input.Password); err != nil {
+		return domain.AccountDeletion{}, err
+	}
+
+	user, err := s.data.Users.GetById(ctx, userId)
+	if err != nil {
+		return domain.AccountDeletion{}, err
+	}
+	if user.DeletionScheduledAt != nil {
+		return domain.AccountDeletion{ScheduledAt: *user.DeletionScheduledAt}, nil
+	}
+
+	at := time.Now().Add(s.cfg.DeletionGrace).UTC()
+	if err := s.data.Users.ScheduleDeletion(ctx, userId, &at); err != nil {
+		return domain.AccountDeletion{}, err
+	}
+	return domain.AccountDeletion{ScheduledAt: at}, nil
+}
+
+func (s *PrivacyService) CancelDeletion(ctx context.Context, userId int) error {
+	user, err := s.data.Users.GetById(ctx, userId)
+	if err != nil {
+		return err
+	}
+	if user.DeletionScheduledAt == nil {
+		return domain.Conflict("account deletion is not scheduled")
+	}
+	return s.data.Users.ScheduleDeletion(ctx, userId, nil)
+}
+
+// Run assembles requested exports and deletes accounts whose grace period is over until ctx is cancelled.
+func (s *PrivacyService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		s.processExports(ctx)
+		s.processDeletions(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *PrivacyService) processExports(ctx context.Context) {
+	log := logrus.WithField("method", "PrivacyService.processExports")
+
+	if err := s.exports.DeleteExpired(ctx, time.Now()); err != nil {
+		log.Error(err)
+	}
+
+	exports, err := s.exports.ClaimPending(ctx, s.cfg.BatchSize, exportLease)
+	if err != nil {
+		log.Error(err)
+		return
+	}
+
+	for _, export := range exports {
+		archive, err := s.buildArchive(ctx, export.UserId)
+		if err == nil {
+			err = s.exports.Complete(ctx, export.Id, archive, time.Now().Add(s.cfg.ExportTTL))
+		} else {
+			log.WithField("export", export.Id).Error(err)
+			err = s.exports.Fail(ctx, export.Id, "failed to assemble the archive")
+		}
+		if err != nil {
+			log.WithField("export", export.Id).Error(err)
+		}
+	}
+}
+
+// buildArchive collects everything stored about the user into a zip archive of JSON files.
+func (s *PrivacyService) buildArchive(ctx context.Context, userId int) ([]byte, error) {
+	user, err := s.data.Users.GetById(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	bookmarks, err := s.data.Users.GetBookmarks(ctx, userId, domain.ArticleQuery{})
+	if err != nil {
+		return nil, err
+	}
+	if bookmarks == nil {
+		bookmarks = []domain.ArticleOutput{}
+	}
+	sessions, err := s.data.Sessions.GetByUser(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	apiKeys, err := s.data.APIKeys.GetByUser(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	clients, err := s.data.OAuth.GetClientsByOwner(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	identities, err := s.data.Identities.GetByUser(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", profileOf(user)},
+		{"bookmarks.json", bookmarks},
+		{"sessions.json", sessions},
+		{"api_keys.json", apiKeys},
+		{"oauth_clients.json", clients},
+		{"identities.json", identities},
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for _, file := range files {
+		w, err := archive.Create(file.name)
+		if err != nil {
+			return nil, err
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(file.data); err != nil {
+			return nil, err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (s *PrivacyService) processDeletions(ctx context.Context) {
+	log := logrus.WithField("method", "PrivacyService.processDeletions")
+
+	now := time.Now()
+	users, err := s.data.Users.GetDueDeletions(ctx, now, s.cfg.BatchSize)
+	if err != nil {
+		log.Error(err)
+		return
+	}
+
+	for _, user := range users {
+		if err := s.deleteUser(ctx, user, now); err != nil && !errors.Is(err, domain.ErrNotFound) {
+			log.WithField("user", user.Id).Error(err)
+		}
+	}
+}
+
+// deleteUser removes the user with everything they own and strips their personal data from the events.
+// Audit records keep referring to the user by id only.
+func (s *PrivacyService) deleteUser(ctx context.Context, user domain.User, now time.Time) error {
+	err := s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.outbox.AnonymizeUser(ctx, user.Id); err != nil {
+			return err
+		}
+		if err := s.data.Users.Delete(ctx, user.Id, now); err != nil {
+			return err
+		}
+
+		event, err := domain.NewEvent(domain.EventUserDeleted, user.Id, map[string]interface{}{
+			"id": user.Id,
+		})
+		if err != nil {
+			return err
+		}
+		return s.outbox.Add(ctx, event)
+	})
+	if err != nil {
+		return err
+	}
+
+	// failed sign-ins are counted by email
+	return s.guard.Unlock(ctx, user.Email)
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/xopxe23/news-server/internal/domain"
+)
+
+// emailTokenTTL is how long the link confirming a new email works.
+const emailTokenTTL = 24 * time.Hour
+
+func (s *UsersService) GetProfile(ctx context.Context, userId int) (domain.Profile, error) {
+	user, err := s.repo.GetById(ctx, userId)
+	if err != nil {
+		return domain.Profile{}, err
+	}
+	return profileOf(user), nil
+}
+
+func profileOf(user domain.User) domain.Profile {
+	return domain.Profile{
+		Id:                  user.Id,
+		Name:                user.Name,
+		Email:               user.Email,
+		Role:                user.Role,
+		AvatarURL:           user.AvatarURL,
+		PendingEmail:        user.PendingEmail,
+		DeletionScheduledAt: user.DeletionScheduledAt,
+	}
+}
+
+func (s *UsersService) UpdateProfile(ctx context.Context, userId int, input domain.UpdateProfileInput) (domain.Profile, error) {
+	if err := input.Validate(); err != nil {
+		return domain.Profile{}, err
+	}
+	if err := s.repo.Update(ctx, userId, input); err != nil {
+		return domain.Profile{}, err
+	}
+	return s.GetProfile(ctx, userId)
+}
+
+// ChangePassword sets a new password and ends all sessions of the user.
+// The caller gets new tokens, so only the other sessions have to sign in again.
+func (s *UsersService) ChangePassword(ctx context.Context, userId int, input domain.ChangePasswordInput) (string, string, error) {
+	if err := input.Validate(); err != nil {
+		return "", "", err
+	}
+
+	user, err := s.repo.GetById(ctx, userId)
+	if err != nil {
+		return "", "", err
+	}
+	if err := s.checkPassword(ctx, user.Email, input.CurrentPassword); err != nil {
+		return "", "", err
+	}
+
+	password, err := s.hasher.Hash(input.NewPassword)
+	if err != nil {
+		return "", "", err
+	}
+
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.UpdatePassword(ctx, userId, password); err != nil {
+			return err
+		}
+		return s.sessionsRepo.DeleteByUser(ctx, userId)
+	})
+	if err != nil {
+		return "", "", err
+	}
+	return s.generateTokens(ctx, userId)
+}
+
+// RequestEmailChange starts changing the email. It changes once the link sent to the new address
+// is followed, see ConfirmEmailChange.
+func (s *UsersService) RequestEmailChange(ctx context.Context, userId int, input domain.ChangeEmailInput) error {
+	if err := input.Validate(); err != nil {
+		return err
+	}
+
+	user, err := s.repo.GetById(ctx, userId)
+	if err != nil {
+		return err
+	}
+	if err := s.checkPassword(ctx, user.Email, input.Password); err != nil {
+		return err
+	}
+	if strings.EqualFold(user.Email, input.Email) {
+		return domain.InvalidInput("this is the current email", nil)
+	}
+	if _, err := s.repo.GetByEmail(ctx, input.Email); err == nil {
+		return domain.Conflict("user with this email already exists")
+	} else if !errors.Is(err, domain.ErrNotFound) {
+		return err
+	}
+
+	token, err := randomToken(32)
+	if err != nil {
+		return err
+	}
+	sealed, err := sealToken(s.hmacSecret, token)
+	if err != nil {
+		return err
+	}
+	expiresAt := time.Now().Add(emailTokenTTL)
+
+	return s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.SetPendingEmail(ctx, userId, input.Email, hashSecret(token), expiresAt); err != nil {
+			return err
+		}
+
+		event, err := domain.NewEvent(domain.EventUserEmailChangeRequested, userId, map[string]interface{}{
+			"id":           userId,
+			"email":        input.Email,
+			"sealed_token": sealed,
+			"expires_at":   expiresAt.UTC(),
+		})
+		if err != nil {
+			return err
+		}
+		return s.outbox.Add(ctx, event)
+	})
+}
+
+func (s *UsersService) ConfirmEmailChange(ctx context.Context, input domain.ConfirmEmailInput) error {
+	if err := input.Validate(); err != nil {
+		return err
+	}
+
+	return s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		change, err := s.repo.ConfirmEmail(ctx, hashSecret(input.Token), time.Now())
+		if err != nil {
+			return err
+		}
+
+		event, err := domain.NewEvent(domain.EventUserEmailChanged, change.UserId, map[string]interface{}{
+			"id":        change.UserId,
+			"old_email": change.OldEmail,
+			"email":     change.NewEmail,
+		})
+		if err != nil {
+			return err
+		}
+		return s.outbox.Add(ctx, event)
+	})
+}
+
+// VerifyPassword checks the password of a signed in user before a sensitive operation.
+func (s *UsersService) VerifyPassword(ctx context.Context, userId int, password string) error {
+	user, err := s.repo.GetById(ctx, userId)
+	if err != nil {
+		return err
+	}
+	return s.checkPassword(ctx, user.Email, password)
+}
+
+// checkPassword verifies the password of a signed in user. Wrong passwords count as failed sign-ins,
+// so a stolen session can't be used to guess the password.
+func (s *UsersService) checkPassword(ctx context.Context, email, password string) error {
+	ip := domain.ClientIPFrom(ctx)
+	if err := s.guard.Check(ctx, email, ip); err != nil {
+		return err
+	}
+
+	hash, err := s.hasher.Hash(password)
+	if err != nil {
+		return err
+	}
+	if _, err := s.repo.GetByCredentials(ctx, email, hash); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			if err := s.signInFailed(ctx, email, ip); err != nil {
+				return err
+			}
+			return domain.Unauthorized("wrong password")
+		}
+		return err
+	}
+	return nil
+}
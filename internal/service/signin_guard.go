@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/xopxe23/news-server/internal/domain"
+)
+
+type LoginFailuresRepository interface {
+	Get(ctx context.Context, key string) (domain.LoginFailures, error)
+	RecordFailure(ctx context.Context, key string, now, windowStart time.Time) (domain.LoginFailures, error)
+	Lock(ctx context.Context, key string, until time.Time) error
+	Reset(ctx context.Context, key string) error
+}
+
+type SignInGuardConfig struct {
+	// MaxFailures is the number of failed attempts after which an account is locked.
+	MaxFailures int
+	// IPMaxFailures is the number of failed attempts after which an IP address is locked.
+	IPMaxFailures int
+	// Window is how long failures are remembered after the last one.
+	Window  time.Duration
+	Lockout time.Duration
+	// BaseDelay is the pause required after the first failure, it doubles with every next one up to MaxDelay.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+// SignInGuard slows down and locks out password guessing. Failures are counted per email, whether
+// the account exists or not, so its answers don't tell which emails are registered.
+type SignInGuard struct {
+	repo LoginFailuresRepository
+	cfg  SignInGuardConfig
+}
+
+func NewSignInGuard(repo LoginFailuresRepository, cfg SignInGuardConfig) *SignInGuard {
+	return &SignInGuard{repo: repo, cfg: cfg}
+}
+
+// Check returns an ErrTooManyRequests error if signing in to email from ip isn't allowed at the moment.
+func (g *SignInGuard) Check(ctx context.Context, email, ip string) error {
+	now := time.Now()
+	for _, key := range g.keys(email, ip) {
+		failures, err := g.repo.Get(ctx, key)
+		if err != nil {
+			return err
+		}
+
+		if failures.Locked(now) {
+			return domain.TooManyRequests("too many failed sign-in attempts, try again later", failures.LockedUntil.Sub(now))
+		}
+		if failures.Failures == 0 || now.Sub(failures.LastFailureAt) > g.cfg.Window {
+			continue
+		}
+		if next := failures.LastFailureAt.Add(g.delay(failures.Failures)); now.Before(next) {
+			return domain.TooManyRequests("too many failed sign-in attempts, try again later", next.Sub(now))
+		}
+	}
+	return nil
+}
+
+// Fail records a failed attempt and locks the account or the IP address once they reach their limits.
+// It returns when the account lock ends if this attempt locked it.
+func (g *SignInGuard) Fail(ctx context.Context, email, ip string) (time.Time, error) {
+	now := time.Now()
+	windowStart := now.Add(-g.cfg.Window)
+
+	var accountLockedUntil time.Time
+	for _, key := range g.keys(email, ip) {
+		failures, err := g.repo.RecordFailure(ctx, key, now, windowStart)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		limit := g.cfg.MaxFailures
+		if strings.HasPrefix(key, "ip:") {
+			limit = g.cfg.IPMaxFailures
+		}
+		if failures.Failures < limit || failures.Locked(now) {
+			continue
+		}
+
+		until := now.Add(g.cfg.Lockout)
+		if err := g.repo.Lock(ctx, key, until); err != nil {
+			return time.Time{}, err
+		}
+		if key == accountKey(email) {
+			accountLockedUntil = until
+		}
+	}
+	return accountLockedUntil, nil
+}
+
+// Succeed forgets failures of the account after the right password was given.
+func (g *SignInGuard) Succeed(ctx context.Context, email string) error {
+	return g.repo.Reset(ctx, accountKey(email))
+}
+
+// Unlock lifts the lock of the account and forgets its failures.
+func (g *SignInGuard) Unlock(ctx context.Context, email string) error {
+	return g.repo.Reset(ctx, accountKey(email))
+}
+
+func (g *SignInGuard) keys(email, ip string) []string {
+	keys := []string{accountKey(email)}
+	if ip != "" {
+		keys = append(keys, "ip:"+ip)
+	}
+	return keys
+}
+
+func (g *SignInGuard) delay(failures int) time.Duration {
+	delay := g.cfg.BaseDelay
+	for i := 1; i < failures && delay < g.cfg.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > g.cfg.MaxDelay {
+		return g.cfg.MaxDelay
+	}
+	return delay
+}
+
+func accountKey(email string) string {
+	return "email:" + strings.ToLower(email)
+}
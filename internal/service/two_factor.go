@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/xopxe23/news-server/internal/domain"
+	"github.com/xopxe23/news-server/pkg/totp"
+)
+
+const recoveryCodesCount = 10
+
+type TwoFactorRepository interface {
+	Get(ctx context.Context, userId int) (domain.TOTP, error)
+	SetSecret(ctx context.Context, userId int, secret string) error
+	Enable(ctx context.Context, userId int) error
+	Disable(ctx context.Context, userId int) error
+	UseStep(ctx context.Context, userId int, step int64) error
+	ReplaceRecoveryCodes(ctx context.Context, userId int, hashes []string) error
+	UseRecoveryCode(ctx context.Context, userId int, hash string) error
+}
+
+// TwoFactor manages TOTP secrets and recovery codes of users.
+type TwoFactor struct {
+	repo       TwoFactorRepository
+	transactor Transactor
+	hasher     PasswordHasher
+	issuer     string
+}
+
+func NewTwoFactor(repo TwoFactorRepository, transactor Transactor, hasher PasswordHasher, issuer string) *TwoFactor {
+	return &TwoFactor{repo: repo, transactor: transactor, hasher: hasher, issuer: issuer}
+}
+
+func (t *TwoFactor) Enabled(ctx context.Context, userId int) (bool, error) {
+	state, err := t.repo.Get(ctx, userId)
+	if err != nil {
+		return false, err
+	}
+	return state.Enabled, nil
+}
+
+// Enroll generates a new secret for the user. It takes effect after Confirm.
+func (t *TwoFactor) Enroll(ctx context.Context, userId int, account string) (domain.TOTPEnrollment, error) {
+	state, err := t.repo.Get(ctx, userId)
+	if err != nil {
+		return domain.TOTPEnrollment{}, err
+	}
+	if state.Enabled {
+		return domain.TOTPEnrollment{}, domain.Conflict("two-factor authentication is already enabled")
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return domain.TOTPEnrollment{}, err
+	}
+	if err := t.repo.SetSecret(ctx, userId, secret); err != nil {
+		return domain.TOTPEnrollment{}, err
+	}
+
+	return domain.TOTPEnrollment{
+		Secret: secret,
+		URI:    totp.URI(t.issuer, account, secret),
+	}, nil
+}
+
+// Confirm enables two-factor authentication if code matches the enrolled secret
+// and returns recovery codes. They are stored hashed, so this is the only time they can be seen.
+func (t *TwoFactor) Confirm(ctx context.Context, userId int, code string) ([]string, error) {
+	state, err := t.repo.Get(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	if state.Enabled {
+		return nil, domain.Conflict("two-factor authentication is already enabled")
+	}
+	if state.Secret == "" {
+		return nil, domain.Conflict("two-factor enrollment is not started")
+	}
+
+	if err := t.checkCode(ctx, userId, state, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := t.newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	err = t.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := t.repo.Enable(ctx, userId); err != nil {
+			return err
+		}
+		return t.repo.ReplaceRecoveryCodes(ctx, userId, hashes)
+	})
+	return codes, err
+}
+
+// Disable turns two-factor authentication off. It takes a code or a recovery code to prove
+// the second factor is still at hand.
+func (t *TwoFactor) Disable(ctx context.Context, userId int, code string) error {
+	if err := t.Verify(ctx, userId, code); err != nil {
+		return err
+	}
+	return t.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		return t.repo.Disable(ctx, userId)
+	})
+}
+
+// Verify accepts a code from the authenticator app or an unused recovery code of the user.
+func (t *TwoFactor) Verify(ctx context.Context, userId int, code string) error {
+	state, err := t.repo.Get(ctx, userId)
+	if err != nil {
+		return err
+	}
+	if !state.Enabled {
+		return domain.Conflict("two-factor authentication is not enabled")
+	}
+
+	code = strings.TrimSpace(code)
+	if len(code) == totp.Digits {
+		return t.checkCode(ctx, userId, state, code)
+	}
+
+	hash, err := t.hasher.Hash(normalizeRecoveryCode(code))
+	if err != nil {
+		return err
+	}
+	if err := t.repo.UseRecoveryCode(ctx, userId, hash); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.Unauthorized("invalid code")
+		}
+		return err
+	}
+	return nil
+}
+
+func (t *TwoFactor) checkCode(ctx context.Context, userId int, state domain.TOTP, code string) error {
+	step, ok := totp.Validate(state.Secret, code, time.Now())
+	if !ok {
+		return domain.Unauthorized("invalid code")
+	}
+
+	if err := t.repo.UseStep(ctx, userId, step); err != nil {
+		if errors.Is(err, domain.ErrConflict) {
+			return domain.Unauthorized("invalid code")
+		}
+		return err
+	}
+	return nil
+}
+
+// newRecoveryCodes returns codes formatted as xxxxx-xxxxx and their hashes.
+func (t *TwoFactor) newRecoveryCodes() ([]string, []string, error) {
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+
+	codes := make([]string, 0, recoveryCodesCount)
+	hashes := make([]string, 0, recoveryCodesCount)
+	for i := 0; i < recoveryCodesCount; i++ {
+		b := make([]byte, 7)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		raw := strings.ToLower(encoding.EncodeToString(b))[:10]
+
+		hash, err := t.hasher.Hash(raw)
+		if err != nil {
+			return nil, nil, err
+		}
+		codes = append(codes, raw[:5]+"-"+raw[5:])
+		hashes = append(hashes, hash)
+	}
+	return codes, hashes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(code, "-", ""))
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/xopxe23/news-server/internal/domain"
+	"github.com/xopxe23/news-server/pkg/totp"
+)
+
+// fakeTwoFactorRepository keeps the state of one user, the other calls panic on the nil interface.
+// UseStep behaves like the repository: only steps later than the last used one are accepted.
+type fakeTwoFactorRepository struct {
+	TwoFactorRepository
+	state domain.TOTP
+}
+
+func (r *fakeTwoFactorRepository) Get(ctx context.Context, userId int) (domain.TOTP, error) {
+	return r.state, nil
+}
+
+func (r *fakeTwoFactorRepository) UseStep(ctx context.Context, userId int, step int64) error {
+	if step <= r.state.LastStep {
+		return domain.Conflict("code was already used")
+	}
+	r.state.LastStep = step
+	return nil
+}
+
+func TestTwoFactorRejectsReusedCodes(t *testing.T) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := &fakeTwoFactorRepository{state: domain.TOTP{Secret: secret, Enabled: true}}
+	twoFactor := NewTwoFactor(repo, nil, nil, "news")
+
+	current := totp.Step(time.Now())
+	code := func(step int64) string {
+		c, err := totp.Code(secret, step)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	if err := twoFactor.Verify(context.Background(), 1, code(current-1)); err != nil {
+		t.Fatalf("the code of the previous step must be accepted: %v", err)
+	}
+	if err := twoFactor.Verify(context.Background(), 1, code(current)); err != nil {
+		t.Fatalf("the code of the current step must be accepted: %v", err)
+	}
+
+	for name, reused := range map[string]string{
+		"same code":    code(current),
+		"earlier code": code(current - 1),
+	} {
+		err := twoFactor.Verify(context.Background(), 1, reused)
+		if !errors.Is(err, domain.ErrUnauthorized) {
+			t.Errorf("%s: got %v, want unauthorized", name, err)
+		}
+	}
+	if repo.state.LastStep != current {
+		t.Errorf("got last step %d, want %d", repo.state.LastStep, current)
+	}
+}
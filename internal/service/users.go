@@ -9,45 +9,80 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
-	"github.com/sirupsen/logrus"
-	audit "github.com/xopxe23/auditlog/pkg/domain"
 	"github.com/xopxe23/news-server/internal/domain"
 )
 
+const (
+	tokenTypeAccess    = "access"
+	tokenTypeChallenge = "mfa_challenge"
+
+	challengeTTL = 5 * time.Minute
+)
+
 type PasswordHasher interface {
 	Hash(password string) (string, error)
 }
 
 type UsersRepository interface {
-	Create(ctx context.Context, user domain.User) error
+	Create(ctx context.Context, user domain.User) (int, error)
 	GetByCredentials(ctx context.Context, email, password string) (domain.User, error)
-	GetBookmarks(ctx context.Context, userId int) ([]domain.ArticleOutput, error)
+	GetById(ctx context.Context, userId int) (domain.User, error)
+	GetByEmail(ctx context.Context, email string) (domain.User, error)
+	GetBookmarks(ctx context.Context, userId int, query domain.ArticleQuery) ([]domain.ArticleOutput, error)
+	GetRole(ctx context.Context, userId int) (string, error)
+	SetRole(ctx context.Context, userId int, role string) error
+	Update(ctx context.Context, userId int, input domain.UpdateProfileInput) error
+	UpdatePassword(ctx context.Context, userId int, password string) error
+	ReclaimAccount(ctx context.Context, userId int, at time.Time) error
+	SetPendingEmail(ctx context.Context, userId int, email, tokenHash string, expiresAt time.Time) error
+	ConfirmEmail(ctx context.Context, tokenHash string, now time.Time) (domain.EmailChange, error)
+	ScheduleDeletion(ctx context.Context, userId int, at *time.Time) error
+	GetDueDeletions(ctx context.Context, now time.Time, limit int) ([]domain.User, error)
+	Delete(ctx context.Context, userId int, now time.Time) error
+	List(ctx context.Context, filter domain.UserFilter) ([]domain.AdminUser, int, error)
+	GetAdminUser(ctx context.Context, userId int) (domain.AdminUser, error)
+	CountBookmarks(ctx context.Context, userId int) (int, error)
+	GetStatus(ctx context.Context, userId int) (domain.AccountStatus, error)
+	SetDisabled(ctx context.Context, userId int, at *time.Time) error
+	RevokeSessions(ctx context.Context, userId int, at time.Time) error
+	SetPasswordReset(ctx context.Context, userId int, tokenHash string, expiresAt time.Time) error
+	ResetPassword(ctx context.Context, tokenHash, password string, now time.Time) (domain.PasswordReset, error)
 }
 
 type SessionsRepository interface {
 	Create(ctx context.Context, token domain.RefreshSession) error
 	GetToken(ctx context.Context, token string) (domain.RefreshSession, error)
-}
-
-type AuditClient interface {
-	SendLogRequest(ctx context.Context, req audit.LogItem) error
+	DeleteByUser(ctx context.Context, userId int) error
+	GetByUser(ctx context.Context, userId int) ([]domain.Session, error)
 }
 
 type UsersService struct {
-	repo         UsersRepository
-	hasher       PasswordHasher
-	auditClient  AuditClient
-	sessionsRepo SessionsRepository
-	hmacSecret   []byte
+	repo           UsersRepository
+	hasher         PasswordHasher
+	transactor     Transactor
+	outbox         Outbox
+	sessionsRepo   SessionsRepository
+	guard          *SignInGuard
+	twoFactor      *TwoFactor
+	apiKeysRepo    APIKeysRepository
+	oidc           *OIDC
+	impersonations ImpersonationsRepository
+	hmacSecret     []byte
 }
 
-func NewUsersService(repo UsersRepository, auditClient AuditClient, hasher PasswordHasher, sessionsRepo SessionsRepository, secret []byte) *UsersService {
+func NewUsersService(repo UsersRepository, transactor Transactor, outbox Outbox, hasher PasswordHasher, sessionsRepo SessionsRepository, guard *SignInGuard, twoFactor *TwoFactor, apiKeysRepo APIKeysRepository, oidc *OIDC, impersonations ImpersonationsRepository, secret []byte) *UsersService {
 	return &UsersService{
-		repo:         repo,
-		sessionsRepo: sessionsRepo,
-		auditClient:  auditClient,
-		hasher:       hasher,
-		hmacSecret:   secret,
+		repo:           repo,
+		sessionsRepo:   sessionsRepo,
+		transactor:     transactor,
+		outbox:         outbox,
+		hasher:         hasher,
+		guard:          guard,
+		twoFactor:      twoFactor,
+		apiKeysRepo:    apiKeysRepo,
+		oidc:           oidc,
+		impersonations: impersonations,
+		hmacSecret:     secret,
 	}
 }
 
@@ -66,59 +101,178 @@ func (s *UsersService) SignUp(ctx context.Context, input domain.SignUpInput) err
 		return err
 	}
 
-	if err := s.repo.Create(ctx, user); err != nil {
-		return err
+	return s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		id, err := s.repo.Create(ctx, user)
+		if err != nil {
+			return err
+		}
+
+		event, err := domain.NewEvent(domain.EventUserRegistered, id, map[string]interface{}{
+			"id":    id,
+			"name":  user.Name,
+			"email": user.Email,
+		})
+		if err != nil {
+			return err
+		}
+		return s.outbox.Add(ctx, event)
+	})
+}
+
+// SignIn checks the password and issues tokens. Users with two-factor authentication
+// get a challenge token instead, see VerifyMFA.
+func (s *UsersService) SignIn(ctx context.Context, input domain.SignInInput) (domain.SignInResult, error) {
+	ip := domain.ClientIPFrom(ctx)
+	if err := s.guard.Check(ctx, input.Email, ip); err != nil {
+		return domain.SignInResult{}, err
 	}
 
-	user, err = s.repo.GetByCredentials(ctx, input.Email, password)
+	password, err := s.hasher.Hash(input.Password)
 	if err != nil {
-		return err
+		return domain.SignInResult{}, err
 	}
 
-	if err := s.auditClient.SendLogRequest(ctx, audit.LogItem{
-		Action: audit.ACTION_REGISTER,
-		Entity: audit.ENTITY_USER,
-		EntityID: int64(user.Id),
-		Timestamp: time.Now(),
-	}); err != nil {
-		logrus.WithFields(logrus.Fields{
-			"method": "Users.SignUp",
-		}).Error("failed to sent log request: ", err)
+	user, err := s.repo.GetByCredentials(ctx, input.Email, password)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			if err := s.signInFailed(ctx, input.Email, ip); err != nil {
+				return domain.SignInResult{}, err
+			}
+			// the answer is the same whether the account exists or not
+			return domain.SignInResult{}, domain.Unauthorized("invalid email or password")
+		}
+		return domain.SignInResult{}, err
 	}
 
-	return nil
-}
+	if err := s.guard.Succeed(ctx, input.Email); err != nil {
+		return domain.SignInResult{}, err
+	}
 
-func (s *UsersService) SignIn(ctx context.Context, input domain.SignInInput) (string, string, error) {
-	password, err := s.hasher.Hash(input.Password)
+	mfa, err := s.twoFactor.Enabled(ctx, user.Id)
 	if err != nil {
+		return domain.SignInResult{}, err
+	}
+	if mfa {
+		challenge, err := s.newChallengeToken(user.Id)
+		return domain.SignInResult{ChallengeToken: challenge}, err
+	}
+
+	accessToken, refreshToken, err := s.generateTokens(ctx, user.Id)
+	return domain.SignInResult{AccessToken: accessToken, RefreshToken: refreshToken}, err
+}
+
+// VerifyMFA exchanges a challenge token and a code from the authenticator app, or a recovery code, for tokens.
+// Wrong codes count as failed sign-ins.
+func (s *UsersService) VerifyMFA(ctx context.Context, input domain.MFAVerifyInput) (string, string, error) {
+	if err := input.Validate(); err != nil {
 		return "", "", err
 	}
 
-	user, err := s.repo.GetByCredentials(ctx, input.Email, password)
+	userId, err := s.parseToken(input.ChallengeToken, tokenTypeChallenge)
+	if err != nil {
+		return "", "", err
+	}
+	user, err := s.repo.GetById(ctx, userId)
 	if err != nil {
 		return "", "", err
 	}
 
-	return s.generateTokens(ctx, user.Id)
+	ip := domain.ClientIPFrom(ctx)
+	if err := s.guard.Check(ctx, user.Email, ip); err != nil {
+		return "", "", err
+	}
+
+	if err := s.twoFactor.Verify(ctx, userId, input.Code); err != nil {
+		if errors.Is(err, domain.ErrUnauthorized) {
+			if err := s.signInFailed(ctx, user.Email, ip); err != nil {
+				return "", "", err
+			}
+		}
+		return "", "", err
+	}
+
+	if err := s.guard.Succeed(ctx, user.Email); err != nil {
+		return "", "", err
+	}
+	return s.generateTokens(ctx, userId)
+}
+
+// signInFailed counts the failure and lets the owner of the account know if it got locked.
+func (s *UsersService) signInFailed(ctx context.Context, email, ip string) error {
+	lockedUntil, err := s.guard.Fail(ctx, email, ip)
+	if err != nil {
+		return err
+	}
+
+	if !lockedUntil.IsZero() {
+		return s.notifyLocked(ctx, email, lockedUntil)
+	}
+	return nil
+}
+
+func (s *UsersService) notifyLocked(ctx context.Context, email string, lockedUntil time.Time) error {
+	user, err := s.repo.GetByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	event, err := domain.NewEvent(domain.EventUserLocked, user.Id, map[string]interface{}{
+		"id":           user.Id,
+		"locked_until": lockedUntil.UTC(),
+	})
+	if err != nil {
+		return err
+	}
+	return s.outbox.Add(ctx, event)
+}
+
+// UnlockUser lifts the sign-in lock of the user.
+func (s *UsersService) UnlockUser(ctx context.Context, userId int) error {
+	user, err := s.repo.GetById(ctx, userId)
+	if err != nil {
+		return err
+	}
+	return s.guard.Unlock(ctx, user.Email)
 }
 
 func (s *UsersService) RefreshTokens(ctx context.Context, token string) (string, string, error) {
 	session, err := s.sessionsRepo.GetToken(ctx, token)
 	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return "", "", domain.Unauthorized("invalid refresh token")
+		}
 		return "", "", err
 	}
 
 	if session.ExpiresAt.Unix() < time.Now().Unix() {
-		return "", "", err
+		return "", "", domain.Unauthorized("refresh token expired")
 	}
 
 	return s.generateTokens(ctx, session.UserId)
 }
 
+func (s *UsersService) newChallengeToken(userId int) (string, error) {
+	t := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": strconv.Itoa(userId),
+		"typ": tokenTypeChallenge,
+		"iat": time.Now().Unix(),
+		"exp": time.Now().Add(challengeTTL).Unix(),
+	})
+	return t.SignedString(s.hmacSecret)
+}
+
+// generateTokens starts a new session of the user unless they are disabled.
 func (s *UsersService) generateTokens(ctx context.Context, userId int) (string, string, error) {
+	if err := s.CheckActive(ctx, userId); err != nil {
+		return "", "", err
+	}
+
 	t := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 		"sub": strconv.Itoa(userId),
+		"typ": tokenTypeAccess,
 		"iat": time.Now().Unix(),
 		"exp": time.Now().Add(time.Hour * 24).Unix(),
 	})
@@ -156,7 +310,72 @@ func newRefreshToken() (string, error) {
 	return fmt.Sprintf("%x", b), nil
 }
 
+// ParseToken returns the user of a valid access token. Impersonation tokens are refused,
+// they are only accepted where ParseAccessToken is used.
 func (s *UsersService) ParseToken(ctx context.Context, token string) (int, error) {
+	principal, err := s.ParseAccessToken(ctx, token)
+	if err != nil {
+		return 0, err
+	}
+	if principal.Impersonated() {
+		return 0, domain.Unauthorized("impersonation tokens can't be used here")
+	}
+	return principal.UserId, nil
+}
+
+// ParseAccessToken returns who a valid access token speaks for, as long as the user is active
+// and the session of the token hasn't been ended.
+func (s *UsersService) ParseAccessToken(ctx context.Context, token string) (domain.Principal, error) {
+	claims, err := s.parseClaims(token, tokenTypeAccess)
+	if err != nil {
+		return domain.Principal{}, err
+	}
+	userId, err := subjectOf(claims)
+	if err != nil {
+		return domain.Principal{}, err
+	}
+
+	var issuedAt time.Time
+	if iat, err := claims.GetIssuedAt(); err == nil && iat != nil {
+		issuedAt = iat.Time
+	} else {
+		// tokens without issue time can't be told apart from the ones issued before their session ended
+		issuedAt = time.Unix(0, 0)
+	}
+	if err := s.checkAccess(ctx, userId, issuedAt); err != nil {
+		return domain.Principal{}, err
+	}
+
+	if _, ok := claims["imp"]; ok {
+		return s.impersonationOf(ctx, userId, claims)
+	}
+	return domain.Principal{UserId: userId}, nil
+}
+
+// parseToken returns the user id of a valid token of the given type.
+func (s *UsersService) parseToken(token, tokenType string) (int, error) {
+	claims, err := s.parseClaims(token, tokenType)
+	if err != nil {
+		return 0, err
+	}
+	return subjectOf(claims)
+}
+
+func subjectOf(claims jwt.MapClaims) (int, error) {
+	subject, ok := claims["sub"].(string)
+	if !ok {
+		return 0, domain.Unauthorized("invalid subject")
+	}
+	id, err := strconv.Atoi(subject)
+	if err != nil {
+		return 0, domain.Unauthorized("invalid subject")
+	}
+	return id, nil
+}
+
+// parseClaims returns the claims of a valid token of the given type.
+// Access tokens issued before types were introduced have no type.
+func (s *UsersService) parseClaims(token, tokenType string) (jwt.MapClaims, error) {
 	t, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, errors.New("invalid signing method")
@@ -164,26 +383,80 @@ func (s *UsersService) ParseToken(ctx context.Context, token string) (int, error
 		return s.hmacSecret, nil
 	})
 	if err != nil {
-		return 0, err
+		return nil, domain.NewError(domain.ErrUnauthorized, "invalid token", err)
 	}
 	if !t.Valid {
-		return 0, errors.New("invalid token")
+		return nil, domain.Unauthorized("invalid token")
 	}
 	claims, ok := t.Claims.(jwt.MapClaims)
 	if !ok {
-		return 0, errors.New("invalid claims")
+		return nil, domain.Unauthorized("invalid claims")
 	}
-	subject, ok := claims["sub"].(string)
-	if !ok {
-		return 0, errors.New("invalid subject")
+	typ, _ := claims["typ"].(string)
+	if typ != tokenType && !(typ == "" && tokenType == tokenTypeAccess) {
+		return nil, domain.Unauthorized("invalid token type")
 	}
-	id, err := strconv.Atoi(subject)
+	return claims, nil
+}
+
+func (s *UsersService) GetBookmarks(ctx context.Context, userId int, query domain.ArticleQuery) ([]domain.ArticleOutput, error) {
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+	return s.repo.GetBookmarks(ctx, userId, query)
+}
+
+func (s *UsersService) IsAdmin(ctx context.Context, userId int) (bool, error) {
+	role, err := s.repo.GetRole(ctx, userId)
 	if err != nil {
-		return 0, errors.New("invalid subject")
+		return false, err
 	}
-	return id, nil
+	return role == domain.RoleAdmin, nil
+}
+
+func (s *UsersService) SetRole(ctx context.Context, userId int, input domain.SetRoleInput) error {
+	if err := input.Validate(); err != nil {
+		return err
+	}
+
+	return s.repo.SetRole(ctx, userId, input.Role)
 }
 
-func (s *UsersService) GetBookmarks(ctx context.Context, userId int) ([]domain.ArticleOutput, error) {
-	return s.repo.GetBookmarks(ctx, userId)
+// BootstrapAdmin makes the registered user with the email an admin. It returns the id of the user and whether
+// the role was changed, the user may be an admin already.
+func (s *UsersService) BootstrapAdmin(ctx context.Context, email string) (int, bool, error) {
+	user, err := s.repo.GetByEmail(ctx, email)
+	if err != nil {
+		return 0, false, err
+	}
+	if user.Role == domain.RoleAdmin {
+		return user.Id, false, nil
+	}
+	return user.Id, true, s.repo.SetRole(ctx, user.Id, domain.RoleAdmin)
+}
+
+// EnrollTOTP starts two-factor enrollment of the user.
+func (s *UsersService) EnrollTOTP(ctx context.Context, userId int) (domain.TOTPEnrollment, error) {
+	user, err := s.repo.GetById(ctx, userId)
+	if err != nil {
+		return domain.TOTPEnrollment{}, err
+	}
+	return s.twoFactor.Enroll(ctx, userId, user.Email)
+}
+
+// ConfirmTOTP enables two-factor authentication and returns recovery codes.
+func (s *UsersService) ConfirmTOTP(ctx context.Context, userId int, input domain.TOTPCodeInput) (domain.RecoveryCodes, error) {
+	if err := input.Validate(); err != nil {
+		return domain.RecoveryCodes{}, err
+	}
+
+	codes, err := s.twoFactor.Confirm(ctx, userId, input.Code)
+	return domain.RecoveryCodes{Codes: codes}, err
+}
+
+func (s *UsersService) DisableTOTP(ctx context.Context, userId int, input domain.TOTPCodeInput) error {
+	if err := input.Validate(); err != nil {
+		return err
+	}
+	return s.twoFactor.Disable(ctx, userId, input.Code)
 }
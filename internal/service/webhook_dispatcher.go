@@ -0,0 +1,148 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/xopxe23/news-server/internal/domain"
+)
+
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+)
+
+type DeliveryQueue interface {
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]domain.PendingDelivery, error)
+	MarkDelivered(ctx context.Context, id, responseCode int) error
+	MarkFailed(ctx context.Context, id, responseCode int, reason string, retryIn time.Duration) error
+	MarkDead(ctx context.Context, id, responseCode int, reason string) error
+}
+
+type DispatcherConfig struct {
+	PollInterval time.Duration
+	Timeout      time.Duration
+	BatchSize    int
+	MaxAttempts  int
+	BaseBackoff  time.Duration
+	MaxBackoff   time.Duration
+}
+
+// WebhookDispatcher polls the delivery queue and posts signed payloads to the subscribers.
+type WebhookDispatcher struct {
+	queue  DeliveryQueue
+	client *http.Client
+	cfg    DispatcherConfig
+}
+
+func NewWebhookDispatcher(queue DeliveryQueue, cfg DispatcherConfig) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		queue:  queue,
+		client: &http.Client{Timeout: cfg.Timeout},
+		cfg:    cfg,
+	}
+}
+
+// Run processes the queue until ctx is cancelled.
+func (d *WebhookDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		d.dispatch(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *WebhookDispatcher) dispatch(ctx context.Context) {
+	deliveries, err := d.queue.ClaimDue(ctx, d.cfg.BatchSize, d.lease())
+	if err != nil {
+		logrus.WithField("method", "WebhookDispatcher.dispatch").Error(err)
+		return
+	}
+
+	for _, delivery := range deliveries {
+		d.deliver(ctx, delivery)
+	}
+}
+
+// lease is how long claimed deliveries stay invisible for other workers. The batch is sent one by one,
+// so it lasts for every delivery timing out with a timeout to spare.
+func (d *WebhookDispatcher) lease() time.Duration {
+	return time.Duration(d.cfg.BatchSize+1) * d.cfg.Timeout
+}
+
+func (d *WebhookDispatcher) deliver(ctx context.Context, delivery domain.PendingDelivery) {
+	code, err := d.send(ctx, delivery)
+	if err == nil {
+		err = d.queue.MarkDelivered(ctx, delivery.Id, code)
+	} else if delivery.Attempts+1 >= d.cfg.MaxAttempts {
+		err = d.queue.MarkDead(ctx, delivery.Id, code, err.Error())
+	} else {
+		err = d.queue.MarkFailed(ctx, delivery.Id, code, err.Error(), d.backoff(delivery.Attempts+1))
+	}
+
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"method":   "WebhookDispatcher.deliver",
+			"delivery": delivery.Id,
+		}).Error(err)
+	}
+}
+
+func (d *WebhookDispatcher) send(ctx context.Context, delivery domain.PendingDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, delivery.Event)
+	req.Header.Set(DeliveryHeader, strconv.Itoa(delivery.Id))
+	req.Header.Set(SignatureHeader, Sign(delivery.Secret, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff doubles the delay with every attempt, starting from BaseBackoff and capped by MaxBackoff.
+func (d *WebhookDispatcher) backoff(attempt int) time.Duration {
+	delay := d.cfg.BaseBackoff
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= d.cfg.MaxBackoff {
+			return d.cfg.MaxBackoff
+		}
+	}
+	return delay
+}
+
+// Sign returns the value of the signature header: hex encoded HMAC-SHA256 of the body.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xopxe23/news-server/internal/domain"
+)
+
+type queueResult struct {
+	status  string
+	code    int
+	retryIn time.Duration
+}
+
+// fakeDeliveryQueue hands out the pending deliveries once and remembers how they ended.
+type fakeDeliveryQueue struct {
+	mu      sync.Mutex
+	pending []domain.PendingDelivery
+	lease   time.Duration
+	results map[int]queueResult
+}
+
+func newFakeDeliveryQueue(pending ...domain.PendingDelivery) *fakeDeliveryQueue {
+	return &fakeDeliveryQueue{pending: pending, results: make(map[int]queueResult)}
+}
+
+func (q *fakeDeliveryQueue) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]domain.PendingDelivery, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.lease = lease
+	if limit > len(q.pending) {
+		limit = len(q.pending)
+	}
+	claimed := q.pending[:limit]
+	q.pending = q.pending[limit:]
+	return claimed, nil
+}
+
+func (q *fakeDeliveryQueue) MarkDelivered(ctx context.Context, id, responseCode int) error {
+	return q.mark(id, queueResult{status: domain.DeliveryDelivered, code: responseCode})
+}
+
+func (q *fakeDeliveryQueue) MarkFailed(ctx context.Context, id, responseCode int, reason string, retryIn time.Duration) error {
+	return q.mark(id, queueResult{status: domain.DeliveryPending, code: responseCode, retryIn: retryIn})
+}
+
+func (q *fakeDeliveryQueue) MarkDead(ctx context.Context, id, responseCode int, reason string) error {
+	return q.mark(id, queueResult{status: domain.DeliveryDead, code: responseCode})
+}
+
+func (q *fakeDeliveryQueue) mark(id int, result queueResult) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.results[id] = result
+	return nil
+}
+
+func testDispatcherConfig() DispatcherConfig {
+	return DispatcherConfig{
+		PollInterval: time.Second,
+		Timeout:      time.Second,
+		BatchSize:    10,
+		MaxAttempts:  3,
+		BaseBackoff:  time.Second,
+		MaxBackoff:   10 * time.Second,
+	}
+}
+
+func pendingDelivery(id, attempts int, url string) domain.PendingDelivery {
+	return domain.PendingDelivery{
+		WebhookDelivery: domain.WebhookDelivery{
+			Id:       id,
+			Event:    domain.EventArticleCreated,
+			Payload:  json.RawMessage(`{"id":1}`),
+			Attempts: attempts,
+		},
+		URL:    url,
+		Secret: "secret",
+	}
+}
+
+func TestWebhookDispatcherSignsDeliveries(t *testing.T) {
+	type received struct {
+		header http.Header
+		body   []byte
+	}
+	requests := make(chan received, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- received{header: r.Header, body: body}
+	}))
+	defer server.Close()
+
+	queue := newFakeDeliveryQueue(pendingDelivery(1, 0, server.URL))
+	NewWebhookDispatcher(queue, testDispatcherConfig()).dispatch(context.Background())
+
+	got := <-requests
+	if want := Sign("secret", got.body); got.header.Get(SignatureHeader) != want {
+		t.Errorf("got signature %q, want %q", got.header.Get(SignatureHeader), want)
+	}
+	if got.header.Get(EventHeader) != domain.EventArticleCreated || got.header.Get(DeliveryHeader) != "1" {
+		t.Errorf("got event %q and delivery %q", got.header.Get(EventHeader), got.header.Get(DeliveryHeader))
+	}
+	if string(got.body) != `{"id":1}` {
+		t.Errorf("got body %s", got.body)
+	}
+	if result := queue.results[1]; result.status != domain.DeliveryDelivered || result.code != http.StatusOK {
+		t.Errorf("got %+v, want delivered with 200", result)
+	}
+}
+
+func TestWebhookDispatcherRetriesWithBackoff(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	// attempts made before this one
+	queue := newFakeDeliveryQueue(
+		pendingDelivery(1, 0, server.URL),
+		pendingDelivery(2, 1, server.URL),
+		pendingDelivery(3, 2, server.URL),
+	)
+	NewWebhookDispatcher(queue, testDispatcherConfig()).dispatch(context.Background())
+
+	want := map[int]queueResult{
+		1: {status: domain.DeliveryPending, code: http.StatusServiceUnavailable, retryIn: time.Second},
+		2: {status: domain.DeliveryPending, code: http.StatusServiceUnavailable, retryIn: 2 * time.Second},
+		3: {status: domain.DeliveryDead, code: http.StatusServiceUnavailable},
+	}
+	for id, result := range want {
+		if queue.results[id] != result {
+			t.Errorf("delivery %d: got %+v, want %+v", id, queue.results[id], result)
+		}
+	}
+}
+
+func TestWebhookDispatcherDeadAfterUnreachableReceiver(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	queue := newFakeDeliveryQueue(pendingDelivery(1, 2, url))
+	NewWebhookDispatcher(queue, testDispatcherConfig()).dispatch(context.Background())
+
+	if result := queue.results[1]; result.status != domain.DeliveryDead || result.code != 0 {
+		t.Errorf("got %+v, want dead without a response code", result)
+	}
+}
+
+func TestWebhookDispatcherLeasesWholeBatch(t *testing.T) {
+	cfg := testDispatcherConfig()
+	queue := newFakeDeliveryQueue()
+	NewWebhookDispatcher(queue, cfg).dispatch(context.Background())
+
+	if min := time.Duration(cfg.BatchSize) * cfg.Timeout; queue.lease <= min {
+		t.Errorf("got lease %s, want more than %s for %d deliveries sent one by one", queue.lease, min, cfg.BatchSize)
+	}
+}
+
+func TestWebhookBackoffIsCapped(t *testing.T) {
+	d := NewWebhookDispatcher(nil, testDispatcherConfig())
+	for attempt, want := range []time.Duration{0, time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second} {
+		if attempt == 0 {
+			continue
+		}
+		t.Run(strconv.Itoa(attempt), func(t *testing.T) {
+			if got := d.backoff(attempt); got != want {
+				t.Errorf("got %s, want %s", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/xopxe23/news-server/internal/domain"
+)
+
+type WebhooksRepository interface {
+	Create(ctx context.Context, webhook domain.Webhook) (int, error)
+	GetAll(ctx context.Context) ([]domain.Webhook, error)
+	GetById(ctx context.Context, id int) (domain.Webhook, error)
+	Update(ctx context.Context, id int, input domain.UpdateWebhookInput) error
+	Delete(ctx context.Context, id int) error
+	Enqueue(ctx context.Context, key, event string, payload []byte) error
+	GetDeliveries(ctx context.Context, webhookId int) ([]domain.WebhookDelivery, error)
+	Redeliver(ctx context.Context, webhookId, deliveryId int) error
+}
+
+type WebhooksService struct {
+	repo WebhooksRepository
+}
+
+func NewWebhooksService(repo WebhooksRepository) *WebhooksService {
+	return &WebhooksService{repo: repo}
+}
+
+func (s *WebhooksService) CreateWebhook(ctx context.Context, input domain.WebhookInput) (int, error) {
+	if err := input.Validate(); err != nil {
+		return 0, err
+	}
+
+	return s.repo.Create(ctx, domain.Webhook{
+		URL:    input.URL,
+		Secret: input.Secret,
+		Events: input.Events,
+	})
+}
+
+func (s *WebhooksService) GetAllWebhooks(ctx context.Context) ([]domain.Webhook, error) {
+	return s.repo.GetAll(ctx)
+}
+
+func (s *WebhooksService) GetWebhookById(ctx context.Context, id int) (domain.Webhook, error) {
+	return s.repo.GetById(ctx, id)
+}
+
+func (s *WebhooksService) UpdateWebhook(ctx context.Context, id int, input domain.UpdateWebhookInput) error {
+	if err := input.Validate(); err != nil {
+		return err
+	}
+
+	return s.repo.Update(ctx, id, input)
+}
+
+func (s *WebhooksService) DeleteWebhook(ctx context.Context, id int) error {
+	return s.repo.Delete(ctx, id)
+}
+
+func (s *WebhooksService) GetDeliveries(ctx context.Context, webhookId int) ([]domain.WebhookDelivery, error) {
+	return s.repo.GetDeliveries(ctx, webhookId)
+}
+
+func (s *WebhooksService) Redeliver(ctx context.Context, webhookId, deliveryId int) error {
+	return s.repo.Redeliver(ctx, webhookId, deliveryId)
+}
+
+// HandleEvent queues the event for every subscribed webhook. The payload sent to the
+// receivers is an envelope holding the event id, name, time and the data itself.
+func (s *WebhooksService) HandleEvent(ctx context.Context, event domain.Event) error {
+	payload, err := json.Marshal(map[string]interface{}{
+		"id":          event.IdempotencyKey,
+		"event":       event.Type,
+		"occurred_at": event.CreatedAt,
+		"data":        event.Payload,
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.repo.Enqueue(ctx, event.IdempotencyKey, event.Type, payload)
+}
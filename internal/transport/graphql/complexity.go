@@ -0,0 +1,103 @@
+package graphql
+
+import (
+	"errors"
+
+	"github.com/graph-gophers/graphql-go/types"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+// listMultiplier is the assumed size of a list when estimating the cost of its elements.
+const listMultiplier = 10
+
+// complexity estimates the cost of the operation: every field costs one, and the fields selected under
+// a list are counted listMultiplier times. Which fields are lists is taken from the schema.
+func complexity(schema *types.Schema, query, operationName string) (int, error) {
+	doc, err := parser.ParseQuery(&ast.Source{Input: query})
+	if err != nil {
+		return 0, err
+	}
+
+	var op *ast.OperationDefinition
+	if operationName == "" && len(doc.Operations) == 1 {
+		op = doc.Operations[0]
+	} else {
+		op = doc.Operations.ForName(operationName)
+	}
+	if op == nil {
+		return 0, errors.New("operation not found")
+	}
+
+	return selectionCost(schema, doc, schema.EntryPoints[string(op.Operation)], op.SelectionSet, map[string]bool{}), nil
+}
+
+func selectionCost(schema *types.Schema, doc *ast.QueryDocument, parent types.NamedType, set ast.SelectionSet,
+	visiting map[string]bool) int {
+	cost := 0
+	for _, selection := range set {
+		switch s := selection.(type) {
+		case *ast.Field:
+			fieldType := fieldType(parent, s.Name)
+			childCost := selectionCost(schema, doc, namedType(fieldType), s.SelectionSet, visiting)
+			if isList(fieldType) {
+				childCost *= listMultiplier
+			}
+			cost += 1 + childCost
+		case *ast.InlineFragment:
+			on := parent
+			if s.TypeCondition != "" {
+				on = schema.Types[s.TypeCondition]
+			}
+			cost += selectionCost(schema, doc, on, s.SelectionSet, visiting)
+		case *ast.FragmentSpread:
+			fragment := doc.Fragments.ForName(s.Name)
+			// cyclic spreads are rejected by validation anyway
+			if fragment == nil || visiting[s.Name] {
+				continue
+			}
+			visiting[s.Name] = true
+			cost += selectionCost(schema, doc, schema.Types[fragment.TypeCondition], fragment.SelectionSet, visiting)
+			delete(visiting, s.Name)
+		}
+	}
+	return cost
+}
+
+// fieldType returns the type of the field of the object or interface, nil for meta fields like __typename.
+func fieldType(parent types.NamedType, name string) types.Type {
+	var fields types.FieldsDefinition
+	switch t := parent.(type) {
+	case *types.ObjectTypeDefinition:
+		fields = t.Fields
+	case *types.InterfaceTypeDefinition:
+		fields = t.Fields
+	}
+	if field := fields.Get(name); field != nil {
+		return field.Type
+	}
+	return nil
+}
+
+func isList(t types.Type) bool {
+	if nonNull, ok := t.(*types.NonNull); ok {
+		t = nonNull.OfType
+	}
+	_, ok := t.(*types.List)
+	return ok
+}
+
+func namedType(t types.Type) types.NamedType {
+	for {
+		switch wrapper := t.(type) {
+		case *types.NonNull:
+			t = wrapper.OfType
+		case *types.List:
+			t = wrapper.OfType
+		case types.NamedType:
+			return wrapper
+		default:
+			return nil
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package graphql
+
+import (
+	"testing"
+
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+func TestComplexity(t *testing.T) {
+	tests := []struct {
+		name          string
+		query         string
+		operationName string
+		cost          int
+	}{
+		{"shorthand", `{ article(id: "1") { title } }`, "", 2},
+		{"list", `query { articles { id title } }`, "", 21},
+		{"nested lists", `{ authors { articles { id } } }`, "", 111},
+		{"alias", `{ a: articles { id } b: article(id: 1) { id } }`, "", 13},
+		{
+			"fragments",
+			`query Feed($id: ID!) { article(id: $id) { ...card author { ... on Author { name } } } }
+			fragment card on Article { title bookmarked }`,
+			"", 5,
+		},
+		{
+			"named operation",
+			`query Feed { articles { id } }
+			mutation Remove($id: ID! = "1") { deleteArticle(id: $id) }`,
+			"Remove", 1,
+		},
+		{"typename", `{ __typename articles { __typename } }`, "", 12},
+	}
+	schema := graphql.MustParseSchema(schema, &resolver{}).ASTSchema()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cost, err := complexity(schema, tt.query, tt.operationName)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cost != tt.cost {
+				t.Errorf("got cost %d, want %d", cost, tt.cost)
+			}
+		})
+	}
+}
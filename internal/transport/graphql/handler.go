@@ -0,0 +1,123 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/xopxe23/news-server/internal/domain"
+)
+
+type ArticlesService interface {
+	CreateAuthor(ctx context.Context, author domain.Author) (int, error)
+	GetAllAuthors(ctx context.Context) ([]domain.Author, error)
+	GetAuthorById(ctx context.Context, authorId int) (domain.Author, error)
+	GetAuthorsByIds(ctx context.Context, ids []int) ([]domain.Author, error)
+	GetArticlesOfAuthors(ctx context.Context, ids []int, query domain.ArticleQuery) ([]domain.ArticleOutput, error)
+	UpdateAuthor(ctx context.Context, authorId int, input domain.UpdateAuthorInput) error
+	DeleteAuthor(ctx context.Context, authorId int) error
+
+	CreateArticle(ctx context.Context, input domain.Article) (int, error)
+	GetAllArticles(ctx context.Context, query domain.ArticleQuery) ([]domain.ArticleOutput, error)
+	GetArticleById(ctx context.Context, articleId int) (domain.ArticleOutput, error)
+	AddArticleInBookmarks(ctx context.Context, articleId, userId int) error
+	UpdateArticle(ctx context.Context, articleId int, input domain.UpdateArticleInput) error
+	DeleteArticle(ctx context.Context, articleId int) error
+}
+
+type UsersService interface {
+	GetBookmarks(ctx context.Context, userId int, query domain.ArticleQuery) ([]domain.ArticleOutput, error)
+}
+
+type Config struct {
+	MaxDepth      int
+	MaxComplexity int
+}
+
+// Handler serves GraphQL queries. It expects the acting user in the request context, see domain.WithActor,
+// and the scopes of requests made with API keys and OAuth tokens, see domain.WithScopes.
+type Handler struct {
+	schema   *graphql.Schema
+	articles ArticlesService
+	cfg      Config
+}
+
+func NewHandler(articles ArticlesService, users UsersService, cfg Config) *Handler {
+	return &Handler{
+		schema: graphql.MustParseSchema(schema, &resolver{articles: articles, users: users},
+			graphql.MaxDepth(cfg.MaxDepth),
+		),
+		articles: articles,
+		cfg:      cfg,
+	}
+}
+
+type request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req request
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logError(err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if errs := h.schema.ValidateWithVariables(req.Query, req.Variables); len(errs) > 0 {
+		writeResponse(w, &graphql.Response{Errors: errs})
+		return
+	}
+
+	cost, err := complexity(h.schema.ASTSchema(), req.Query, req.OperationName)
+	if err == nil && cost > h.cfg.MaxComplexity {
+		err = fmt.Errorf("query complexity %d exceeds the limit of %d", cost, h.cfg.MaxComplexity)
+	}
+	if err != nil {
+		writeResponse(w, &graphql.Response{Errors: []*gqlerrors.QueryError{gqlerrors.Errorf("%s", err)}})
+		return
+	}
+
+	ctx := withLoaders(r.Context(), h.articles)
+	resp := h.schema.Exec(ctx, req.Query, req.OperationName, req.Variables)
+	for _, err := range resp.Errors {
+		logError(err)
+
+		// resolver errors other than domain ones may expose internals
+		var domainErr *domain.Error
+		if err.Err != nil {
+			if errors.As(err.Err, &domainErr) {
+				err.Message = domainErr.Message
+			} else {
+				err.Message = "internal error"
+			}
+		}
+	}
+
+	writeResponse(w, resp)
+}
+
+func writeResponse(w http.ResponseWriter, resp *graphql.Response) {
+	response, err := json.Marshal(resp)
+	if err != nil {
+		logError(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	w.Write(response)
+}
+
+func logError(err error) {
+	logrus.WithFields(logrus.Fields{
+		"handler": "graphql",
+	}).Error(err)
+}
@@ -0,0 +1,118 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/xopxe23/news-server/internal/domain"
+)
+
+// fakeArticles implements only the calls the tests make, the rest panic on the nil interface.
+type fakeArticles struct {
+	ArticlesService
+	deleted []int
+}
+
+func (f *fakeArticles) GetAllArticles(ctx context.Context, query domain.ArticleQuery) ([]domain.ArticleOutput, error) {
+	return []domain.ArticleOutput{{Id: 1, Title: "title"}}, nil
+}
+
+func (f *fakeArticles) DeleteArticle(ctx context.Context, articleId int) error {
+	f.deleted = append(f.deleted, articleId)
+	return nil
+}
+
+func serve(t *testing.T, h *Handler, ctx context.Context, query, operationName string) []string {
+	t.Helper()
+
+	body, err := json.Marshal(request{Query: query, OperationName: operationName})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("POST", "/graphql", strings.NewReader(string(body))).WithContext(ctx))
+
+	var resp struct {
+		Errors []struct{ Message string }
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	messages := make([]string, 0, len(resp.Errors))
+	for _, e := range resp.Errors {
+		messages = append(messages, e.Message)
+	}
+	return messages
+}
+
+func TestHandlerScopes(t *testing.T) {
+	const forbidden = "the write scope is required"
+	read := domain.WithScopes(context.Background(), domain.Scopes{domain.ScopeRead})
+	write := domain.WithScopes(context.Background(), domain.Scopes{domain.ScopeWrite})
+
+	tests := []struct {
+		name          string
+		ctx           context.Context
+		query         string
+		operationName string
+		errors        []string
+		deleted       bool
+	}{
+		{name: "read query", ctx: read, query: `{ articles { id } }`},
+		{name: "read mutation", ctx: read, query: `mutation { deleteArticle(id: 1) }`, errors: []string{forbidden}},
+		{
+			name:          "read mutation picked by name",
+			ctx:           read,
+			query:         `query Feed { articles { id } } mutation Feed2 { deleteArticle(id: 1) }`,
+			operationName: "Feed2",
+			errors:        []string{forbidden},
+		},
+		{
+			name:          "read mutation named like a query",
+			ctx:           read,
+			query:         "# query\nmutation query { deleteArticle(id: 1) }",
+			operationName: "query",
+			errors:        []string{forbidden},
+		},
+		{
+			name:   "read mutation with several fields",
+			ctx:    read,
+			query:  `mutation { a: deleteArticle(id: "1") b: deleteArticle(id: 2) }`,
+			errors: []string{forbidden, forbidden},
+		},
+		{
+			name:          "read query picked by name",
+			ctx:           read,
+			query:         `mutation Remove { deleteArticle(id: 1) } query Feed { articles { id } }`,
+			operationName: "Feed",
+		},
+		{name: "write mutation", ctx: write, query: `mutation { deleteArticle(id: 1) }`, deleted: true},
+		{name: "session mutation", ctx: context.Background(), query: `mutation { deleteArticle(id: 1) }`, deleted: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			articles := &fakeArticles{}
+			h := NewHandler(articles, nil, Config{MaxDepth: 10, MaxComplexity: 1000})
+
+			errors := serve(t, h, tt.ctx, tt.query, tt.operationName)
+			if strings.Join(errors, "; ") != strings.Join(tt.errors, "; ") {
+				t.Errorf("got errors %q, want %q", errors, tt.errors)
+			}
+			if deleted := len(articles.deleted) > 0; deleted != tt.deleted {
+				t.Errorf("got deleted %v, want %v", deleted, tt.deleted)
+			}
+		})
+	}
+}
+
+func TestHandlerComplexityLimit(t *testing.T) {
+	h := NewHandler(&fakeArticles{}, nil, Config{MaxDepth: 10, MaxComplexity: 20})
+
+	errors := serve(t, h, context.Background(), `{ articles { id title } }`, "")
+	if len(errors) != 1 || !strings.Contains(errors[0], "complexity 21") {
+		t.Errorf("got %q, want the complexity error", errors)
+	}
+}
@@ -0,0 +1,162 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/xopxe23/news-server/internal/domain"
+)
+
+type loadersKey struct{}
+
+// loaders are created for every request, so cached values never outlive it.
+type loaders struct {
+	authors        *batchLoader[domain.Author]
+	authorArticles *batchLoader[[]domain.ArticleOutput]
+
+	bookmarksOnce sync.Once
+	bookmarks     map[int]bool
+	bookmarksErr  error
+}
+
+func withLoaders(ctx context.Context, articles ArticlesService) context.Context {
+	return context.WithValue(ctx, loadersKey{}, &loaders{
+		authors: newBatchLoader(func(ids []int) (map[int]domain.Author, error) {
+			authors, err := articles.GetAuthorsByIds(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+
+			byId := make(map[int]domain.Author, len(authors))
+			for _, author := range authors {
+				byId[author.Id] = author
+			}
+			return byId, nil
+		}),
+		authorArticles: newBatchLoader(func(ids []int) (map[int][]domain.ArticleOutput, error) {
+			list, err := articles.GetArticlesOfAuthors(ctx, ids, domain.ArticleQuery{})
+			if err != nil {
+				return nil, err
+			}
+
+			byAuthor := make(map[int][]domain.ArticleOutput, len(ids))
+			for _, article := range list {
+				byAuthor[article.AuthorId] = append(byAuthor[article.AuthorId], article)
+			}
+			return byAuthor, nil
+		}),
+	})
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
+
+// isBookmarked loads the bookmarks of the user once per request.
+func (l *loaders) isBookmarked(ctx context.Context, users UsersService, articleId int) (bool, error) {
+	l.bookmarksOnce.Do(func() {
+		articles, err := users.GetBookmarks(ctx, domain.ActorFrom(ctx), domain.ArticleQuery{Fields: []string{"id"}})
+		if err != nil {
+			l.bookmarksErr = err
+			return
+		}
+
+		l.bookmarks = make(map[int]bool, len(articles))
+		for _, article := range articles {
+			l.bookmarks[article.Id] = true
+		}
+	})
+	return l.bookmarks[articleId], l.bookmarksErr
+}
+
+// author loads the author in the batch of the concurrently resolved ones.
+func (l *loaders) author(id int) (domain.Author, error) {
+	author, found, err := l.authors.Load(id)
+	if err == nil && !found {
+		err = fmt.Errorf("author %d not found", id)
+	}
+	return author, err
+}
+
+// articlesOfAuthor loads the articles of the author in the batch of the concurrently resolved authors.
+func (l *loaders) articlesOfAuthor(id int) ([]domain.ArticleOutput, error) {
+	articles, _, err := l.authorArticles.Load(id)
+	return articles, err
+}
+
+const (
+	batchWait    = time.Millisecond
+	maxBatchSize = 100
+)
+
+type batchResult[V any] struct {
+	done  chan struct{}
+	value V
+	found bool
+	err   error
+}
+
+// batchLoader collects the ids requested by concurrently running resolvers during a short window
+// and fetches them with a single query.
+type batchLoader[V any] struct {
+	fetch func(ids []int) (map[int]V, error)
+
+	mu      sync.Mutex
+	cache   map[int]*batchResult[V]
+	pending map[int]*batchResult[V]
+}
+
+func newBatchLoader[V any](fetch func(ids []int) (map[int]V, error)) *batchLoader[V] {
+	return &batchLoader[V]{
+		fetch: fetch,
+		cache: make(map[int]*batchResult[V]),
+	}
+}
+
+// Load returns the value fetched for the id and whether there was one.
+func (l *batchLoader[V]) Load(id int) (V, bool, error) {
+	l.mu.Lock()
+	res, ok := l.cache[id]
+	if !ok {
+		res = &batchResult[V]{done: make(chan struct{})}
+		l.cache[id] = res
+
+		if l.pending == nil {
+			l.pending = make(map[int]*batchResult[V])
+			time.AfterFunc(batchWait, l.flush)
+		}
+		l.pending[id] = res
+		if len(l.pending) >= maxBatchSize {
+			go l.flush()
+		}
+	}
+	l.mu.Unlock()
+
+	<-res.done
+	return res.value, res.found, res.err
+}
+
+func (l *batchLoader[V]) flush() {
+	l.mu.Lock()
+	batch := l.pending
+	l.pending = nil
+	l.mu.Unlock()
+
+	if len(batch) == 0 {
+		return
+	}
+
+	ids := make([]int, 0, len(batch))
+	for id := range batch {
+		ids = append(ids, id)
+	}
+
+	values, err := l.fetch(ids)
+	for id, res := range batch {
+		res.value, res.found = values[id]
+		res.err = err
+		close(res.done)
+	}
+}
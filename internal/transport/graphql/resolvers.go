@@ -0,0 +1,348 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/xopxe23/news-server/internal/domain"
+)
+
+type resolver struct {
+	articles ArticlesService
+	users    UsersService
+}
+
+func parseId(id graphql.ID) (int, error) {
+	return strconv.Atoi(string(id))
+}
+
+func toId(id int) graphql.ID {
+	return graphql.ID(strconv.Itoa(id))
+}
+
+// authorize checks the scopes of requests made with API keys and OAuth tokens. It runs in the root resolvers,
+// so it applies to the operation graph-gophers executes: query fields need read, mutation fields need write.
+func authorize(ctx context.Context, scope string) error {
+	if scopes, ok := domain.ScopesFrom(ctx); ok && !scopes.Allows(scope) {
+		return domain.Forbidden(fmt.Sprintf("the %s scope is required", scope))
+	}
+	return nil
+}
+
+// Query
+
+func (r *resolver) Articles(ctx context.Context) ([]*articleResolver, error) {
+	if err := authorize(ctx, domain.ScopeRead); err != nil {
+		return nil, err
+	}
+
+	articles, err := r.articles.GetAllArticles(ctx, domain.ArticleQuery{})
+	if err != nil {
+		return nil, err
+	}
+	return r.articleResolvers(articles), nil
+}
+
+func (r *resolver) Article(ctx context.Context, args struct{ Id graphql.ID }) (*articleResolver, error) {
+	if err := authorize(ctx, domain.ScopeRead); err != nil {
+		return nil, err
+	}
+
+	id, err := parseId(args.Id)
+	if err != nil {
+		return nil, err
+	}
+	return r.article(ctx, id)
+}
+
+func (r *resolver) Authors(ctx context.Context) ([]*authorResolver, error) {
+	if err := authorize(ctx, domain.ScopeRead); err != nil {
+		return nil, err
+	}
+
+	authors, err := r.articles.GetAllAuthors(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resolvers := make([]*authorResolver, 0, len(authors))
+	for _, author := range authors {
+		resolvers = append(resolvers, &authorResolver{root: r, author: author})
+	}
+	return resolvers, nil
+}
+
+func (r *resolver) Author(ctx context.Context, args struct{ Id graphql.ID }) (*authorResolver, error) {
+	if err := authorize(ctx, domain.ScopeRead); err != nil {
+		return nil, err
+	}
+
+	id, err := parseId(args.Id)
+	if err != nil {
+		return nil, err
+	}
+	return r.author(ctx, id)
+}
+
+func (r *resolver) Bookmarks(ctx context.Context) ([]*articleResolver, error) {
+	if err := authorize(ctx, domain.ScopeRead); err != nil {
+		return nil, err
+	}
+
+	articles, err := r.users.GetBookmarks(ctx, domain.ActorFrom(ctx), domain.ArticleQuery{})
+	if err != nil {
+		return nil, err
+	}
+	return r.articleResolvers(articles), nil
+}
+
+// Mutation
+
+type authorInput struct {
+	Name    string
+	Surname string
+}
+
+type updateAuthorInput struct {
+	Name    *string
+	Surname *string
+}
+
+type articleInput struct {
+	AuthorId      graphql.ID
+	Title         string
+	Content       string
+	ContentFormat *string
+}
+
+type updateArticleInput struct {
+	Title         *string
+	Content       *string
+	ContentFormat *string
+}
+
+func (r *resolver) CreateAuthor(ctx context.Context, args struct{ Input authorInput }) (*authorResolver, error) {
+	if err := authorize(ctx, domain.ScopeWrite); err != nil {
+		return nil, err
+	}
+
+	id, err := r.articles.CreateAuthor(ctx, domain.Author{
+		Name:    args.Input.Name,
+		Surname: args.Input.Surname,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.author(ctx, id)
+}
+
+func (r *resolver) UpdateAuthor(ctx context.Context, args struct {
+	Id    graphql.ID
+	Input updateAuthorInput
+}) (*authorResolver, error) {
+	if err := authorize(ctx, domain.ScopeWrite); err != nil {
+		return nil, err
+	}
+
+	id, err := parseId(args.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.articles.UpdateAuthor(ctx, id, domain.UpdateAuthorInput{
+		Name:    args.Input.Name,
+		Surname: args.Input.Surname,
+	}); err != nil {
+		return nil, err
+	}
+	return r.author(ctx, id)
+}
+
+func (r *resolver) DeleteAuthor(ctx context.Context, args struct{ Id graphql.ID }) (bool, error) {
+	if err := authorize(ctx, domain.ScopeWrite); err != nil {
+		return false, err
+	}
+
+	id, err := parseId(args.Id)
+	if err != nil {
+		return false, err
+	}
+	return true, r.articles.DeleteAuthor(ctx, id)
+}
+
+func (r *resolver) CreateArticle(ctx context.Context, args struct{ Input articleInput }) (*articleResolver, error) {
+	if err := authorize(ctx, domain.ScopeWrite); err != nil {
+		return nil, err
+	}
+
+	authorId, err := parseId(args.Input.AuthorId)
+	if err != nil {
+		return nil, err
+	}
+
+	article := domain.Article{
+		AuthorId: authorId,
+		Title:    args.Input.Title,
+		Content:  args.Input.Content,
+	}
+	if args.Input.ContentFormat != nil {
+		article.ContentFormat = *args.Input.ContentFormat
+	}
+
+	id, err := r.articles.CreateArticle(ctx, article)
+	if err != nil {
+		return nil, err
+	}
+	return r.article(ctx, id)
+}
+
+func (r *resolver) UpdateArticle(ctx context.Context, args struct {
+	Id    graphql.ID
+	Input updateArticleInput
+}) (*articleResolver, error) {
+	if err := authorize(ctx, domain.ScopeWrite); err != nil {
+		return nil, err
+	}
+
+	id, err := parseId(args.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.articles.UpdateArticle(ctx, id, domain.UpdateArticleInput{
+		Title:         args.Input.Title,
+		Content:       args.Input.Content,
+		ContentFormat: args.Input.ContentFormat,
+	}); err != nil {
+		return nil, err
+	}
+	return r.article(ctx, id)
+}
+
+func (r *resolver) DeleteArticle(ctx context.Context, args struct{ Id graphql.ID }) (bool, error) {
+	if err := authorize(ctx, domain.ScopeWrite); err != nil {
+		return false, err
+	}
+
+	id, err := parseId(args.Id)
+	if err != nil {
+		return false, err
+	}
+	return true, r.articles.DeleteArticle(ctx, id)
+}
+
+func (r *resolver) AddBookmark(ctx context.Context, args struct{ ArticleId graphql.ID }) (bool, error) {
+	if err := authorize(ctx, domain.ScopeWrite); err != nil {
+		return false, err
+	}
+
+	id, err := parseId(args.ArticleId)
+	if err != nil {
+		return false, err
+	}
+	return true, r.articles.AddArticleInBookmarks(ctx, id, domain.ActorFrom(ctx))
+}
+
+func (r *resolver) article(ctx context.Context, id int) (*articleResolver, error) {
+	article, err := r.articles.GetArticleById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &articleResolver{root: r, article: article}, nil
+}
+
+func (r *resolver) author(ctx context.Context, id int) (*authorResolver, error) {
+	author, err := r.articles.GetAuthorById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &authorResolver{root: r, author: author}, nil
+}
+
+func (r *resolver) articleResolvers(articles []domain.ArticleOutput) []*articleResolver {
+	resolvers := make([]*articleResolver, 0, len(articles))
+	for _, article := range articles {
+		resolvers = append(resolvers, &articleResolver{root: r, article: article})
+	}
+	return resolvers
+}
+
+type authorResolver struct {
+	root   *resolver
+	author domain.Author
+}
+
+func (r *authorResolver) Id() graphql.ID {
+	return toId(r.author.Id)
+}
+
+func (r *authorResolver) Name() string {
+	return r.author.Name
+}
+
+func (r *authorResolver) Surname() string {
+	return r.author.Surname
+}
+
+func (r *authorResolver) Articles(ctx context.Context) ([]*articleResolver, error) {
+	articles, err := loadersFrom(ctx).articlesOfAuthor(r.author.Id)
+	if err != nil {
+		return nil, err
+	}
+	return r.root.articleResolvers(articles), nil
+}
+
+type articleResolver struct {
+	root    *resolver
+	article domain.ArticleOutput
+}
+
+func (r *articleResolver) Id() graphql.ID {
+	return toId(r.article.Id)
+}
+
+func (r *articleResolver) Title() string {
+	return r.article.Title
+}
+
+func (r *articleResolver) Content() string {
+	return r.article.Content
+}
+
+func (r *articleResolver) ContentFormat() string {
+	return r.article.ContentFormat
+}
+
+func (r *articleResolver) ContentHtml() string {
+	return r.article.ContentHTML
+}
+
+func (r *articleResolver) Excerpt() string {
+	return r.article.Excerpt
+}
+
+func (r *articleResolver) WordCount() int32 {
+	return int32(r.article.WordCount)
+}
+
+func (r *articleResolver) ReadingTime() int32 {
+	return int32(r.article.ReadingTime)
+}
+
+func (r *articleResolver) CreatedAt() graphql.Time {
+	return graphql.Time{Time: r.article.CreatedAt}
+}
+
+func (r *articleResolver) Author(ctx context.Context) (*authorResolver, error) {
+	author, err := loadersFrom(ctx).author(r.article.AuthorId)
+	if err != nil {
+		return nil, err
+	}
+	return &authorResolver{root: r.root, author: author}, nil
+}
+
+func (r *articleResolver) Bookmarked(ctx context.Context) (bool, error) {
+	return loadersFrom(ctx).isBookmarked(ctx, r.root.users, r.article.Id)
+}
@@ -0,0 +1,75 @@
+package graphql
+
+const schema = `
+schema {
+	query: Query
+	mutation: Mutation
+}
+
+scalar Time
+
+type Query {
+	articles: [Article!]!
+	article(id: ID!): Article!
+	authors: [Author!]!
+	author(id: ID!): Author!
+	bookmarks: [Article!]!
+}
+
+type Mutation {
+	createAuthor(input: AuthorInput!): Author!
+	updateAuthor(id: ID!, input: UpdateAuthorInput!): Author!
+	deleteAuthor(id: ID!): Boolean!
+	createArticle(input: ArticleInput!): Article!
+	updateArticle(id: ID!, input: UpdateArticleInput!): Article!
+	deleteArticle(id: ID!): Boolean!
+	addBookmark(articleId: ID!): Boolean!
+}
+
+type Author {
+	id: ID!
+	name: String!
+	surname: String!
+	articles: [Article!]!
+}
+
+type Article {
+	id: ID!
+	title: String!
+	content: String!
+	# plain or markdown
+	contentFormat: String!
+	# the content rendered to sanitized HTML
+	contentHtml: String!
+	excerpt: String!
+	wordCount: Int!
+	# estimated reading time in minutes
+	readingTime: Int!
+	createdAt: Time!
+	author: Author!
+	bookmarked: Boolean!
+}
+
+input AuthorInput {
+	name: String!
+	surname: String!
+}
+
+input UpdateAuthorInput {
+	name: String
+	surname: String
+}
+
+input ArticleInput {
+	authorId: ID!
+	title: String!
+	content: String!
+	contentFormat: String
+}
+
+input UpdateArticleInput {
+	title: String
+	content: String
+	contentFormat: String
+}
+`
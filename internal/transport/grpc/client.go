@@ -2,38 +2,109 @@ package grpc_client
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
+	"os"
+	"time"
 
 	audit "github.com/xopxe23/auditlog/pkg/domain"
+	"github.com/xopxe23/news-server/internal/domain"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/encoding/protowire"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+type ClientConfig struct {
+	Address string
+	Timeout time.Duration
+
+	// CAFile enables TLS, CertFile and KeyFile additionally enable client authentication (mTLS).
+	CAFile     string
+	CertFile   string
+	KeyFile    string
+	ServerName string
+}
+
 type Client struct {
 	conn        *grpc.ClientConn
 	auditClient audit.AuditServiceClient
+	timeout     time.Duration
+}
+
+func NewClient(cfg ClientConfig) (*Client, error) {
+	creds, err := transportCredentials(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := grpc.Dial(cfg.Address, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{conn: conn, auditClient: audit.NewAuditServiceClient(conn), timeout: cfg.Timeout}, nil
 }
 
-func NewClient(port int) (*Client, error) {
-	var conn *grpc.ClientConn
-	addr := fmt.Sprintf(":%d", port)
+func transportCredentials(cfg ClientConfig) (credentials.TransportCredentials, error) {
+	if cfg.CAFile == "" {
+		return insecure.NewCredentials(), nil
+	}
 
-	conn, err := grpc.Dial(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	ca, err := os.ReadFile(cfg.CAFile)
 	if err != nil {
 		return nil, err
 	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, fmt.Errorf("no certificates found in %s", cfg.CAFile)
+	}
+
+	tlsConfig := &tls.Config{
+		RootCAs:    pool,
+		ServerName: cfg.ServerName,
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return credentials.NewTLS(tlsConfig), nil
+}
 
-	return &Client{conn: conn, auditClient: audit.NewAuditServiceClient(conn)}, nil
+// CheckConnection connects to the audit service and waits until the connection is ready.
+func (c *Client) CheckConnection(ctx context.Context) error {
+	c.conn.Connect()
+	for {
+		state := c.conn.GetState()
+		switch state {
+		case connectivity.Ready:
+			return nil
+		case connectivity.Shutdown:
+			return errors.New("connection is closed")
+		}
+
+		if !c.conn.WaitForStateChange(ctx, state) {
+			return fmt.Errorf("audit service at %s is not reachable: %w", c.conn.Target(), ctx.Err())
+		}
+	}
 }
 
 func (c *Client) CloseConnection() error {
 	return c.conn.Close()
 }
 
-
-func (c *Client) SendLogRequest(ctx context.Context, req audit.LogItem) error {
+func (c *Client) SendLogRequest(ctx context.Context, req domain.AuditLogItem) error {
 	action, err := audit.ToPbAction(req.Action)
 	if err != nil {
 		return err
@@ -44,12 +115,58 @@ func (c *Client) SendLogRequest(ctx context.Context, req audit.LogItem) error {
 		return err
 	}
 
-	_, err = c.auditClient.Log(ctx, &audit.LogRequest{
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	msg := &audit.LogRequest{
 		Action:    action,
 		Entity:    entity,
-		EntityId:  req.EntityID,
+		EntityId:  req.EntityId,
 		Timestamp: timestamppb.New(req.Timestamp),
-	})
+	}
+	msg.ProtoReflect().SetUnknown(logRequestExtension(req))
+
+	_, err = c.auditClient.Log(ctx, msg)
 
 	return err
-}
\ No newline at end of file
+}
+
+// Field numbers of the extension of LogRequest, see proto/audit.proto.
+const (
+	fieldActorId        protowire.Number = 5
+	fieldImpersonatorId protowire.Number = 6
+	fieldRequestId      protowire.Number = 7
+	fieldEntityName     protowire.Number = 8
+	fieldActionName     protowire.Number = 9
+)
+
+// logRequestExtension encodes the fields the generated LogRequest of the auditlog module doesn't have yet.
+// They go on the wire as unknown fields, so servers built from the old definition just skip them.
+func logRequestExtension(req domain.AuditLogItem) []byte {
+	var b []byte
+	if req.ActorId != 0 {
+		b = protowire.AppendTag(b, fieldActorId, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(req.ActorId))
+	}
+	if req.ImpersonatorId != 0 {
+		b = protowire.AppendTag(b, fieldImpersonatorId, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(req.ImpersonatorId))
+	}
+	for _, field := range []struct {
+		number protowire.Number
+		value  string
+	}{
+		{fieldRequestId, req.RequestId},
+		{fieldEntityName, req.EntityName},
+		{fieldActionName, req.ActionName},
+	} {
+		if field.value != "" {
+			b = protowire.AppendTag(b, field.number, protowire.BytesType)
+			b = protowire.AppendString(b, field.value)
+		}
+	}
+	return b
+}
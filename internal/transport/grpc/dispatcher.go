@@ -0,0 +1,233 @@
+package grpc_client
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/xopxe23/news-server/internal/domain"
+)
+
+type Sender interface {
+	SendLogRequest(ctx context.Context, req domain.AuditLogItem) error
+}
+
+type DispatcherConfig struct {
+	QueueSize        int
+	BatchSize        int
+	FlushInterval    time.Duration
+	MaxRetries       int
+	BaseBackoff      time.Duration
+	MaxBackoff       time.Duration
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+	SpoolPath        string
+}
+
+// Dispatcher delivers log items to the audit service in the background.
+// Items are buffered in a bounded queue and sent in batches with retries. While the
+// audit service is unavailable, or the queue is full, items are written to a spool
+// file and replayed once the service is back.
+type Dispatcher struct {
+	sender  Sender
+	cfg     DispatcherConfig
+	queue   chan domain.AuditLogItem
+	breaker *breaker
+	spool   *spool
+
+	mu        sync.RWMutex
+	closed    bool
+	abort     chan struct{}
+	abortOnce sync.Once
+	done      chan struct{}
+}
+
+func NewDispatcher(sender Sender, cfg DispatcherConfig) (*Dispatcher, error) {
+	spool, err := newSpool(cfg.SpoolPath)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Dispatcher{
+		sender:  sender,
+		cfg:     cfg,
+		queue:   make(chan domain.AuditLogItem, cfg.QueueSize),
+		breaker: newBreaker(cfg.BreakerThreshold, cfg.BreakerCooldown),
+		spool:   spool,
+		abort:   make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go d.run()
+
+	return d, nil
+}
+
+// SendLogRequest queues the item without waiting for the audit service.
+func (d *Dispatcher) SendLogRequest(ctx context.Context, req domain.AuditLogItem) error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if !d.closed {
+		select {
+		case d.queue <- req:
+			return nil
+		default:
+		}
+	}
+	return d.spool.Write([]domain.AuditLogItem{req})
+}
+
+// Close stops accepting items and drains the queue. Whatever is not delivered
+// by the time ctx is done goes to the spool.
+func (d *Dispatcher) Close(ctx context.Context) error {
+	d.mu.Lock()
+	if !d.closed {
+		d.closed = true
+		close(d.queue)
+	}
+	d.mu.Unlock()
+
+	select {
+	case <-d.done:
+		return nil
+	case <-ctx.Done():
+		d.abortOnce.Do(func() { close(d.abort) })
+		<-d.done
+		return ctx.Err()
+	}
+}
+
+func (d *Dispatcher) run() {
+	defer close(d.done)
+
+	ticker := time.NewTicker(d.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]domain.AuditLogItem, 0, d.cfg.BatchSize)
+	for {
+		select {
+		case item, ok := <-d.queue:
+			if !ok {
+				d.flush(batch)
+				return
+			}
+			batch = append(batch, item)
+			if len(batch) >= d.cfg.BatchSize {
+				d.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			d.flush(batch)
+			batch = batch[:0]
+			d.replay()
+		}
+	}
+}
+
+// flush sends the batch, spooling the items that could not be delivered.
+func (d *Dispatcher) flush(batch []domain.AuditLogItem) {
+	for i, item := range batch {
+		if d.aborted() || !d.breaker.Allow() {
+			d.toSpool(batch[i:])
+			return
+		}
+
+		if err := d.send(item); err != nil {
+			logrus.WithField("method", "Dispatcher.flush").Error("failed to send log request: ", err)
+			d.toSpool([]domain.AuditLogItem{item})
+		}
+	}
+}
+
+func (d *Dispatcher) send(item domain.AuditLogItem) error {
+	var err error
+	for attempt := 0; attempt <= d.cfg.MaxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(d.backoff(attempt)):
+			case <-d.abort:
+				return err
+			}
+		}
+
+		err = d.sender.SendLogRequest(context.Background(), item)
+		if err == nil {
+			d.breaker.Success()
+			return nil
+		}
+
+		d.breaker.Failure()
+		if !d.breaker.Allow() {
+			return err
+		}
+	}
+	return err
+}
+
+// replay delivers spooled items once the breaker lets calls through. Items are removed from the spool
+// batch by batch after they are delivered, a failure leaves the rest for the next attempt.
+func (d *Dispatcher) replay() {
+	if !d.breaker.Ready() {
+		return
+	}
+
+	items, err := d.spool.Read()
+	if err != nil {
+		logrus.WithField("method", "Dispatcher.replay").Error(err)
+		return
+	}
+
+	for start := 0; start < len(items); start += d.cfg.BatchSize {
+		end := start + d.cfg.BatchSize
+		if end > len(items) {
+			end = len(items)
+		}
+
+		sent := 0
+		for _, item := range items[start:end] {
+			if d.aborted() || !d.breaker.Allow() {
+				break
+			}
+			if err := d.send(item); err != nil {
+				logrus.WithField("method", "Dispatcher.replay").Error("failed to send log request: ", err)
+				break
+			}
+			sent++
+		}
+
+		if err := d.spool.Remove(sent); err != nil {
+			logrus.WithField("method", "Dispatcher.replay").Error(err)
+			return
+		}
+		if sent < end-start {
+			return
+		}
+	}
+}
+
+func (d *Dispatcher) toSpool(items []domain.AuditLogItem) {
+	if err := d.spool.Write(items); err != nil {
+		logrus.WithField("method", "Dispatcher.toSpool").Errorf("%d log items lost: %s", len(items), err)
+	}
+}
+
+func (d *Dispatcher) aborted() bool {
+	select {
+	case <-d.abort:
+		return true
+	default:
+		return false
+	}
+}
+
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	delay := d.cfg.BaseBackoff
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= d.cfg.MaxBackoff {
+			return d.cfg.MaxBackoff
+		}
+	}
+	return delay
+}
@@ -0,0 +1,134 @@
+package grpc_client
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/xopxe23/news-server/internal/domain"
+)
+
+// spool keeps log items which could not be delivered in a file, one JSON object per line.
+type spool struct {
+	mu   sync.Mutex
+	path string
+}
+
+func newSpool(path string) (*spool, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &spool{path: path}, f.Close()
+}
+
+func (s *spool) Write(items []domain.AuditLogItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	enc := json.NewEncoder(f)
+	for _, item := range items {
+		if err := enc.Encode(item); err != nil {
+			return err
+		}
+	}
+	return f.Sync()
+}
+
+// Read returns the spooled items in the order they were written. They stay in the file until removed.
+func (s *spool) Read() ([]domain.AuditLogItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lines, err := s.lines()
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]domain.AuditLogItem, 0, len(lines))
+	for _, line := range lines {
+		var item domain.AuditLogItem
+		if err := json.Unmarshal(line, &item); err != nil {
+			// a torn line left by a crash in the middle of a write
+			continue
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// Remove drops the first n items returned by Read, once they are delivered. Items written since
+// are appended after them, so they are kept.
+func (s *spool) Remove(n int) error {
+	if n <= 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lines, err := s.lines()
+	if err != nil {
+		return err
+	}
+
+	rest := lines
+	for removed := 0; removed < n && len(rest) > 0; rest = rest[1:] {
+		var item domain.AuditLogItem
+		if json.Unmarshal(rest[0], &item) == nil {
+			removed++
+		}
+	}
+
+	// the remainder replaces the file at once, so a crash leaves either the old or the new spool
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".spool-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	for _, line := range rest {
+		w.Write(line)
+		w.WriteByte('\n')
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+func (s *spool) lines() ([][]byte, error) {
+	f, err := os.Open(s.path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines [][]byte
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, append([]byte(nil), scanner.Bytes()...))
+	}
+	return lines, scanner.Err()
+}
@@ -0,0 +1,59 @@
+package grpc_server
+
+import (
+	"context"
+
+	"github.com/xopxe23/news-server/internal/domain"
+	"github.com/xopxe23/news-server/pkg/api"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+type articlesServer struct {
+	api.UnimplementedArticlesServiceServer
+	service ArticlesService
+}
+
+func (s *articlesServer) CreateArticle(ctx context.Context, req *api.CreateArticleRequest) (*api.CreateResponse, error) {
+	id, err := s.service.CreateArticle(ctx, domain.Article{
+		AuthorId: int(req.GetAuthorId()),
+		Title:    req.GetTitle(),
+		Content:  req.GetContent(),
+	})
+	if err != nil {
+		return nil, toStatus("CreateArticle", err)
+	}
+	return &api.CreateResponse{Id: int64(id)}, nil
+}
+
+func (s *articlesServer) GetAllArticles(ctx context.Context, _ *emptypb.Empty) (*api.ArticlesResponse, error) {
+	articles, err := s.service.GetAllArticles(ctx, domain.ArticleQuery{})
+	if err != nil {
+		return nil, toStatus("GetAllArticles", err)
+	}
+	return toPbArticles(articles), nil
+}
+
+func (s *articlesServer) GetArticleById(ctx context.Context, req *api.IdRequest) (*api.Article, error) {
+	article, err := s.service.GetArticleById(ctx, int(req.GetId()))
+	if err != nil {
+		return nil, toStatus("GetArticleById", err)
+	}
+	return toPbArticle(article), nil
+}
+
+func (s *articlesServer) UpdateArticle(ctx context.Context, req *api.UpdateArticleRequest) (*emptypb.Empty, error) {
+	if err := s.service.UpdateArticle(ctx, int(req.GetId()), domain.UpdateArticleInput{
+		Title:   req.Title,
+		Content: req.Content,
+	}); err != nil {
+		return nil, toStatus("UpdateArticle", err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *articlesServer) DeleteArticle(ctx context.Context, req *api.IdRequest) (*emptypb.Empty, error) {
+	if err := s.service.DeleteArticle(ctx, int(req.GetId())); err != nil {
+		return nil, toStatus("DeleteArticle", err)
+	}
+	return &emptypb.Empty{}, nil
+}
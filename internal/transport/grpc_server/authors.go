@@ -0,0 +1,71 @@
+package grpc_server
+
+import (
+	"context"
+
+	"github.com/xopxe23/news-server/internal/domain"
+	"github.com/xopxe23/news-server/pkg/api"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+type authorsServer struct {
+	api.UnimplementedAuthorsServiceServer
+	service ArticlesService
+}
+
+func (s *authorsServer) CreateAuthor(ctx context.Context, req *api.CreateAuthorRequest) (*api.CreateResponse, error) {
+	id, err := s.service.CreateAuthor(ctx, domain.Author{
+		Name:    req.GetName(),
+		Surname: req.GetSurname(),
+	})
+	if err != nil {
+		return nil, toStatus("CreateAuthor", err)
+	}
+	return &api.CreateResponse{Id: int64(id)}, nil
+}
+
+func (s *authorsServer) GetAllAuthors(ctx context.Context, _ *emptypb.Empty) (*api.AuthorsResponse, error) {
+	authors, err := s.service.GetAllAuthors(ctx)
+	if err != nil {
+		return nil, toStatus("GetAllAuthors", err)
+	}
+
+	resp := &api.AuthorsResponse{Authors: make([]*api.Author, 0, len(authors))}
+	for _, author := range authors {
+		resp.Authors = append(resp.Authors, toPbAuthor(author))
+	}
+	return resp, nil
+}
+
+func (s *authorsServer) GetAuthorById(ctx context.Context, req *api.IdRequest) (*api.Author, error) {
+	author, err := s.service.GetAuthorById(ctx, int(req.GetId()))
+	if err != nil {
+		return nil, toStatus("GetAuthorById", err)
+	}
+	return toPbAuthor(author), nil
+}
+
+func (s *authorsServer) GetAuthorArticles(ctx context.Context, req *api.IdRequest) (*api.ArticlesResponse, error) {
+	articles, err := s.service.GetAuthorArticles(ctx, int(req.GetId()), domain.ArticleQuery{})
+	if err != nil {
+		return nil, toStatus("GetAuthorArticles", err)
+	}
+	return toPbArticles(articles), nil
+}
+
+func (s *authorsServer) UpdateAuthor(ctx context.Context, req *api.UpdateAuthorRequest) (*emptypb.Empty, error) {
+	if err := s.service.UpdateAuthor(ctx, int(req.GetId()), domain.UpdateAuthorInput{
+		Name:    req.Name,
+		Surname: req.Surname,
+	}); err != nil {
+		return nil, toStatus("UpdateAuthor", err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *authorsServer) DeleteAuthor(ctx context.Context, req *api.IdRequest) (*emptypb.Empty, error) {
+	if err := s.service.DeleteAuthor(ctx, int(req.GetId())); err != nil {
+		return nil, toStatus("DeleteAuthor", err)
+	}
+	return &emptypb.Empty{}, nil
+}
@@ -0,0 +1,34 @@
+package grpc_server
+
+import (
+	"context"
+
+	"github.com/xopxe23/news-server/internal/domain"
+	"github.com/xopxe23/news-server/pkg/api"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+type bookmarksServer struct {
+	api.UnimplementedBookmarksServiceServer
+	articles ArticlesService
+	users    UsersService
+}
+
+func (s *bookmarksServer) AddBookmark(ctx context.Context, req *api.IdRequest) (*emptypb.Empty, error) {
+	userId := ctx.Value(ctxUserID).(int)
+
+	if err := s.articles.AddArticleInBookmarks(ctx, int(req.GetId()), userId); err != nil {
+		return nil, toStatus("AddBookmark", err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *bookmarksServer) GetBookmarks(ctx context.Context, _ *emptypb.Empty) (*api.ArticlesResponse, error) {
+	userId := ctx.Value(ctxUserID).(int)
+
+	articles, err := s.users.GetBookmarks(ctx, userId, domain.ArticleQuery{})
+	if err != nil {
+		return nil, toStatus("GetBookmarks", err)
+	}
+	return toPbArticles(articles), nil
+}
@@ -0,0 +1,33 @@
+package grpc_server
+
+import (
+	"github.com/xopxe23/news-server/internal/domain"
+	"github.com/xopxe23/news-server/pkg/api"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func toPbAuthor(author domain.Author) *api.Author {
+	return &api.Author{
+		Id:      int64(author.Id),
+		Name:    author.Name,
+		Surname: author.Surname,
+	}
+}
+
+func toPbArticle(article domain.ArticleOutput) *api.Article {
+	return &api.Article{
+		Id:        int64(article.Id),
+		Author:    article.Author,
+		Title:     article.Title,
+		Content:   article.Content,
+		CreatedAt: timestamppb.New(article.CreatedAt),
+	}
+}
+
+func toPbArticles(articles []domain.ArticleOutput) *api.ArticlesResponse {
+	resp := &api.ArticlesResponse{Articles: make([]*api.Article, 0, len(articles))}
+	for _, article := range articles {
+		resp.Articles = append(resp.Articles, toPbArticle(article))
+	}
+	return resp
+}
@@ -0,0 +1,154 @@
+package grpc_server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/xopxe23/news-server/internal/domain"
+	"github.com/xopxe23/news-server/pkg/api"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+type contextKey string
+
+const ctxUserID contextKey = "userId"
+
+type ArticlesService interface {
+	CreateAuthor(ctx context.Context, author domain.Author) (int, error)
+	GetAllAuthors(ctx context.Context) ([]domain.Author, error)
+	GetAuthorById(ctx context.Context, authorId int) (domain.Author, error)
+	GetAuthorArticles(ctx context.Context, authorId int, query domain.ArticleQuery) ([]domain.ArticleOutput, error)
+	UpdateAuthor(ctx context.Context, authorId int, input domain.UpdateAuthorInput) error
+	DeleteAuthor(ctx context.Context, authorId int) error
+
+	CreateArticle(ctx context.Context, input domain.Article) (int, error)
+	GetAllArticles(ctx context.Context, query domain.ArticleQuery) ([]domain.ArticleOutput, error)
+	GetArticleById(ctx context.Context, articleId int) (domain.ArticleOutput, error)
+	AddArticleInBookmarks(ctx context.Context, articleId, userId int) error
+	UpdateArticle(ctx context.Context, articleId int, input domain.UpdateArticleInput) error
+	DeleteArticle(ctx context.Context, articleId int) error
+}
+
+type UsersService interface {
+	ParseToken(ctx context.Context, token string) (int, error)
+	GetBookmarks(ctx context.Context, userId int, query domain.ArticleQuery) ([]domain.ArticleOutput, error)
+}
+
+// NewServer builds a gRPC server exposing the authors, articles and bookmarks API.
+// Every call has to carry the same bearer token as the REST API in the authorization metadata.
+func NewServer(articles ArticlesService, users UsersService) *grpc.Server {
+	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(
+		requestIdInterceptor,
+		loggingInterceptor,
+		authInterceptor(users),
+	))
+
+	api.RegisterAuthorsServiceServer(srv, &authorsServer{service: articles})
+	api.RegisterArticlesServiceServer(srv, &articlesServer{service: articles})
+	api.RegisterBookmarksServiceServer(srv, &bookmarksServer{articles: articles, users: users})
+	reflection.Register(srv)
+
+	return srv
+}
+
+func requestIdInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	var requestId string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("x-request-id"); len(values) > 0 && len(values[0]) <= 64 {
+			requestId = values[0]
+		}
+	}
+	if requestId == "" {
+		b := make([]byte, 16)
+		rand.Read(b)
+		requestId = hex.EncodeToString(b)
+	}
+
+	grpc.SetHeader(ctx, metadata.Pairs("x-request-id", requestId))
+	return handler(domain.WithRequestId(ctx, requestId), req)
+}
+
+func loggingInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	logrus.WithFields(logrus.Fields{
+		"method":     info.FullMethod,
+		"request_id": domain.RequestIdFrom(ctx),
+	}).Info()
+	return handler(ctx, req)
+}
+
+func authInterceptor(users UsersService) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		token, err := getTokenFromMetadata(ctx)
+		if err != nil {
+			logError(info.FullMethod, err)
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+
+		userId, err := users.ParseToken(ctx, token)
+		if err != nil {
+			logError(info.FullMethod, err)
+			return nil, status.Error(codes.Unauthenticated, "invalid token")
+		}
+
+		ctx = context.WithValue(ctx, ctxUserID, userId)
+		return handler(domain.WithActor(ctx, userId), req)
+	}
+}
+
+func getTokenFromMetadata(ctx context.Context) (string, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", errors.New("empty metadata")
+	}
+
+	values := md.Get("authorization")
+	if len(values) == 0 || values[0] == "" {
+		return "", errors.New("empty auth header")
+	}
+
+	headerParts := strings.Split(values[0], " ")
+	if len(headerParts) != 2 || headerParts[0] != "Bearer" || headerParts[1] == "" {
+		return "", errors.New("invalid auth header")
+	}
+
+	return headerParts[1], nil
+}
+
+var errorCodes = []struct {
+	kind error
+	code codes.Code
+}{
+	{domain.ErrValidation, codes.InvalidArgument},
+	{domain.ErrUnauthorized, codes.Unauthenticated},
+	{domain.ErrForbidden, codes.PermissionDenied},
+	{domain.ErrNotFound, codes.NotFound},
+	{domain.ErrConflict, codes.AlreadyExists},
+	{domain.ErrTooManyRequests, codes.ResourceExhausted},
+}
+
+// toStatus converts service errors to gRPC statuses. Only messages of domain errors reach the client.
+func toStatus(method string, err error) error {
+	logError(method, err)
+
+	var domainErr *domain.Error
+	for _, e := range errorCodes {
+		if errors.Is(err, e.kind) && errors.As(err, &domainErr) {
+			return status.Error(e.code, domainErr.Message)
+		}
+	}
+	return status.Error(codes.Internal, "internal error")
+}
+
+func logError(method string, err error) {
+	logrus.WithFields(logrus.Fields{
+		"method": method,
+	}).Error(err)
+}
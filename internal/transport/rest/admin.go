@@ -0,0 +1,287 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/xopxe23/news-server/internal/domain"
+)
+
+// @Summary List Users
+// @Description Users ordered by id, q searches names and emails.
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Tags Admin
+// @ID list-users
+// @Produce json
+// @Param q query string false "Part of the name or the email"
+// @Param role query string false "Role" Enums(reader, admin)
+// @Param disabled query bool false "Only disabled or only active users"
+// @Param limit query int false "Page size, 20 by default" minimum(1) maximum(100)
+// @Param offset query int false "Number of users to skip" minimum(0)
+// @Success 200 {object} domain.UsersPage
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /admin/users [get]
+func (h *Handler) listUsers(w http.ResponseWriter, r *http.Request) {
+	filter, err := userFilterFrom(r.URL.Query())
+	if err != nil {
+		writeError(w, r, "listUsers", badRequest(err))
+		return
+	}
+
+	page, err := h.usersService.ListUsers(r.Context(), filter)
+	if err != nil {
+		writeError(w, r, "listUsers", err)
+		return
+	}
+
+	render(w, r, "listUsers", http.StatusOK, page)
+}
+
+func userFilterFrom(query url.Values) (domain.UserFilter, error) {
+	filter := domain.UserFilter{
+		Query: query.Get("q"),
+		Role:  query.Get("role"),
+	}
+
+	if raw := query.Get("disabled"); raw != "" {
+		disabled, err := strconv.ParseBool(raw)
+		if err != nil {
+			return filter, fmt.Errorf("invalid disabled: %w", err)
+		}
+		filter.Disabled = &disabled
+	}
+	for name, v := range map[string]*int{"limit": &filter.Limit, "offset": &filter.Offset} {
+		if raw := query.Get(name); raw != "" {
+			n, err := strconv.Atoi(raw)
+			if err != nil {
+				return filter, fmt.Errorf("invalid %s: %w", name, err)
+			}
+			*v = n
+		}
+	}
+	return filter, nil
+}
+
+// @Summary Get User
+// @Description The user with the number of bookmarks and active sessions.
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Tags Admin
+// @ID get-user
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} domain.AdminUserDetails
+// @Failure 400 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /admin/users/{id} [get]
+func (h *Handler) getUser(w http.ResponseWriter, r *http.Request) {
+	userId, err := getIdFromRequest(r)
+	if err != nil {
+		writeError(w, r, "getUser", badRequest(err))
+		return
+	}
+
+	user, err := h.usersService.GetUserDetails(r.Context(), userId)
+	if err != nil {
+		writeError(w, r, "getUser", err)
+		return
+	}
+
+	render(w, r, "getUser", http.StatusOK, user)
+}
+
+// @Summary Disable User
+// @Description The user's tokens and API keys stop working at once, signing in is refused until the user is enabled.
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Tags Admin
+// @ID disable-user
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200
+// @Failure 400 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /admin/users/{id}/disable [post]
+func (h *Handler) disableUser(w http.ResponseWriter, r *http.Request) {
+	userId, err := getIdFromRequest(r)
+	if err != nil {
+		writeError(w, r, "disableUser", badRequest(err))
+		return
+	}
+
+	if err := h.usersService.DisableUser(r.Context(), userId); err != nil {
+		writeError(w, r, "disableUser", err)
+		return
+	}
+
+	render(w, r, "disableUser", http.StatusOK, statusResponse{Status: fmt.Sprintf("user №%d disabled", userId)})
+}
+
+// @Summary Enable User
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Tags Admin
+// @ID enable-user
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200
+// @Failure 400 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /admin/users/{id}/enable [post]
+func (h *Handler) enableUser(w http.ResponseWriter, r *http.Request) {
+	userId, err := getIdFromRequest(r)
+	if err != nil {
+		writeError(w, r, "enableUser", badRequest(err))
+		return
+	}
+
+	if err := h.usersService.EnableUser(r.Context(), userId); err != nil {
+		writeError(w, r, "enableUser", err)
+		return
+	}
+
+	render(w, r, "enableUser", http.StatusOK, statusResponse{Status: fmt.Sprintf("user №%d enabled", userId)})
+}
+
+// @Summary Log User Out
+// @Description Ends all sessions of the user, their access tokens stop working too. API keys and OAuth tokens stay valid.
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Tags Admin
+// @ID logout-user
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200
+// @Failure 400 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /admin/users/{id}/logout [post]
+func (h *Handler) logoutUser(w http.ResponseWriter, r *http.Request) {
+	userId, err := getIdFromRequest(r)
+	if err != nil {
+		writeError(w, r, "logoutUser", badRequest(err))
+		return
+	}
+
+	if err := h.usersService.LogoutUser(r.Context(), userId); err != nil {
+		writeError(w, r, "logoutUser", err)
+		return
+	}
+
+	render(w, r, "logoutUser", http.StatusOK, statusResponse{Status: fmt.Sprintf("user №%d logged out", userId)})
+}
+
+// @Summary Reset User Password
+// @Description Sends the user a link to set a new password at /auth/password/reset.
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Tags Admin
+// @ID reset-user-password
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 202
+// @Failure 400 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /admin/users/{id}/password-reset [post]
+func (h *Handler) resetUserPassword(w http.ResponseWriter, r *http.Request) {
+	userId, err := getIdFromRequest(r)
+	if err != nil {
+		writeError(w, r, "resetUserPassword", badRequest(err))
+		return
+	}
+
+	if err := h.usersService.RequestPasswordReset(r.Context(), userId); err != nil {
+		writeError(w, r, "resetUserPassword", err)
+		return
+	}
+
+	render(w, r, "resetUserPassword", http.StatusAccepted, statusResponse{Status: "password reset link sent"})
+}
+
+// @Summary Set User Role
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Tags Admin
+// @ID set-user-role
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param input body domain.SetRoleInput true "Role input"
+// @Success 200
+// @Failure 400 {object} Problem
+// @Failure 413 {object} Problem
+// @Failure 415 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /admin/users/{id}/role [put]
+func (h *Handler) setUserRole(w http.ResponseWriter, r *http.Request) {
+	userId, err := getIdFromRequest(r)
+	if err != nil {
+		writeError(w, r, "setUserRole", badRequest(err))
+		return
+	}
+
+	input, err := decode[domain.SetRoleInput](w, r)
+	if err != nil {
+		writeError(w, r, "setUserRole", err)
+		return
+	}
+
+	if err := h.usersService.SetRole(r.Context(), userId, input); err != nil {
+		writeError(w, r, "setUserRole", err)
+		return
+	}
+
+	render(w, r, "setUserRole", http.StatusOK, statusResponse{Status: fmt.Sprintf("user №%d is %s now", userId, input.Role)})
+}
+
+// @Summary Unlock User
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Tags Admin
+// @ID unlock-user
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200
+// @Failure 400 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /admin/users/{id}/unlock [post]
+func (h *Handler) unlockUser(w http.ResponseWriter, r *http.Request) {
+	userId, err := getIdFromRequest(r)
+	if err != nil {
+		writeError(w, r, "unlockUser", badRequest(err))
+		return
+	}
+
+	if err := h.usersService.UnlockUser(r.Context(), userId); err != nil {
+		writeError(w, r, "unlockUser", err)
+		return
+	}
+
+	render(w, r, "unlockUser", http.StatusOK, statusResponse{Status: fmt.Sprintf("user №%d unlocked", userId)})
+}
@@ -0,0 +1,95 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/xopxe23/news-server/internal/domain"
+)
+
+// @Summary Get API Keys
+// @Description Lists keys of the user. Keys themselves are never shown again, only their prefixes.
+// @Security BearerAuth
+// @Tags API keys
+// @ID get-api-keys
+// @Produce json
+// @Success 200 {array} domain.APIKey
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /auth/home/api-keys [get]
+func (h *Handler) getAPIKeys(w http.ResponseWriter, r *http.Request) {
+	userId := r.Context().Value(ctxUserID).(int)
+
+	keys, err := h.usersService.GetAPIKeys(r.Context(), userId)
+	if err != nil {
+		writeError(w, r, "getAPIKeys", err)
+		return
+	}
+
+	render(w, r, "getAPIKeys", http.StatusOK, dataResponse{Data: keys})
+}
+
+// @Summary Create API Key
+// @Description Returns the new key, it is shown only once. Keys are sent in the X-API-Key header.
+// @Description Keys can't be managed with API keys, only with a signed in session.
+// @Security BearerAuth
+// @Tags API keys
+// @ID create-api-key
+// @Accept json
+// @Produce json
+// @Param input body domain.CreateAPIKeyInput true "API key input"
+// @Success 201 {object} domain.CreatedAPIKey
+// @Failure 400 {object} Problem
+// @Failure 413 {object} Problem
+// @Failure 415 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /auth/home/api-keys [post]
+func (h *Handler) createAPIKey(w http.ResponseWriter, r *http.Request) {
+	userId := r.Context().Value(ctxUserID).(int)
+
+	input, err := decode[domain.CreateAPIKeyInput](w, r)
+	if err != nil {
+		writeError(w, r, "createAPIKey", err)
+		return
+	}
+
+	key, err := h.usersService.CreateAPIKey(r.Context(), userId, input)
+	if err != nil {
+		writeError(w, r, "createAPIKey", err)
+		return
+	}
+
+	render(w, r, "createAPIKey", http.StatusCreated, key)
+}
+
+// @Summary Revoke API Key
+// @Security BearerAuth
+// @Tags API keys
+// @ID revoke-api-key
+// @Produce json
+// @Param id path int true "API key ID"
+// @Success 200
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /auth/home/api-keys/{id} [delete]
+func (h *Handler) revokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	userId := r.Context().Value(ctxUserID).(int)
+
+	keyId, err := getIdFromRequest(r)
+	if err != nil {
+		writeError(w, r, "revokeAPIKey", badRequest(err))
+		return
+	}
+
+	if err := h.usersService.RevokeAPIKey(r.Context(), userId, keyId); err != nil {
+		writeError(w, r, "revokeAPIKey", err)
+		return
+	}
+
+	render(w, r, "revokeAPIKey", http.StatusOK, statusResponse{Status: "api key revoked"})
+}
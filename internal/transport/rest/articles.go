@@ -1,15 +1,19 @@
 package rest
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"encoding/xml"
 	"errors"
 	"fmt"
-	"io"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 
 	"github.com/gorilla/mux"
+	"github.com/vmihailenco/msgpack/v5"
 	"github.com/xopxe23/news-server/internal/domain"
 )
 
@@ -17,388 +21,326 @@ type ArticlesService interface {
 	CreateAuthor(ctx context.Context, author domain.Author) (int, error)
 	GetAllAuthors(ctx context.Context) ([]domain.Author, error)
 	GetAuthorById(ctx context.Context, authorId int) (domain.Author, error)
-	GetAuthorArticles(ctx context.Context, authorId int) ([]domain.ArticleOutput, error)
+	GetAuthorArticles(ctx context.Context, authorId int, query domain.ArticleQuery) ([]domain.ArticleOutput, error)
 	UpdateAuthor(ctx context.Context, authorId int, input domain.UpdateAuthorInput) error
 	DeleteAuthor(ctx context.Context, authorId int) error
 
 	CreateArticle(ctx context.Context, input domain.Article) (int, error)
-	GetAllArticles(ctx context.Context) ([]domain.ArticleOutput, error)
+	GetAllArticles(ctx context.Context, query domain.ArticleQuery) ([]domain.ArticleOutput, error)
 	GetArticleById(ctx context.Context, articleId int) (domain.ArticleOutput, error)
 	AddArticleInBookmarks(ctx context.Context, articleId, userId int) error
 	UpdateArticle(ctx context.Context, articleId int, input domain.UpdateArticleInput) error
 	DeleteArticle(ctx context.Context, articleId int) error
 }
 
+type authorIdResponse struct {
+	XMLName  xml.Name `json:"-" xml:"response"`
+	AuthorId int      `json:"author id" xml:"author_id"`
+}
+
+type articleIdResponse struct {
+	XMLName   xml.Name `json:"-" xml:"response"`
+	ArticleId int      `json:"article id" xml:"article_id"`
+}
+
+type articleResponse struct {
+	XMLName xml.Name             `json:"-" xml:"response"`
+	Article domain.ArticleOutput `json:"article"`
+}
+
 // @Summary Get All Authors
 // @Security BearerAuth
+// @Security APIKeyAuth
 // @Tags Authors
 // @ID get-all-authors
-// @Accept json
-// @Produce json
+// @Accept json,xml,application/msgpack
+// @Produce json,xml,application/msgpack
 // @Success 200 {array} domain.Author
-// @Failure 400
-// @Failure 500
+// @Failure 400 {object} Problem
+// @Failure 406 {object} Problem
+// @Failure 500 {object} Problem
 // @Router /authors [get]
 func (h *Handler) getAllAuthors(w http.ResponseWriter, r *http.Request) {
 	authors, err := h.articlesService.GetAllAuthors(r.Context())
 	if err != nil {
-		logError("getAllAuthors", err)
-		w.WriteHeader(http.StatusInternalServerError)
+		writeError(w, r, "getAllAuthors", err)
 		return
 	}
 
-	response, err := json.Marshal(map[string]interface{}{
-		"data": authors,
-	})
-	if err != nil {
-		logError("getAllAuthors", err)
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
-
-	w.Header().Add("Content-Type", "application/json")
-	w.Write(response)
+	render(w, r, "getAllAuthors", http.StatusOK, dataResponse{Data: authors})
 }
 
 // @Summary Create Author
 // @Security BearerAuth
+// @Security APIKeyAuth
 // @Tags Authors
 // @ID create-author
-// @Accept json
-// @Produce json
+// @Accept json,xml,application/msgpack
+// @Produce json,xml,application/msgpack
 // @Param input body domain.Author true "Author input"
-// @Success 200 {integer} domain.Author.Id
-// @Failure 400
-// @Failure 500
+// @Success 201 {integer} domain.Author.Id
+// @Failure 400 {object} Problem
+// @Failure 406 {object} Problem
+// @Failure 413 {object} Problem
+// @Failure 415 {object} Problem
+// @Failure 500 {object} Problem
 // @Router /authors [post]
 func (h *Handler) createAuthor(w http.ResponseWriter, r *http.Request) {
-	reqBytes, err := io.ReadAll(r.Body)
+	author, err := decode[domain.Author](w, r)
 	if err != nil {
-		logError("createAuthor", err)
-		w.WriteHeader(http.StatusBadRequest)
-		return
-	}
-
-	var author domain.Author
-	if err := json.Unmarshal(reqBytes, &author); err != nil {
-		logError("createAuthor", err)
-		w.WriteHeader(http.StatusBadRequest)
+		writeError(w, r, "createAuthor", err)
 		return
 	}
 
 	auhorId, err := h.articlesService.CreateAuthor(r.Context(), author)
 	if err != nil {
-		logError("createAuthor", err)
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
-	response, err := json.Marshal(map[string]int{
-		"author id": auhorId,
-	})
-	if err != nil {
-		logError("createAuthor", err)
-		w.WriteHeader(http.StatusInternalServerError)
+		writeError(w, r, "createAuthor", err)
 		return
 	}
-
-	w.Header().Add("Content-Type", "application/json")
-	w.Write(response)
+	render(w, r, "createAuthor", http.StatusCreated, authorIdResponse{AuthorId: auhorId})
 }
 
 // @Summary Get Author By Id
 // @Security BearerAuth
+// @Security APIKeyAuth
 // @Tags Authors
 // @ID get-author-by-id
-// @Accept json
-// @Produce json
+// @Accept json,xml,application/msgpack
+// @Produce json,xml,application/msgpack
 // @Param id path int true "Author ID"
 // @Success 200 {object} domain.Author
-// @Failure 400
-// @Failure 500
+// @Failure 400 {object} Problem
+// @Failure 406 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 500 {object} Problem
 // @Router /authors/{id} [get]
 func (h *Handler) getAuthorById(w http.ResponseWriter, r *http.Request) {
 	authorId, err := getIdFromRequest(r)
 	if err != nil {
-		logError("getAuthorById", err)
-		w.WriteHeader(http.StatusBadRequest)
+		writeError(w, r, "getAuthorById", badRequest(err))
 		return
 	}
 
 	author, err := h.articlesService.GetAuthorById(r.Context(), authorId)
 	if err != nil {
-		logError("getAuthorById", err)
-		w.WriteHeader(http.StatusInternalServerError)
+		writeError(w, r, "getAuthorById", err)
 		return
 	}
 
-	response, err := json.Marshal(author)
-	if err != nil {
-		logError("getAuthorById", err)
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
-
-	w.Header().Add("Content-Type", "application/json")
-	w.Write(response)
+	render(w, r, "getAuthorById", http.StatusOK, author)
 }
 
 // @Summary Get Author Articles
 // @Security BearerAuth
+// @Security APIKeyAuth
 // @Tags Authors
 // @ID get-author-articles
-// @Accept json
-// @Produce json
+// @Accept json,xml,application/msgpack
+// @Produce json,xml,application/msgpack
 // @Param id path int true "Author ID"
+// @Param fields query string false "Comma separated fields to return, e.g. id,title,excerpt, the id is always returned. summary returns all but content and content_html"
+// @Param include query string false "Related resources to embed" Enums(author)
 // @Success 200 {array} []domain.Article
-// @Failure 400
-// @Failure 500
+// @Failure 400 {object} Problem
+// @Failure 406 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 500 {object} Problem
 // @Router /authors/{id}/articles [get]
 func (h *Handler) getAuthorArticles(w http.ResponseWriter, r *http.Request) {
 	authorId, err := getIdFromRequest(r)
 	if err != nil {
-		logError("getAuthorArticles", err)
-		w.WriteHeader(http.StatusBadRequest)
+		writeError(w, r, "getAuthorArticles", badRequest(err))
 		return
 	}
 
-	articles, err := h.articlesService.GetAuthorArticles(r.Context(), authorId)
+	query, err := articleQueryFrom(r.URL.Query())
 	if err != nil {
-		logError("getAuthorArticles", err)
-		w.WriteHeader(http.StatusInternalServerError)
+		writeError(w, r, "getAuthorArticles", err)
 		return
 	}
 
-	response, err := json.Marshal(articles)
+	articles, err := h.articlesService.GetAuthorArticles(r.Context(), authorId, query)
 	if err != nil {
-		logError("getAuthorArticles", err)
-		w.WriteHeader(http.StatusInternalServerError)
+		writeError(w, r, "getAuthorArticles", err)
 		return
 	}
 
-	w.Header().Add("Content-Type", "application/json")
-	w.Write(response)
+	render(w, r, "getAuthorArticles", http.StatusOK, sparseArticles(articles, query))
 }
 
 // @Summary Update Author
 // @Security BearerAuth
+// @Security APIKeyAuth
 // @Tags Authors
 // @ID update-author
-// @Accept json
-// @Produce json
+// @Accept json,xml,application/msgpack
+// @Produce json,xml,application/msgpack
 // @Param id path int true "Author ID"
 // @Param input body domain.UpdateAuthorInput true "Update Author input"
 // @Success 200
-// @Failure 400
-// @Failure 500
+// @Failure 400 {object} Problem
+// @Failure 406 {object} Problem
+// @Failure 413 {object} Problem
+// @Failure 415 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 500 {object} Problem
 // @Router /authors/{id} [put]
 func (h *Handler) updateAuthor(w http.ResponseWriter, r *http.Request) {
 	authorId, err := getIdFromRequest(r)
 	if err != nil {
-		logError("updateAuthor", err)
-		w.WriteHeader(http.StatusBadRequest)
+		writeError(w, r, "updateAuthor", badRequest(err))
 		return
 	}
 
-	reqBytes, err := io.ReadAll(r.Body)
+	input, err := decode[domain.UpdateAuthorInput](w, r)
 	if err != nil {
-		logError("updateAuthor", err)
-		w.WriteHeader(http.StatusBadRequest)
-		return
-	}
-
-	var input domain.UpdateAuthorInput
-	if err := json.Unmarshal(reqBytes, &input); err != nil {
-		logError("updateAuthor", err)
-		w.WriteHeader(http.StatusBadRequest)
+		writeError(w, r, "updateAuthor", err)
 		return
 	}
 	if err = h.articlesService.UpdateAuthor(r.Context(), authorId, input); err != nil {
-		logError("updateAuthor", err)
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
-	response, err := json.Marshal(map[string]string{
-		"status": "author updated",
-	})
-	if err != nil {
-		logError("updateAuthor", err)
-		w.WriteHeader(http.StatusInternalServerError)
+		writeError(w, r, "updateAuthor", err)
 		return
 	}
-
-	w.Header().Add("Content-Type", "application/json")
-	w.Write(response)
+	render(w, r, "updateAuthor", http.StatusOK, statusResponse{Status: "author updated"})
 }
 
 // @Summary Delete Author
 // @Security BearerAuth
+// @Security APIKeyAuth
 // @Tags Authors
 // @ID delete-author
-// @Accept json
-// @Produce json
+// @Accept json,xml,application/msgpack
+// @Produce json,xml,application/msgpack
 // @Param id path int true "Author ID"
 // @Success 200
-// @Failure 400
-// @Failure 500
+// @Failure 400 {object} Problem
+// @Failure 406 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 500 {object} Problem
 // @Router /authors/{id} [delete]
 func (h *Handler) deleteAuthor(w http.ResponseWriter, r *http.Request) {
 	authorId, err := getIdFromRequest(r)
 	if err != nil {
-		logError("deleteAuthor", err)
-		w.WriteHeader(http.StatusBadRequest)
+		writeError(w, r, "deleteAuthor", badRequest(err))
 		return
 	}
 	err = h.articlesService.DeleteAuthor(r.Context(), authorId)
 	if err != nil {
-		logError("deleteAuthor", err)
-		w.WriteHeader(http.StatusBadRequest)
+		writeError(w, r, "deleteAuthor", err)
 		return
 	}
-	response, err := json.Marshal(map[string]string{
-		"status": fmt.Sprintf("author №%d deleted", authorId),
-	})
-	if err != nil {
-		logError("deleteAuthor", err)
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
-
-	w.Header().Add("Content-Type", "application/json")
-	w.Write(response)
+	render(w, r, "deleteAuthor", http.StatusOK, statusResponse{Status: fmt.Sprintf("author №%d deleted", authorId)})
 }
 
 // @Summary Get All Articles
 // @Security BearerAuth
+// @Security APIKeyAuth
 // @Tags Articles
 // @ID get-all-articles
-// @Accept json
-// @Produce json
+// @Accept json,xml,application/msgpack
+// @Produce json,xml,application/msgpack
+// @Param fields query string false "Comma separated fields to return, e.g. id,title,excerpt, the id is always returned. summary returns all but content and content_html"
+// @Param include query string false "Related resources to embed" Enums(author)
 // @Success 200 {array} domain.Article
-// @Failure 400
-// @Failure 500
+// @Failure 400 {object} Problem
+// @Failure 406 {object} Problem
+// @Failure 500 {object} Problem
 // @Router /articles [get]
 func (h *Handler) getAllArticles(w http.ResponseWriter, r *http.Request) {
-	var articles []domain.ArticleOutput
-	articles, err := h.articlesService.GetAllArticles(r.Context())
+	query, err := articleQueryFrom(r.URL.Query())
 	if err != nil {
-		logError("getAllArticles", err)
-		w.WriteHeader(http.StatusInternalServerError)
+		writeError(w, r, "getAllArticles", err)
 		return
 	}
-	response, err := json.Marshal(map[string][]domain.ArticleOutput{
-		"data": articles,
-	})
+
+	articles, err := h.articlesService.GetAllArticles(r.Context(), query)
 	if err != nil {
-		logError("getAllArticles", err)
-		w.WriteHeader(http.StatusInternalServerError)
+		writeError(w, r, "getAllArticles", err)
 		return
 	}
-
-	w.Header().Add("Content-Type", "application/json")
-	w.Write(response)
+	render(w, r, "getAllArticles", http.StatusOK, dataResponse{Data: sparseArticles(articles, query)})
 }
 
 // @Summary Create Article
 // @Security BearerAuth
+// @Security APIKeyAuth
 // @Tags Articles
 // @ID create-articles
-// @Accept json
-// @Produce json
+// @Accept json,xml,application/msgpack
+// @Produce json,xml,application/msgpack
 // @Param input body domain.Article true "Article input"
-// @Success 200
-// @Failure 400
-// @Failure 500
+// @Success 201
+// @Failure 400 {object} Problem
+// @Failure 406 {object} Problem
+// @Failure 413 {object} Problem
+// @Failure 415 {object} Problem
+// @Failure 500 {object} Problem
 // @Router /articles [post]
 func (h *Handler) createArticle(w http.ResponseWriter, r *http.Request) {
-	reqBytes, err := io.ReadAll(r.Body)
+	input, err := decode[domain.Article](w, r)
 	if err != nil {
-		logError("createArticle", err)
-		w.WriteHeader(http.StatusBadRequest)
-		return
-	}
-
-	var input domain.Article
-	if err := json.Unmarshal(reqBytes, &input); err != nil {
-		logError("createArticle", err)
-		w.WriteHeader(http.StatusBadRequest)
+		writeError(w, r, "createArticle", err)
 		return
 	}
 
 	articleId, err := h.articlesService.CreateArticle(r.Context(), input)
 	if err != nil {
-		logError("createArticle", err)
-		w.WriteHeader(http.StatusBadRequest)
+		writeError(w, r, "createArticle", err)
 		return
 	}
 
-	response, err := json.Marshal(map[string]int{
-		"article id": articleId,
-	})
-	if err != nil {
-		logError("createArticle", err)
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
-
-	w.Header().Add("Content-Type", "application/json")
-	w.Write(response)
+	render(w, r, "createArticle", http.StatusCreated, articleIdResponse{ArticleId: articleId})
 }
 
 // @Summary Get Article By Id
 // @Security BearerAuth
+// @Security APIKeyAuth
 // @Tags Articles
 // @ID get-article-by-id
-// @Accept json
-// @Produce json
+// @Accept json,xml,application/msgpack
+// @Produce json,xml,application/msgpack
 // @Param id path int true "Article ID"
 // @Success 200 {object} domain.Article
-// @Failure 400
-// @Failure 500
+// @Failure 400 {object} Problem
+// @Failure 406 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 500 {object} Problem
 // @Router /articles/{id} [get]
 func (h *Handler) getArticleById(w http.ResponseWriter, r *http.Request) {
 	articleId, err := getIdFromRequest(r)
 	if err != nil {
-		logError("getArticleById", err)
-		w.WriteHeader(http.StatusBadRequest)
+		writeError(w, r, "getArticleById", badRequest(err))
 		return
 	}
 
 	article, err := h.articlesService.GetArticleById(r.Context(), articleId)
 	if err != nil {
-		logError("getArticleById", err)
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
-
-	response, err := json.Marshal(map[string]domain.ArticleOutput{
-		"article": article,
-	})
-	if err != nil {
-		logError("getArticleById", err)
-		w.WriteHeader(http.StatusInternalServerError)
+		writeError(w, r, "getArticleById", err)
 		return
 	}
 
-	w.Header().Add("Content-Type", "application/json")
-	w.Write(response)
+	render(w, r, "getArticleById", http.StatusOK, articleResponse{Article: article})
 }
 
 // @Summary Add Article in bookmarks
 // @Security BearerAuth
+// @Security APIKeyAuth
 // @Tags Articles
 // @ID add-article-in-bookmarks
-// @Accept json
-// @Produce json
+// @Accept json,xml,application/msgpack
+// @Produce json,xml,application/msgpack
 // @Param id path int true "Article ID"
 // @Success 200
-// @Failure 400
-// @Failure 500
+// @Failure 400 {object} Problem
+// @Failure 406 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 409 {object} Problem
+// @Failure 500 {object} Problem
 // @Router /articles/{id}/bookmark [get]
 func (h *Handler) addArticleInBookmarks(w http.ResponseWriter, r *http.Request) {
 	articleId, err := getIdFromRequest(r)
 	if err != nil {
-		logError("addArticleInBookmarks", err)
-		w.WriteHeader(http.StatusBadRequest)
+		writeError(w, r, "addArticleInBookmarks", badRequest(err))
 		return
 	}
 
@@ -406,113 +348,79 @@ func (h *Handler) addArticleInBookmarks(w http.ResponseWriter, r *http.Request)
 
 	err = h.articlesService.AddArticleInBookmarks(r.Context(), articleId, userId)
 	if err != nil {
-		logError("addArticleInBookmarks", err)
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
-
-	resposne, err := json.Marshal(map[string]string{
-		"status": "bookmark added",
-	})
-	if err != nil {
-		logError("addArticleInBookmarks", err)
-		w.WriteHeader(http.StatusInternalServerError)
+		writeError(w, r, "addArticleInBookmarks", err)
 		return
 	}
 
-	w.Header().Add("Content-Type", "application/json")
-	w.Write(resposne)
+	render(w, r, "addArticleInBookmarks", http.StatusOK, statusResponse{Status: "bookmark added"})
 }
 
 // @Summary Update Article
 // @Security BearerAuth
+// @Security APIKeyAuth
 // @Tags Articles
 // @ID update-article
-// @Accept json
-// @Produce json
+// @Accept json,xml,application/msgpack
+// @Produce json,xml,application/msgpack
 // @Param id path int true "Article ID"
 // @Param input body domain.UpdateArticleInput true "Update Article input"
 // @Success 200
-// @Failure 400
-// @Failure 500
+// @Failure 400 {object} Problem
+// @Failure 406 {object} Problem
+// @Failure 413 {object} Problem
+// @Failure 415 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 500 {object} Problem
 // @Router /articles/{id} [put]
 func (h *Handler) updateArticle(w http.ResponseWriter, r *http.Request) {
 	articleId, err := getIdFromRequest(r)
 	if err != nil {
-		logError("updateArticle", err)
-		w.WriteHeader(http.StatusInternalServerError)
+		writeError(w, r, "updateArticle", badRequest(err))
 		return
 	}
 
-	var input domain.UpdateArticleInput
-	reqBytes, err := io.ReadAll(r.Body)
+	input, err := decode[domain.UpdateArticleInput](w, r)
 	if err != nil {
-		logError("updateArticle", err)
-		w.WriteHeader(http.StatusBadRequest)
-		return
-	}
-	if err := json.Unmarshal(reqBytes, &input); err != nil {
-		logError("updateArticle", err)
-		w.WriteHeader(http.StatusInternalServerError)
+		writeError(w, r, "updateArticle", err)
 		return
 	}
 
 	err = h.articlesService.UpdateArticle(r.Context(), articleId, input)
 	if err != nil {
-		logError("updateArticle", err)
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
-
-	response, err := json.Marshal(map[string]string{
-		"status": fmt.Sprintf("article №%d updated", articleId),
-	})
-	if err != nil {
-		logError("updateArticle", err)
-		w.WriteHeader(http.StatusInternalServerError)
+		writeError(w, r, "updateArticle", err)
 		return
 	}
 
-	w.Header().Add("Content-Type", "application/json")
-	w.Write(response)
+	render(w, r, "updateArticle", http.StatusOK, statusResponse{Status: fmt.Sprintf("article №%d updated", articleId)})
 }
 
 // @Summary Delete Article
 // @Security BearerAuth
+// @Security APIKeyAuth
 // @Tags Articles
 // @ID delete-article
-// @Accept json
-// @Produce json
+// @Accept json,xml,application/msgpack
+// @Produce json,xml,application/msgpack
 // @Param id path int true "Article ID"
 // @Success 200
-// @Failure 400
-// @Failure 500
+// @Failure 400 {object} Problem
+// @Failure 406 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 500 {object} Problem
 // @Router /articles/{id} [delete]
 func (h *Handler) deleteArticle(w http.ResponseWriter, r *http.Request) {
 	articleId, err := getIdFromRequest(r)
 	if err != nil {
-		logError("deleteArticle", err)
-		w.WriteHeader(http.StatusBadRequest)
+		writeError(w, r, "deleteArticle", badRequest(err))
 		return
 	}
 
 	if err := h.articlesService.DeleteArticle(r.Context(), articleId); err != nil {
-		logError("deleteArticle", err)
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
-
-	response, err := json.Marshal(map[string]string{
-		"status": fmt.Sprintf("article №%d deleted", articleId),
-	})
-	if err != nil {
-		logError("deleteArticle", err)
-		w.WriteHeader(http.StatusInternalServerError)
+		writeError(w, r, "deleteArticle", err)
 		return
 	}
 
-	w.Header().Add("Content-Type", "application/json")
-	w.Write(response)
+	render(w, r, "deleteArticle", http.StatusOK, statusResponse{Status: fmt.Sprintf("article №%d deleted", articleId)})
 }
 
 func getIdFromRequest(r *http.Request) (int, error) {
@@ -526,3 +434,132 @@ func getIdFromRequest(r *http.Request) (int, error) {
 	}
 	return id, nil
 }
+
+// articleQueryFrom reads the query parameters of the article lists. The field names are validated by the service.
+func articleQueryFrom(values url.Values) (domain.ArticleQuery, error) {
+	var query domain.ArticleQuery
+	switch fields := values.Get("fields"); fields {
+	case "":
+	case "summary":
+		query.Summary = true
+	default:
+		query.Fields = strings.Split(fields, ",")
+	}
+
+	switch include := values.Get("include"); include {
+	case "":
+	case "author":
+		query.IncludeAuthor = true
+	default:
+		return query, fieldError("include", fmt.Sprintf("must be author, got %q", include), nil)
+	}
+	return query, nil
+}
+
+// articleFieldValues are the fields of domain.ArticleOutput by their JSON names, in the order they are written.
+var articleFieldValues = []struct {
+	name  string
+	value func(a domain.ArticleOutput) interface{}
+}{
+	{"id", func(a domain.ArticleOutput) interface{} { return a.Id }},
+	{"author_id", func(a domain.ArticleOutput) interface{} { return a.AuthorId }},
+	{"author", func(a domain.ArticleOutput) interface{} { return a.Author }},
+	{"title", func(a domain.ArticleOutput) interface{} { return a.Title }},
+	{"content", func(a domain.ArticleOutput) interface{} { return a.Content }},
+	{"content_format", func(a domain.ArticleOutput) interface{} { return a.ContentFormat }},
+	{"content_html", func(a domain.ArticleOutput) interface{} { return a.ContentHTML }},
+	{"excerpt", func(a domain.ArticleOutput) interface{} { return a.Excerpt }},
+	{"word_count", func(a domain.ArticleOutput) interface{} { return a.WordCount }},
+	{"reading_time", func(a domain.ArticleOutput) interface{} { return a.ReadingTime }},
+	{"created_at", func(a domain.ArticleOutput) interface{} { return a.CreatedAt }},
+}
+
+type articleFieldValue struct {
+	name  string
+	value interface{}
+}
+
+// sparseArticle is an article of a list asked for with ?fields=. Exactly the requested fields are written,
+// zero values included, lists without ?fields= are written as domain.ArticleOutput.
+type sparseArticle struct {
+	article domain.ArticleOutput
+	fields  map[string]bool
+}
+
+// sparseArticles returns the articles to render for the query.
+func sparseArticles(articles []domain.ArticleOutput, query domain.ArticleQuery) interface{} {
+	if len(query.Fields) == 0 {
+		return articles
+	}
+
+	fields := map[string]bool{"id": true}
+	for _, name := range query.Fields {
+		fields[name] = true
+	}
+	var sparse []sparseArticle
+	for _, article := range articles {
+		sparse = append(sparse, sparseArticle{article: article, fields: fields})
+	}
+	return sparse
+}
+
+func (a sparseArticle) values() []articleFieldValue {
+	values := make([]articleFieldValue, 0, len(a.fields)+1)
+	for _, field := range articleFieldValues {
+		if a.fields[field.name] {
+			values = append(values, articleFieldValue{name: field.name, value: field.value(a.article)})
+		}
+	}
+	if a.article.Embedded != nil {
+		values = append(values, articleFieldValue{name: "embedded", value: a.article.Embedded})
+	}
+	return values
+}
+
+func (a sparseArticle) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, field := range a.values() {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		value, err := json.Marshal(field.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString(`"` + field.name + `":`)
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// MarshalXML names the element article whatever the enclosing field is, like domain.ArticleOutput does.
+func (a sparseArticle) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	start := xml.StartElement{Name: xml.Name{Local: "article"}}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	for _, field := range a.values() {
+		if err := e.EncodeElement(field.value, xml.StartElement{Name: xml.Name{Local: field.name}}); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+func (a sparseArticle) EncodeMsgpack(enc *msgpack.Encoder) error {
+	values := a.values()
+	if err := enc.EncodeMapLen(len(values)); err != nil {
+		return err
+	}
+	for _, field := range values {
+		if err := enc.EncodeString(field.name); err != nil {
+			return err
+		}
+		if err := enc.Encode(field.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
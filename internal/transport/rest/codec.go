@@ -0,0 +1,113 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"reflect"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"github.com/xopxe23/news-server/internal/domain"
+)
+
+type decoder interface {
+	Decode(v interface{}) error
+}
+
+// codec reads and writes bodies of one format. The first media type is the canonical one.
+type codec struct {
+	mediaTypes []string
+	marshal    func(v interface{}) ([]byte, error)
+	newDecoder func(r io.Reader) decoder
+}
+
+func (c codec) contentType() string {
+	return c.mediaTypes[0]
+}
+
+var (
+	jsonCodec = codec{
+		mediaTypes: []string{"application/json"},
+		marshal:    json.Marshal,
+		newDecoder: func(r io.Reader) decoder {
+			dec := json.NewDecoder(r)
+			dec.DisallowUnknownFields()
+			return dec
+		},
+	}
+	msgpackCodec = codec{
+		mediaTypes: []string{"application/msgpack", "application/x-msgpack"},
+		marshal:    marshalMsgpack,
+		newDecoder: func(r io.Reader) decoder {
+			dec := msgpack.NewDecoder(r)
+			dec.SetCustomStructTag("json")
+			dec.DisallowUnknownFields(true)
+			return dec
+		},
+	}
+	xmlCodec = codec{
+		mediaTypes: []string{"application/xml", "text/xml"},
+		marshal:    marshalXML,
+		newDecoder: func(r io.Reader) decoder {
+			return newXMLDecoder(r)
+		},
+	}
+)
+
+// defaultCodecs are used by routes which don't declare their formats.
+var defaultCodecs = []codec{jsonCodec}
+
+// marshalMsgpack uses json tags, so field names are the same as in JSON.
+func marshalMsgpack(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetCustomStructTag("json")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+type xmlList struct {
+	XMLName xml.Name `xml:"response"`
+	Items   interface{}
+}
+
+// marshalXML wraps lists into a root element, XML documents can't have several of them.
+func marshalXML(v interface{}) ([]byte, error) {
+	if reflect.ValueOf(v).Kind() == reflect.Slice {
+		v = xmlList{Items: v}
+	}
+
+	body, err := xml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
+
+func formatsMiddleware(codecs ...codec) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, ok := negotiate(r.Header.Get("Accept"), codecs); !ok {
+				writeError(w, r, "formatsMiddleware", domain.NewError(errNotAcceptable, "none of the accepted media types can be produced", nil))
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), ctxCodecs, codecs)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// codecsFrom returns the formats the route supports.
+func codecsFrom(ctx context.Context) []codec {
+	codecs, ok := ctx.Value(ctxCodecs).([]codec)
+	if !ok {
+		return defaultCodecs
+	}
+	return codecs
+}
@@ -0,0 +1,86 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/xopxe23/news-server/internal/domain"
+)
+
+// Problem is an RFC 7807 problem details object.
+type Problem struct {
+	Type      string              `json:"type"`
+	Title     string              `json:"title"`
+	Status    int                 `json:"status"`
+	Detail    string              `json:"detail,omitempty"`
+	Instance  string              `json:"instance,omitempty"`
+	RequestId string              `json:"request_id,omitempty"`
+	Errors    []domain.FieldError `json:"errors,omitempty"`
+}
+
+var errorStatuses = []struct {
+	kind   error
+	status int
+}{
+	{domain.ErrValidation, http.StatusBadRequest},
+	{domain.ErrUnauthorized, http.StatusUnauthorized},
+	{domain.ErrForbidden, http.StatusForbidden},
+	{domain.ErrNotFound, http.StatusNotFound},
+	{domain.ErrConflict, http.StatusConflict},
+	{domain.ErrTooManyRequests, http.StatusTooManyRequests},
+	{domain.ErrTooLarge, http.StatusRequestEntityTooLarge},
+	{domain.ErrUnsupportedMediaType, http.StatusUnsupportedMediaType},
+	{errNotAcceptable, http.StatusNotAcceptable},
+}
+
+// statusOf maps an error to the HTTP status it should be reported with.
+func statusOf(err error) int {
+	for _, s := range errorStatuses {
+		if errors.Is(err, s.kind) {
+			return s.status
+		}
+	}
+	return http.StatusInternalServerError
+}
+
+// badRequest marks errors of reading the request as client errors.
+func badRequest(err error) error {
+	return domain.InvalidInput("malformed request", err)
+}
+
+// writeError logs the error and responds with the matching problem details.
+// Only the messages of domain errors reach the client, anything else is reported as an internal error.
+func writeError(w http.ResponseWriter, r *http.Request, handler string, err error) {
+	logError(handler, err)
+
+	status := statusOf(err)
+	problem := Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Instance:  r.URL.Path,
+		RequestId: domain.RequestIdFrom(r.Context()),
+	}
+
+	var domainErr *domain.Error
+	if status != http.StatusInternalServerError && errors.As(err, &domainErr) {
+		problem.Detail = domainErr.Message
+		problem.Errors = domainErr.Fields
+		if domainErr.RetryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(domainErr.RetryAfter)))
+		}
+	}
+
+	response, err := json.Marshal(problem)
+	if err != nil {
+		logError(handler, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+	w.Write(response)
+}
@@ -1,45 +1,138 @@
 package rest
 
 import (
+	"context"
 	"net/http"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/swaggo/http-swagger"
-	_ "github.com/xopxe23/news-server/docs"
+	"github.com/xopxe23/news-server/docs"
+	"github.com/xopxe23/news-server/internal/domain"
 )
 
+type Config struct {
+	// LegacySunset is when unprefixed routes stop working. Zero means it isn't planned yet.
+	LegacySunset time.Time
+	// TrustedProxies is the number of proxies in front of the server appending to X-Forwarded-For.
+	// Zero ignores the header.
+	TrustedProxies int
+	RateLimit      RateLimitConfig
+	// Audit records requests made under impersonation.
+	Audit AuditSink
+	// MaxUploadSize is the largest file accepted by uploads in bytes.
+	MaxUploadSize int64
+}
+
+type AuditSink interface {
+	Record(ctx context.Context, record domain.AuditRecord) error
+}
+
 type Handler struct {
 	articlesService ArticlesService
 	usersService    UsersService
+	webhooksService WebhooksService
+	oauthService    OAuthService
+	privacyService  PrivacyService
+	mediaService    MediaService
+	graphqlHandler  http.Handler
+	cfg             Config
 }
 
-func NewHandler(users UsersService, articles ArticlesService) *Handler {
+func NewHandler(users UsersService, articles ArticlesService, webhooks WebhooksService, oauth OAuthService, privacy PrivacyService, media MediaService, graphql http.Handler, cfg Config) *Handler {
 	return &Handler{
 		usersService:    users,
 		articlesService: articles,
+		webhooksService: webhooks,
+		oauthService:    oauth,
+		privacyService:  privacy,
+		mediaService:    media,
+		graphqlHandler:  graphql,
+		cfg:             cfg,
 	}
 }
 
+// InitRoutes mounts every API version under its own prefix. A new version gets its own
+// init function and swagger instance, so versions can differ in handlers and docs.
 func (h *Handler) InitRoutes() *mux.Router {
 	r := mux.NewRouter()
-	r.Use(loggingMiddleware)
-	r.PathPrefix("/swagger").HandlerFunc(httpSwagger.WrapHandler)
+	r.Use(requestIdMiddleware, h.clientIPMiddleware, loggingMiddleware)
+
+	r.Handle("/graphql", h.operationAuthMiddleware(h.rateLimitMiddleware("api")(h.graphqlHandler))).Methods(http.MethodPost)
+
+	h.initV1(r.PathPrefix("/v1").Subrouter())
+
+	// unprefixed routes are kept for clients which haven't moved to /v1 yet
+	legacy := r.NewRoute().Subrouter()
+	legacy.Use(deprecationMiddleware("/v1", h.cfg.LegacySunset))
+	h.initV1(legacy)
+
+	return r
+}
+
+func (h *Handler) initV1(r *mux.Router) {
+	r.PathPrefix("/swagger").Handler(httpSwagger.Handler(httpSwagger.InstanceName(docs.SwaggerInfo.InstanceName())))
 
 	auth := r.PathPrefix("/auth").Subrouter()
 	{
-		auth.HandleFunc("/sign-up", h.signUp).Methods(http.MethodPost)
-		auth.HandleFunc("/sign-in", h.signIn).Methods(http.MethodPost)
-		auth.HandleFunc("/refresh", h.refresh).Methods(http.MethodGet)
+		public := auth.NewRoute().Subrouter()
+		public.Use(h.rateLimitMiddleware("auth"))
+		public.HandleFunc("/sign-up", h.signUp).Methods(http.MethodPost)
+		public.HandleFunc("/sign-in", h.signIn).Methods(http.MethodPost)
+		public.HandleFunc("/refresh", h.refresh).Methods(http.MethodGet)
+		public.HandleFunc("/2fa/verify", h.verifyMFA).Methods(http.MethodPost)
+		public.HandleFunc("/oidc/login", h.oidcLogin).Methods(http.MethodGet)
+		public.HandleFunc("/oidc/callback", h.oidcCallback).Methods(http.MethodGet)
+		public.HandleFunc("/email/confirm", h.confirmEmail).Methods(http.MethodPost)
+		public.HandleFunc("/password/reset", h.resetPassword).Methods(http.MethodPost)
 
 		home := auth.PathPrefix("/home").Subrouter()
-		home.Use(h.authMiddleware)
+		home.Use(h.authMiddleware, h.rateLimitMiddleware("api"))
 		{
 			home.HandleFunc("/bookmarks", h.getBookmarks).Methods(http.MethodGet)
+			home.HandleFunc("/profile", h.getProfile).Methods(http.MethodGet)
+			home.HandleFunc("/profile", h.updateProfile).Methods(http.MethodPatch)
+			home.HandleFunc("/impersonation/stop", h.stopImpersonation).Methods(http.MethodPost)
+
+			// credentials are managed only by signed in users, never with API keys
+			account := home.NewRoute().Subrouter()
+			account.Use(sessionOnlyMiddleware)
+			account.HandleFunc("/password", h.changePassword).Methods(http.MethodPost)
+			account.HandleFunc("/email", h.changeEmail).Methods(http.MethodPost)
+			account.HandleFunc("/2fa/enroll", h.enrollTOTP).Methods(http.MethodPost)
+			account.HandleFunc("/2fa/confirm", h.confirmTOTP).Methods(http.MethodPost)
+			account.HandleFunc("/2fa/disable", h.disableTOTP).Methods(http.MethodPost)
+			account.HandleFunc("/api-keys", h.getAPIKeys).Methods(http.MethodGet)
+			account.HandleFunc("/api-keys", h.createAPIKey).Methods(http.MethodPost)
+			account.HandleFunc("/api-keys/{id:[0-9]+}", h.revokeAPIKey).Methods(http.MethodDelete)
+			account.HandleFunc("/oauth-clients", h.getOAuthClients).Methods(http.MethodGet)
+			account.HandleFunc("/oauth-clients", h.registerOAuthClient).Methods(http.MethodPost)
+			account.HandleFunc("/oauth-clients/{client_id}", h.deleteOAuthClient).Methods(http.MethodDelete)
+			account.HandleFunc("/exports", h.requestExport).Methods(http.MethodPost)
+			account.HandleFunc("/exports/{id:[0-9]+}", h.getExport).Methods(http.MethodGet)
+			account.HandleFunc("/exports/{id:[0-9]+}/download", h.downloadExport).Methods(http.MethodGet)
+			account.HandleFunc("/deletion", h.deleteAccount).Methods(http.MethodPost)
+			account.HandleFunc("/deletion", h.cancelAccountDeletion).Methods(http.MethodDelete)
 		}
 	}
 
+	oauth := r.PathPrefix("/oauth").Subrouter()
+	{
+		// the consent is given by the user themselves, not by an app acting for them
+		consent := oauth.PathPrefix("/authorize").Subrouter()
+		consent.Use(h.authMiddleware, h.rateLimitMiddleware("api"), sessionOnlyMiddleware)
+		consent.HandleFunc("", h.getConsent).Methods(http.MethodGet)
+		consent.HandleFunc("", h.answerConsent).Methods(http.MethodPost)
+
+		clients := oauth.NewRoute().Subrouter()
+		clients.Use(h.rateLimitMiddleware("auth"))
+		clients.HandleFunc("/token", h.oauthToken).Methods(http.MethodPost)
+		clients.HandleFunc("/revoke", h.oauthRevoke).Methods(http.MethodPost)
+		clients.HandleFunc("/introspect", h.oauthIntrospect).Methods(http.MethodPost)
+	}
+
 	authors := r.PathPrefix("/authors").Subrouter()
-	authors.Use(h.authMiddleware)
+	authors.Use(h.authMiddleware, h.rateLimitMiddleware("api"), formatsMiddleware(jsonCodec, msgpackCodec, xmlCodec))
 	{
 		authors.HandleFunc("", h.getAllAuthors).Methods(http.MethodGet)
 		authors.HandleFunc("", h.createAuthor).Methods(http.MethodPost)
@@ -50,7 +143,7 @@ func (h *Handler) InitRoutes() *mux.Router {
 	}
 
 	articles := r.PathPrefix("/articles").Subrouter()
-	articles.Use(h.authMiddleware)
+	articles.Use(h.authMiddleware, h.rateLimitMiddleware("api"), formatsMiddleware(jsonCodec, msgpackCodec, xmlCodec))
 	{
 		articles.HandleFunc("", h.getAllArticles).Methods(http.MethodGet)
 		articles.HandleFunc("", h.createArticle).Methods(http.MethodPost)
@@ -58,6 +151,53 @@ func (h *Handler) InitRoutes() *mux.Router {
 		articles.HandleFunc("/{id:[0-9]+}/bookmark", h.addArticleInBookmarks).Methods(http.MethodGet)
 		articles.HandleFunc("/{id:[0-9]+}", h.updateArticle).Methods(http.MethodPut)
 		articles.HandleFunc("/{id:[0-9]+}", h.deleteArticle).Methods(http.MethodDelete)
+		articles.HandleFunc("/{id:[0-9]+}/media", h.getArticleMedia).Methods(http.MethodGet)
+		articles.HandleFunc("/{id:[0-9]+}/media", h.attachMedia).Methods(http.MethodPost)
+		articles.HandleFunc("/{id:[0-9]+}/media/{media:[0-9]+}", h.detachMedia).Methods(http.MethodDelete)
+	}
+
+	media := r.PathPrefix("/media").Subrouter()
+	media.Use(h.authMiddleware, h.rateLimitMiddleware("api"))
+	{
+		// downloads answer with the file itself, whatever image type the client accepts
+		files := media.NewRoute().Subrouter()
+		files.HandleFunc("/{id:[0-9]+}/file", h.downloadMedia).Methods(http.MethodGet)
+		files.HandleFunc("/{id:[0-9]+}/thumbnail", h.downloadMediaThumbnail).Methods(http.MethodGet)
+
+		metadata := media.NewRoute().Subrouter()
+		metadata.Use(formatsMiddleware(jsonCodec, msgpackCodec, xmlCodec))
+		metadata.HandleFunc("", h.uploadMedia).Methods(http.MethodPost)
+		metadata.HandleFunc("/{id:[0-9]+}", h.getMedia).Methods(http.MethodGet)
+		metadata.HandleFunc("/{id:[0-9]+}", h.deleteMedia).Methods(http.MethodDelete)
+	}
+
+	webhooks := r.PathPrefix("/webhooks").Subrouter()
+	webhooks.Use(h.authMiddleware, h.rateLimitMiddleware("api"), h.adminMiddleware)
+	{
+		webhooks.HandleFunc("", h.getAllWebhooks).Methods(http.MethodGet)
+		webhooks.HandleFunc("", h.createWebhook).Methods(http.MethodPost)
+		webhooks.HandleFunc("/{id:[0-9]+}", h.getWebhookById).Methods(http.MethodGet)
+		webhooks.HandleFunc("/{id:[0-9]+}", h.updateWebhook).Methods(http.MethodPut)
+		webhooks.HandleFunc("/{id:[0-9]+}", h.deleteWebhook).Methods(http.MethodDelete)
+		webhooks.HandleFunc("/{id:[0-9]+}/deliveries", h.getWebhookDeliveries).Methods(http.MethodGet)
+		webhooks.HandleFunc("/{id:[0-9]+}/deliveries/{delivery:[0-9]+}/redeliver", h.redeliverWebhook).Methods(http.MethodPost)
+	}
+
+	admin := r.PathPrefix("/admin").Subrouter()
+	admin.Use(h.authMiddleware, h.rateLimitMiddleware("api"), h.adminMiddleware)
+	{
+		admin.HandleFunc("/users", h.listUsers).Methods(http.MethodGet)
+		admin.HandleFunc("/users/{id:[0-9]+}", h.getUser).Methods(http.MethodGet)
+		admin.HandleFunc("/users/{id:[0-9]+}/role", h.setUserRole).Methods(http.MethodPut)
+		admin.HandleFunc("/users/{id:[0-9]+}/unlock", h.unlockUser).Methods(http.MethodPost)
+		admin.HandleFunc("/users/{id:[0-9]+}/disable", h.disableUser).Methods(http.MethodPost)
+		admin.HandleFunc("/users/{id:[0-9]+}/enable", h.enableUser).Methods(http.MethodPost)
+		admin.HandleFunc("/users/{id:[0-9]+}/logout", h.logoutUser).Methods(http.MethodPost)
+		admin.HandleFunc("/users/{id:[0-9]+}/password-reset", h.resetUserPassword).Methods(http.MethodPost)
+
+		// an admin signed in by password only, so a leaked admin key can't be turned into user tokens
+		adminSession := admin.NewRoute().Subrouter()
+		adminSession.Use(sessionOnlyMiddleware)
+		adminSession.HandleFunc("/users/{id:[0-9]+}/impersonate", h.impersonateUser).Methods(http.MethodPost)
 	}
-	return r
 }
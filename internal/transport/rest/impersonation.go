@@ -0,0 +1,63 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/xopxe23/news-server/internal/domain"
+)
+
+// @Summary Impersonate User
+// @Description Issues a 15 minutes access token of the user for support. Requests made with it are audited,
+// @Description account management and admin routes refuse it. Admins can't be impersonated.
+// @Security BearerAuth
+// @Tags Admin
+// @ID impersonate-user
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} domain.ImpersonationToken
+// @Failure 400 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 409 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /admin/users/{id}/impersonate [post]
+func (h *Handler) impersonateUser(w http.ResponseWriter, r *http.Request) {
+	adminId := r.Context().Value(ctxUserID).(int)
+
+	userId, err := getIdFromRequest(r)
+	if err != nil {
+		writeError(w, r, "impersonateUser", badRequest(err))
+		return
+	}
+
+	token, err := h.usersService.Impersonate(r.Context(), adminId, userId)
+	if err != nil {
+		writeError(w, r, "impersonateUser", err)
+		return
+	}
+
+	render(w, r, "impersonateUser", http.StatusOK, token)
+}
+
+// @Summary Stop Impersonation
+// @Description Ends the impersonation the token belongs to, the token stops working.
+// @Security BearerAuth
+// @Tags Admin
+// @ID stop-impersonation
+// @Produce json
+// @Success 200
+// @Failure 401 {object} Problem
+// @Failure 409 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /auth/home/impersonation/stop [post]
+func (h *Handler) stopImpersonation(w http.ResponseWriter, r *http.Request) {
+	principal, _ := r.Context().Value(ctxPrincipal).(domain.Principal)
+
+	if err := h.usersService.StopImpersonation(r.Context(), principal); err != nil {
+		writeError(w, r, "stopImpersonation", err)
+		return
+	}
+
+	render(w, r, "stopImpersonation", http.StatusOK, statusResponse{Status: "impersonation stopped"})
+}
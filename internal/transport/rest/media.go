@@ -0,0 +1,323 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/xopxe23/news-server/internal/domain"
+)
+
+type MediaService interface {
+	Upload(ctx context.Context, ownerId int, r io.Reader) (domain.Media, error)
+	GetMedia(ctx context.Context, mediaId int) (domain.Media, error)
+	OpenMedia(ctx context.Context, mediaId int, thumbnail bool) (domain.Media, io.ReadCloser, error)
+	DeleteMedia(ctx context.Context, userId, mediaId int) error
+	AttachMedia(ctx context.Context, articleId int, input domain.AttachMediaInput) error
+	DetachMedia(ctx context.Context, articleId, mediaId int) error
+	GetArticleMedia(ctx context.Context, articleId int) ([]domain.ArticleMedia, error)
+}
+
+// withURLs points the media to the routes serving its files.
+func withURLs(media domain.Media) domain.Media {
+	media.URL = fmt.Sprintf("/v1/media/%d/file", media.Id)
+	media.ThumbnailURL = fmt.Sprintf("/v1/media/%d/thumbnail", media.Id)
+	return media
+}
+
+// @Summary Upload Media
+// @Description Uploads a JPEG, PNG or GIF image in the "file" field. The type is sniffed from the content,
+// @Description a JPEG thumbnail is made right away.
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Tags Media
+// @ID upload-media
+// @Accept mpfd
+// @Produce json,xml,application/msgpack
+// @Param file formData file true "The image"
+// @Success 201 {object} domain.Media
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 413 {object} Problem
+// @Failure 415 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /media [post]
+func (h *Handler) uploadMedia(w http.ResponseWriter, r *http.Request) {
+	userId := r.Context().Value(ctxUserID).(int)
+
+	// the service limits the file itself, this only stops clients from streaming endlessly
+	r.Body = http.MaxBytesReader(w, r.Body, h.cfg.MaxUploadSize+maxBodySize)
+	reader, err := r.MultipartReader()
+	if err != nil {
+		writeError(w, r, "uploadMedia", domain.NewError(domain.ErrUnsupportedMediaType, "Content-Type must be multipart/form-data", err))
+		return
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			writeError(w, r, "uploadMedia", uploadError(badRequest(err)))
+			return
+		}
+		if part.FormName() != "file" {
+			continue
+		}
+
+		media, err := h.mediaService.Upload(r.Context(), userId, part)
+		if err != nil {
+			writeError(w, r, "uploadMedia", uploadError(err))
+			return
+		}
+
+		render(w, r, "uploadMedia", http.StatusCreated, withURLs(media))
+		return
+	}
+
+	writeError(w, r, "uploadMedia", fieldError("file", "is required", nil))
+}
+
+// uploadError reports bodies cut by MaxBytesReader as too large.
+func uploadError(err error) error {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return domain.NewError(domain.ErrTooLarge, fmt.Sprintf("body must not be larger than %d bytes", maxBytesErr.Limit), err)
+	}
+	return err
+}
+
+// @Summary Get Media
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Tags Media
+// @ID get-media
+// @Produce json,xml,application/msgpack
+// @Param id path int true "Media ID"
+// @Success 200 {object} domain.Media
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 406 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /media/{id} [get]
+func (h *Handler) getMedia(w http.ResponseWriter, r *http.Request) {
+	mediaId, err := getIdFromRequest(r)
+	if err != nil {
+		writeError(w, r, "getMedia", badRequest(err))
+		return
+	}
+
+	media, err := h.mediaService.GetMedia(r.Context(), mediaId)
+	if err != nil {
+		writeError(w, r, "getMedia", err)
+		return
+	}
+
+	render(w, r, "getMedia", http.StatusOK, withURLs(media))
+}
+
+// @Summary Download Media
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Tags Media
+// @ID download-media
+// @Produce image/jpeg,image/png,image/gif
+// @Param id path int true "Media ID"
+// @Success 200 {file} file
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /media/{id}/file [get]
+func (h *Handler) downloadMedia(w http.ResponseWriter, r *http.Request) {
+	h.serveMedia(w, r, "downloadMedia", false)
+}
+
+// @Summary Download Media Thumbnail
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Tags Media
+// @ID download-media-thumbnail
+// @Produce image/jpeg
+// @Param id path int true "Media ID"
+// @Success 200 {file} file
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /media/{id}/thumbnail [get]
+func (h *Handler) downloadMediaThumbnail(w http.ResponseWriter, r *http.Request) {
+	h.serveMedia(w, r, "downloadMediaThumbnail", true)
+}
+
+func (h *Handler) serveMedia(w http.ResponseWriter, r *http.Request, handler string, thumbnail bool) {
+	mediaId, err := getIdFromRequest(r)
+	if err != nil {
+		writeError(w, r, handler, badRequest(err))
+		return
+	}
+
+	media, file, err := h.mediaService.OpenMedia(r.Context(), mediaId, thumbnail)
+	if err != nil {
+		writeError(w, r, handler, err)
+		return
+	}
+	defer file.Close()
+
+	w.Header().Set("Content-Type", media.ContentType)
+	if !thumbnail {
+		w.Header().Set("Content-Length", strconv.FormatInt(media.Size, 10))
+	}
+	// files never change, a new upload gets a new id
+	w.Header().Set("Cache-Control", "private, max-age=31536000, immutable")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, file); err != nil {
+		logError(handler, err)
+	}
+}
+
+// @Summary Delete Media
+// @Description Deletes media uploaded by the user, it is detached from all articles.
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Tags Media
+// @ID delete-media
+// @Produce json,xml,application/msgpack
+// @Param id path int true "Media ID"
+// @Success 200
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /media/{id} [delete]
+func (h *Handler) deleteMedia(w http.ResponseWriter, r *http.Request) {
+	userId := r.Context().Value(ctxUserID).(int)
+
+	mediaId, err := getIdFromRequest(r)
+	if err != nil {
+		writeError(w, r, "deleteMedia", badRequest(err))
+		return
+	}
+
+	if err := h.mediaService.DeleteMedia(r.Context(), userId, mediaId); err != nil {
+		writeError(w, r, "deleteMedia", err)
+		return
+	}
+
+	render(w, r, "deleteMedia", http.StatusOK, statusResponse{Status: fmt.Sprintf("media №%d deleted", mediaId)})
+}
+
+// @Summary Get Article Media
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Tags Articles
+// @ID get-article-media
+// @Produce json,xml,application/msgpack
+// @Param id path int true "Article ID"
+// @Success 200 {array} domain.ArticleMedia
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 406 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /articles/{id}/media [get]
+func (h *Handler) getArticleMedia(w http.ResponseWriter, r *http.Request) {
+	articleId, err := getIdFromRequest(r)
+	if err != nil {
+		writeError(w, r, "getArticleMedia", badRequest(err))
+		return
+	}
+
+	attached, err := h.mediaService.GetArticleMedia(r.Context(), articleId)
+	if err != nil {
+		writeError(w, r, "getArticleMedia", err)
+		return
+	}
+	for i := range attached {
+		attached[i].Media = withURLs(attached[i].Media)
+	}
+
+	render(w, r, "getArticleMedia", http.StatusOK, dataResponse{Data: attached})
+}
+
+// @Summary Attach Media
+// @Description Adds uploaded media to the end of the article media. Attaching it again changes the caption and the credit.
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Tags Articles
+// @ID attach-media
+// @Accept json,xml,application/msgpack
+// @Produce json,xml,application/msgpack
+// @Param id path int true "Article ID"
+// @Param input body domain.AttachMediaInput true "The media with its caption and credit"
+// @Success 200
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 406 {object} Problem
+// @Failure 413 {object} Problem
+// @Failure 415 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /articles/{id}/media [post]
+func (h *Handler) attachMedia(w http.ResponseWriter, r *http.Request) {
+	articleId, err := getIdFromRequest(r)
+	if err != nil {
+		writeError(w, r, "attachMedia", badRequest(err))
+		return
+	}
+
+	input, err := decode[domain.AttachMediaInput](w, r)
+	if err != nil {
+		writeError(w, r, "attachMedia", err)
+		return
+	}
+
+	if err := h.mediaService.AttachMedia(r.Context(), articleId, input); err != nil {
+		writeError(w, r, "attachMedia", err)
+		return
+	}
+
+	render(w, r, "attachMedia", http.StatusOK, statusResponse{Status: fmt.Sprintf("media №%d attached", input.MediaId)})
+}
+
+// @Summary Detach Media
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Tags Articles
+// @ID detach-media
+// @Produce json,xml,application/msgpack
+// @Param id path int true "Article ID"
+// @Param media path int true "Media ID"
+// @Success 200
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 406 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /articles/{id}/media/{media} [delete]
+func (h *Handler) detachMedia(w http.ResponseWriter, r *http.Request) {
+	articleId, err := getIdFromRequest(r)
+	if err != nil {
+		writeError(w, r, "detachMedia", badRequest(err))
+		return
+	}
+	mediaId, err := strconv.Atoi(mux.Vars(r)["media"])
+	if err != nil {
+		writeError(w, r, "detachMedia", badRequest(err))
+		return
+	}
+
+	if err := h.mediaService.DetachMedia(r.Context(), articleId, mediaId); err != nil {
+		writeError(w, r, "detachMedia", err)
+		return
+	}
+
+	render(w, r, "detachMedia", http.StatusOK, statusResponse{Status: fmt.Sprintf("media №%d detached", mediaId)})
+}
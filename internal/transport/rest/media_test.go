@@ -0,0 +1,70 @@
+package rest
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/xopxe23/news-server/internal/domain"
+)
+
+// fakeUsers, fakeOAuth and fakeMedia implement only the calls the tests make, the rest panic on the nil interface.
+type fakeUsers struct {
+	UsersService
+}
+
+func (f *fakeUsers) ParseAccessToken(ctx context.Context, token string) (domain.Principal, error) {
+	return domain.Principal{UserId: 1}, nil
+}
+
+type fakeOAuth struct {
+	OAuthService
+}
+
+func (f *fakeOAuth) IsAccessToken(raw string) bool {
+	return false
+}
+
+type fakeMedia struct {
+	MediaService
+}
+
+func (f *fakeMedia) GetMedia(ctx context.Context, mediaId int) (domain.Media, error) {
+	return domain.Media{Id: mediaId, ContentType: "image/png"}, nil
+}
+
+func (f *fakeMedia) OpenMedia(ctx context.Context, mediaId int, thumbnail bool) (domain.Media, io.ReadCloser, error) {
+	return domain.Media{Id: mediaId, ContentType: "image/png", Size: 3}, io.NopCloser(strings.NewReader("png")), nil
+}
+
+func TestMediaRoutesNegotiation(t *testing.T) {
+	h := NewHandler(&fakeUsers{}, nil, nil, &fakeOAuth{}, nil, &fakeMedia{}, nil, Config{})
+	routes := h.InitRoutes()
+
+	tests := []struct {
+		path   string
+		accept string
+		status int
+	}{
+		{"/v1/media/1/file", "image/*", http.StatusOK},
+		{"/v1/media/1/thumbnail", "image/png", http.StatusOK},
+		{"/v1/media/1", "application/json", http.StatusOK},
+		{"/v1/media/1", "image/*", http.StatusNotAcceptable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path+" "+tt.accept, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			r.Header.Set("Authorization", "Bearer token")
+			r.Header.Set("Accept", tt.accept)
+			w := httptest.NewRecorder()
+			routes.ServeHTTP(w, r)
+
+			if w.Code != tt.status {
+				t.Errorf("got status %d, want %d: %s", w.Code, tt.status, w.Body.String())
+			}
+		})
+	}
+}
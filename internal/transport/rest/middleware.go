@@ -2,52 +2,262 @@ package rest
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"net"
 	"net/http"
 	"strings"
+	"time"
 
 	log "github.com/sirupsen/logrus"
+	"github.com/xopxe23/news-server/internal/domain"
 )
 
-
 type contextKey string
 
 const (
-	ctxUserID  contextKey = "userId"
+	ctxUserID contextKey = "userId"
+	ctxCodecs contextKey = "codecs"
+	// ctxPrincipal is set for requests made under impersonation
+	ctxPrincipal contextKey = "principal"
 )
 
+const (
+	requestIdHeader = "X-Request-ID"
+	apiKeyHeader    = "X-API-Key"
+)
+
+// requestIdMiddleware makes sure every request has an id, taking the one sent by the client if present.
+func requestIdMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestId := r.Header.Get(requestIdHeader)
+		if requestId == "" || len(requestId) > 64 {
+			b := make([]byte, 16)
+			rand.Read(b)
+			requestId = hex.EncodeToString(b)
+		}
+
+		w.Header().Set(requestIdHeader, requestId)
+		next.ServeHTTP(w, r.WithContext(domain.WithRequestId(r.Context(), requestId)))
+	})
+}
+
+// clientIPMiddleware puts the address of the client into the request context.
+func (h *Handler) clientIPMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(domain.WithClientIP(r.Context(), h.clientIP(r))))
+	})
+}
+
+// clientIP returns the address the outermost trusted proxy got the request from. Every proxy appends the address
+// of its peer to X-Forwarded-For, so that is the entry TrustedProxies from the right, the ones before it are
+// sent by the client and may be anything.
+func (h *Handler) clientIP(r *http.Request) string {
+	if h.cfg.TrustedProxies > 0 {
+		var entries []string
+		for _, header := range r.Header.Values("X-Forwarded-For") {
+			entries = append(entries, strings.Split(header, ",")...)
+		}
+		if len(entries) > 0 {
+			i := len(entries) - h.cfg.TrustedProxies
+			if i < 0 {
+				i = 0
+			}
+			if ip := net.ParseIP(strings.TrimSpace(entries[i])); ip != nil {
+				return ip.String()
+			}
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
 func loggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		log.WithFields(log.Fields{
-			"method": r.Method,
-			"uri":    r.RequestURI,
+			"method":     r.Method,
+			"uri":        r.RequestURI,
+			"request_id": domain.RequestIdFrom(r.Context()),
 		}).Info()
 		next.ServeHTTP(w, r)
 	})
 }
 
+// deprecationMiddleware marks responses of deprecated routes and points to their successor under prefix.
+func deprecationMiddleware(prefix string, sunset time.Time) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Deprecation", "true")
+			if !sunset.IsZero() {
+				w.Header().Set("Sunset", sunset.UTC().Format(http.TimeFormat))
+			}
+			w.Header().Set("Link", fmt.Sprintf(`<%s%s>; rel="successor-version"`, prefix, r.URL.Path))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// authMiddleware accepts an access token, an OAuth access token or an API key of an active user. Requests made with
+// OAuth tokens and API keys are limited by their scopes: safe methods need read, the rest need write.
 func (h *Handler) authMiddleware(next http.Handler) http.Handler {
+	return h.authenticate(next, methodScope)
+}
+
+// operationAuthMiddleware is authMiddleware for GraphQL, where the operation rather than the method tells
+// reads from writes. It leaves the scope check to next, which finds the scopes with domain.ScopesFrom.
+func (h *Handler) operationAuthMiddleware(next http.Handler) http.Handler {
+	return h.authenticate(next, nil)
+}
+
+// authenticate accepts the credentials of authMiddleware. requiredScope returns the scope requests made with
+// OAuth tokens and API keys need, nil passes them all on.
+func (h *Handler) authenticate(next http.Handler, requiredScope func(r *http.Request) string) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if raw := r.Header.Get(apiKeyHeader); raw != "" {
+			key, err := h.usersService.AuthenticateAPIKey(r.Context(), raw)
+			if err != nil {
+				writeError(w, r, "authMiddleware", err)
+				return
+			}
+			serveScoped(w, r, next, key.UserId, key.Scopes, requiredScope)
+			return
+		}
+
 		token, err := getTokenFromRequest(r)
 		if err != nil {
-			logError("authMiddleware", err)
-			w.WriteHeader(http.StatusUnauthorized)
+			writeError(w, r, "authMiddleware", domain.NewError(domain.ErrUnauthorized, err.Error(), err))
+			return
+		}
+
+		if h.oauthService.IsAccessToken(token) {
+			grant, err := h.oauthService.AuthenticateToken(r.Context(), token)
+			if err != nil {
+				writeError(w, r, "authMiddleware", err)
+				return
+			}
+			if err := h.usersService.CheckActive(r.Context(), grant.UserId); err != nil {
+				writeError(w, r, "authMiddleware", err)
+				return
+			}
+			serveScoped(w, r, next, grant.UserId, grant.Scopes, requiredScope)
 			return
 		}
 
-		userId, err := h.usersService.ParseToken(r.Context(), token)
+		principal, err := h.usersService.ParseAccessToken(r.Context(), token)
 		if err != nil {
-			logError("authMiddleware", err)
-			w.WriteHeader(http.StatusUnauthorized)
+			writeError(w, r, "authMiddleware", err)
 			return
 		}
 
-		ctx := context.WithValue(r.Context(), ctxUserID, userId)
+		ctx := context.WithValue(r.Context(), ctxUserID, principal.UserId)
+		ctx = domain.WithActor(ctx, principal.UserId)
+		if principal.Impersonated() {
+			ctx = context.WithValue(ctx, ctxPrincipal, principal)
+			ctx = domain.WithImpersonator(ctx, principal.ImpersonatorId)
+			h.auditImpersonated(ctx, r, principal)
+		}
 		r = r.WithContext(ctx)
 		next.ServeHTTP(w, r)
 	})
 }
 
+// auditImpersonated records a request an admin makes as another user. A failure to record it is logged
+// and doesn't fail the request, the same as with other audit records.
+func (h *Handler) auditImpersonated(ctx context.Context, r *http.Request, principal domain.Principal) {
+	if h.cfg.Audit == nil {
+		return
+	}
+
+	if err := h.cfg.Audit.Record(ctx, domain.AuditRecord{
+		Action:         domain.AuditActionImpersonatedAccess,
+		Entity:         domain.AuditEntityUser,
+		EntityId:       principal.UserId,
+		ActorId:        principal.ImpersonatorId,
+		ImpersonatorId: principal.ImpersonatorId,
+		RequestId:      domain.RequestIdFrom(ctx),
+		Details:        r.Method + " " + r.URL.Path,
+		Timestamp:      time.Now().UTC(),
+	}); err != nil {
+		log.WithField("method", "auditImpersonated").Error("failed to record audit: ", err)
+	}
+}
+
+// methodScope returns the scope a request with the method needs.
+func methodScope(r *http.Request) string {
+	if r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+		return domain.ScopeRead
+	}
+	return domain.ScopeWrite
+}
+
+// serveScoped passes the request on if the scopes grant the scope it requires.
+func serveScoped(w http.ResponseWriter, r *http.Request, next http.Handler, userId int, scopes domain.Scopes,
+	requiredScope func(r *http.Request) string) {
+	if requiredScope != nil {
+		if scope := requiredScope(r); !scopes.Allows(scope) {
+			writeError(w, r, "authMiddleware", domain.Forbidden(fmt.Sprintf("the %s scope is required", scope)))
+			return
+		}
+	}
+
+	ctx := context.WithValue(r.Context(), ctxUserID, userId)
+	ctx = domain.WithScopes(ctx, scopes)
+	ctx = domain.WithActor(ctx, userId)
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// sessionOnlyMiddleware refuses requests made with API keys and OAuth tokens, so a leaked key
+// or a third-party app can't take over the account. Admins impersonating the user are refused too.
+func sessionOnlyMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := domain.ScopesFrom(r.Context()); ok {
+			writeError(w, r, "sessionOnlyMiddleware", domain.Forbidden("api keys and oauth tokens can't be used here, sign in instead"))
+			return
+		}
+		if _, ok := r.Context().Value(ctxPrincipal).(domain.Principal); ok {
+			writeError(w, r, "sessionOnlyMiddleware", domain.Forbidden("not allowed while impersonating"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// adminMiddleware lets admins through. Requests made with API keys also need the admin scope.
+// Impersonation never grants admin rights.
+func (h *Handler) adminMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userId := r.Context().Value(ctxUserID).(int)
+
+		if _, ok := r.Context().Value(ctxPrincipal).(domain.Principal); ok {
+			writeError(w, r, "adminMiddleware", domain.Forbidden("not allowed while impersonating"))
+			return
+		}
+
+		if scopes, ok := domain.ScopesFrom(r.Context()); ok && !scopes.Allows(domain.ScopeAdmin) {
+			writeError(w, r, "adminMiddleware", domain.Forbidden("the admin scope is required"))
+			return
+		}
+
+		isAdmin, err := h.usersService.IsAdmin(r.Context(), userId)
+		if err != nil {
+			writeError(w, r, "adminMiddleware", err)
+			return
+		}
+		if !isAdmin {
+			writeError(w, r, "adminMiddleware", domain.Forbidden("admin role required"))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
 func getTokenFromRequest(r *http.Request) (string, error) {
 	header := r.Header.Get("Authorization")
 	if header == "" {
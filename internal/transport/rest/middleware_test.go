@@ -0,0 +1,38 @@
+package rest
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name      string
+		proxies   int
+		forwarded []string
+		want      string
+	}{
+		{name: "header ignored", proxies: 0, forwarded: []string{"1.1.1.1"}, want: "192.0.2.1"},
+		{name: "no header", proxies: 1, want: "192.0.2.1"},
+		{name: "one proxy", proxies: 1, forwarded: []string{"1.1.1.1"}, want: "1.1.1.1"},
+		{name: "spoofed entry", proxies: 1, forwarded: []string{"6.6.6.6, 1.1.1.1"}, want: "1.1.1.1"},
+		{name: "two proxies", proxies: 2, forwarded: []string{"6.6.6.6, 1.1.1.1, 10.0.0.2"}, want: "1.1.1.1"},
+		{name: "several headers", proxies: 2, forwarded: []string{"6.6.6.6", "1.1.1.1, 10.0.0.2"}, want: "1.1.1.1"},
+		{name: "fewer entries than proxies", proxies: 3, forwarded: []string{"1.1.1.1, 10.0.0.2"}, want: "1.1.1.1"},
+		{name: "not an address", proxies: 1, forwarded: []string{"1.1.1.1, garbage"}, want: "192.0.2.1"},
+		{name: "ipv6", proxies: 1, forwarded: []string{"2001:DB8::1"}, want: "2001:db8::1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &Handler{cfg: Config{TrustedProxies: tt.proxies}}
+			r := httptest.NewRequest("GET", "/", nil)
+			for _, header := range tt.forwarded {
+				r.Header.Add("X-Forwarded-For", header)
+			}
+
+			if got := h.clientIP(r); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,332 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/gorilla/mux"
+	"github.com/xopxe23/news-server/internal/domain"
+)
+
+type OAuthService interface {
+	RegisterClient(ctx context.Context, ownerId int, input domain.OAuthClientInput) (domain.RegisteredOAuthClient, error)
+	GetClients(ctx context.Context, ownerId int) ([]domain.OAuthClient, error)
+	DeleteClient(ctx context.Context, ownerId int, clientId string) error
+	Authorize(ctx context.Context, req domain.AuthorizeRequest) (domain.Consent, error)
+	Consent(ctx context.Context, userId int, input domain.ConsentInput) (string, error)
+	Token(ctx context.Context, req domain.TokenRequest) (domain.IssuedToken, error)
+	Revoke(ctx context.Context, clientId, clientSecret, token string) error
+	Introspect(ctx context.Context, clientId, clientSecret, token string) (domain.TokenIntrospection, error)
+	IsAccessToken(raw string) bool
+	AuthenticateToken(ctx context.Context, raw string) (domain.OAuthToken, error)
+}
+
+type redirectResponse struct {
+	RedirectTo string `json:"redirect_to"`
+}
+
+// @Summary Get OAuth Clients
+// @Security BearerAuth
+// @Tags OAuth
+// @ID get-oauth-clients
+// @Produce json
+// @Success 200 {array} domain.OAuthClient
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /auth/home/oauth-clients [get]
+func (h *Handler) getOAuthClients(w http.ResponseWriter, r *http.Request) {
+	userId := r.Context().Value(ctxUserID).(int)
+
+	clients, err := h.oauthService.GetClients(r.Context(), userId)
+	if err != nil {
+		writeError(w, r, "getOAuthClients", err)
+		return
+	}
+
+	render(w, r, "getOAuthClients", http.StatusOK, dataResponse{Data: clients})
+}
+
+// @Summary Register OAuth Client
+// @Description Registers a third-party application. Confidential clients get a secret, which is shown only once.
+// @Security BearerAuth
+// @Tags OAuth
+// @ID register-oauth-client
+// @Accept json
+// @Produce json
+// @Param input body domain.OAuthClientInput true "Client input"
+// @Success 201 {object} domain.RegisteredOAuthClient
+// @Failure 400 {object} Problem
+// @Failure 413 {object} Problem
+// @Failure 415 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /auth/home/oauth-clients [post]
+func (h *Handler) registerOAuthClient(w http.ResponseWriter, r *http.Request) {
+	userId := r.Context().Value(ctxUserID).(int)
+
+	input, err := decode[domain.OAuthClientInput](w, r)
+	if err != nil {
+		writeError(w, r, "registerOAuthClient", err)
+		return
+	}
+
+	client, err := h.oauthService.RegisterClient(r.Context(), userId, input)
+	if err != nil {
+		writeError(w, r, "registerOAuthClient", err)
+		return
+	}
+
+	render(w, r, "registerOAuthClient", http.StatusCreated, client)
+}
+
+// @Summary Delete OAuth Client
+// @Description Deletes the application together with the tokens issued to it.
+// @Security BearerAuth
+// @Tags OAuth
+// @ID delete-oauth-client
+// @Produce json
+// @Param client_id path string true "Client ID"
+// @Success 200
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /auth/home/oauth-clients/{client_id} [delete]
+func (h *Handler) deleteOAuthClient(w http.ResponseWriter, r *http.Request) {
+	userId := r.Context().Value(ctxUserID).(int)
+
+	if err := h.oauthService.DeleteClient(r.Context(), userId, mux.Vars(r)["client_id"]); err != nil {
+		writeError(w, r, "deleteOAuthClient", err)
+		return
+	}
+
+	render(w, r, "deleteOAuthClient", http.StatusOK, statusResponse{Status: "client deleted"})
+}
+
+// @Summary Get Consent
+// @Description Checks an authorization request and returns what the consent screen has to show.
+// @Description The frontend sends the user's answer to POST /oauth/authorize.
+// @Security BearerAuth
+// @Tags OAuth
+// @ID get-consent
+// @Produce json
+// @Param response_type query string true "code"
+// @Param client_id query string true "Client ID"
+// @Param redirect_uri query string true "Redirect URI"
+// @Param scope query string false "Space separated scopes"
+// @Param state query string false "State"
+// @Param code_challenge query string true "PKCE code challenge"
+// @Param code_challenge_method query string true "S256"
+// @Success 200 {object} domain.Consent
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /oauth/authorize [get]
+func (h *Handler) getConsent(w http.ResponseWriter, r *http.Request) {
+	consent, err := h.oauthService.Authorize(r.Context(), authorizeRequestFrom(r.URL.Query()))
+	if err != nil {
+		writeError(w, r, "getConsent", err)
+		return
+	}
+
+	render(w, r, "getConsent", http.StatusOK, consent)
+}
+
+// @Summary Answer Consent
+// @Description Returns the client redirect URI with an authorization code if the user approved, or with
+// @Description the access_denied error otherwise.
+// @Security BearerAuth
+// @Tags OAuth
+// @ID answer-consent
+// @Accept json
+// @Produce json
+// @Param input body domain.ConsentInput true "Authorization request and the answer"
+// @Success 200 {object} redirectResponse
+// @Failure 400 {object} Problem
+// @Failure 413 {object} Problem
+// @Failure 415 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /oauth/authorize [post]
+func (h *Handler) answerConsent(w http.ResponseWriter, r *http.Request) {
+	userId := r.Context().Value(ctxUserID).(int)
+
+	input, err := decode[domain.ConsentInput](w, r)
+	if err != nil {
+		writeError(w, r, "answerConsent", err)
+		return
+	}
+
+	redirect, err := h.oauthService.Consent(r.Context(), userId, input)
+	if err != nil {
+		writeError(w, r, "answerConsent", err)
+		return
+	}
+
+	render(w, r, "answerConsent", http.StatusOK, redirectResponse{RedirectTo: redirect})
+}
+
+// @Summary Token
+// @Description Issues access tokens for the authorization_code (with PKCE) and client_credentials grants.
+// @Description Clients authenticate with HTTP Basic or client_id and client_secret parameters.
+// @Tags OAuth
+// @ID oauth-token
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param grant_type formData string true "authorization_code or client_credentials"
+// @Param code formData string false "Authorization code"
+// @Param redirect_uri formData string false "Redirect URI the code was issued for"
+// @Param code_verifier formData string false "PKCE code verifier"
+// @Param scope formData string false "Space separated scopes"
+// @Success 200 {object} domain.IssuedToken
+// @Failure 400 {object} domain.OAuthError
+// @Failure 401 {object} domain.OAuthError
+// @Failure 429 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /oauth/token [post]
+func (h *Handler) oauthToken(w http.ResponseWriter, r *http.Request) {
+	form, clientId, clientSecret, err := parseOAuthForm(w, r)
+	if err != nil {
+		writeOAuthError(w, r, "oauthToken", err)
+		return
+	}
+
+	token, err := h.oauthService.Token(r.Context(), domain.TokenRequest{
+		GrantType:    form.Get("grant_type"),
+		Code:         form.Get("code"),
+		RedirectURI:  form.Get("redirect_uri"),
+		CodeVerifier: form.Get("code_verifier"),
+		Scope:        form.Get("scope"),
+		ClientId:     clientId,
+		ClientSecret: clientSecret,
+	})
+	if err != nil {
+		writeOAuthError(w, r, "oauthToken", err)
+		return
+	}
+
+	writeOAuthJSON(w, r, "oauthToken", token)
+}
+
+// @Summary Revoke Token
+// @Description Revokes an access token of the client. Unknown tokens are ignored, see RFC 7009.
+// @Tags OAuth
+// @ID oauth-revoke
+// @Accept x-www-form-urlencoded
+// @Param token formData string true "Access token"
+// @Success 200
+// @Failure 400 {object} domain.OAuthError
+// @Failure 401 {object} domain.OAuthError
+// @Failure 429 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /oauth/revoke [post]
+func (h *Handler) oauthRevoke(w http.ResponseWriter, r *http.Request) {
+	form, clientId, clientSecret, err := parseOAuthForm(w, r)
+	if err != nil {
+		writeOAuthError(w, r, "oauthRevoke", err)
+		return
+	}
+
+	if err := h.oauthService.Revoke(r.Context(), clientId, clientSecret, form.Get("token")); err != nil {
+		writeOAuthError(w, r, "oauthRevoke", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// @Summary Introspect Token
+// @Description Describes an access token of the client, see RFC 7662.
+// @Tags OAuth
+// @ID oauth-introspect
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param token formData string true "Access token"
+// @Success 200 {object} domain.TokenIntrospection
+// @Failure 400 {object} domain.OAuthError
+// @Failure 401 {object} domain.OAuthError
+// @Failure 429 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /oauth/introspect [post]
+func (h *Handler) oauthIntrospect(w http.ResponseWriter, r *http.Request) {
+	form, clientId, clientSecret, err := parseOAuthForm(w, r)
+	if err != nil {
+		writeOAuthError(w, r, "oauthIntrospect", err)
+		return
+	}
+
+	introspection, err := h.oauthService.Introspect(r.Context(), clientId, clientSecret, form.Get("token"))
+	if err != nil {
+		writeOAuthError(w, r, "oauthIntrospect", err)
+		return
+	}
+
+	writeOAuthJSON(w, r, "oauthIntrospect", introspection)
+}
+
+func authorizeRequestFrom(query url.Values) domain.AuthorizeRequest {
+	return domain.AuthorizeRequest{
+		ResponseType:        query.Get("response_type"),
+		ClientId:            query.Get("client_id"),
+		RedirectURI:         query.Get("redirect_uri"),
+		Scope:               query.Get("scope"),
+		State:               query.Get("state"),
+		CodeChallenge:       query.Get("code_challenge"),
+		CodeChallengeMethod: query.Get("code_challenge_method"),
+	}
+}
+
+// parseOAuthForm reads the form body and the client credentials, which come either
+// in the Authorization header or in the form.
+func parseOAuthForm(w http.ResponseWriter, r *http.Request) (url.Values, string, string, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodySize)
+	if err := r.ParseForm(); err != nil {
+		return nil, "", "", domain.NewOAuthError("invalid_request", "body must be a form")
+	}
+	form := r.PostForm
+
+	if id, secret, ok := r.BasicAuth(); ok {
+		// credentials in the header are form encoded, see RFC 6749 section 2.3.1
+		clientId, err1 := url.QueryUnescape(id)
+		clientSecret, err2 := url.QueryUnescape(secret)
+		if err1 != nil || err2 != nil {
+			return nil, "", "", domain.NewOAuthError("invalid_client", "malformed credentials")
+		}
+		return form, clientId, clientSecret, nil
+	}
+	return form, form.Get("client_id"), form.Get("client_secret"), nil
+}
+
+func writeOAuthJSON(w http.ResponseWriter, r *http.Request, handler string, v interface{}) {
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	render(w, r, handler, http.StatusOK, v)
+}
+
+// writeOAuthError answers in the format of RFC 6749, errors which aren't OAuth ones are written as problems.
+func writeOAuthError(w http.ResponseWriter, r *http.Request, handler string, err error) {
+	var oauthErr *domain.OAuthError
+	if !errors.As(err, &oauthErr) {
+		writeError(w, r, handler, err)
+		return
+	}
+	logError(handler, err)
+
+	status := http.StatusBadRequest
+	if oauthErr.Code == "invalid_client" {
+		status = http.StatusUnauthorized
+		w.Header().Set("WWW-Authenticate", `Basic realm="oauth"`)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(oauthErr)
+}
@@ -0,0 +1,85 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/xopxe23/news-server/internal/domain"
+)
+
+const oidcStateCookie = "oidc-state"
+
+// @Summary Sign In With Identity Provider
+// @Description Redirects to the OpenID Connect provider. The provider sends the user back to /auth/oidc/callback.
+// @Tags Users auth
+// @ID oidc-login
+// @Success 302
+// @Failure 404 {object} Problem
+// @Failure 429 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /auth/oidc/login [get]
+func (h *Handler) oidcLogin(w http.ResponseWriter, r *http.Request) {
+	login, err := h.usersService.StartOIDC(r.Context())
+	if err != nil {
+		writeError(w, r, "oidcLogin", err)
+		return
+	}
+
+	// Lax, so the cookie comes back with the redirect from the provider
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    login.StateToken,
+		Path:     "/",
+		MaxAge:   600,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, login.URL, http.StatusFound)
+}
+
+// @Summary Identity Provider Callback
+// @Description Finishes the sign-in started at /auth/oidc/login. The identity is linked to the user with
+// @Description the same verified email, or a new user is registered.
+// @Tags Users auth
+// @ID oidc-callback
+// @Produce json
+// @Param code query string true "Authorization code"
+// @Param state query string true "State"
+// @Success 200 {object} tokenResponse
+// @Success 202 {object} challengeResponse
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 429 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /auth/oidc/callback [get]
+func (h *Handler) oidcCallback(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if e := query.Get("error"); e != "" {
+		writeError(w, r, "oidcCallback", domain.Unauthorized("identity provider: "+e+" "+query.Get("error_description")))
+		return
+	}
+	if query.Get("code") == "" || query.Get("state") == "" {
+		writeError(w, r, "oidcCallback", domain.InvalidInput("code and state are required", nil))
+		return
+	}
+
+	cookie, err := r.Cookie(oidcStateCookie)
+	if err != nil {
+		writeError(w, r, "oidcCallback", domain.NewError(domain.ErrUnauthorized, "missing state cookie", err))
+		return
+	}
+	http.SetCookie(w, &http.Cookie{Name: oidcStateCookie, Path: "/", MaxAge: -1, HttpOnly: true})
+
+	result, err := h.usersService.FinishOIDC(r.Context(), domain.OIDCCallback{
+		Code:       query.Get("code"),
+		State:      query.Get("state"),
+		StateToken: cookie.Value,
+	})
+	if err != nil {
+		writeError(w, r, "oidcCallback", err)
+		return
+	}
+
+	renderSignIn(w, r, "oidcCallback", result)
+}
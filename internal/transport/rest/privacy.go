@@ -0,0 +1,165 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/xopxe23/news-server/internal/domain"
+)
+
+type PrivacyService interface {
+	RequestExport(ctx context.Context, userId int) (domain.DataExport, error)
+	GetExport(ctx context.Context, userId, exportId int) (domain.DataExport, error)
+	GetExportArchive(ctx context.Context, userId, exportId int) ([]byte, error)
+	ScheduleDeletion(ctx context.Context, userId int, input domain.DeleteAccountInput) (domain.AccountDeletion, error)
+	CancelDeletion(ctx context.Context, userId int) error
+}
+
+// @Summary Request Data Export
+// @Description Queues an archive of everything stored about the user. Poll the export until it is ready, then download it.
+// @Security BearerAuth
+// @Tags Privacy
+// @ID request-export
+// @Produce json
+// @Success 202 {object} domain.DataExport
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /auth/home/exports [post]
+func (h *Handler) requestExport(w http.ResponseWriter, r *http.Request) {
+	userId := r.Context().Value(ctxUserID).(int)
+
+	export, err := h.privacyService.RequestExport(r.Context(), userId)
+	if err != nil {
+		writeError(w, r, "requestExport", err)
+		return
+	}
+
+	render(w, r, "requestExport", http.StatusAccepted, export)
+}
+
+// @Summary Get Data Export
+// @Security BearerAuth
+// @Tags Privacy
+// @ID get-export
+// @Produce json
+// @Param id path int true "Export ID"
+// @Success 200 {object} domain.DataExport
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /auth/home/exports/{id} [get]
+func (h *Handler) getExport(w http.ResponseWriter, r *http.Request) {
+	userId := r.Context().Value(ctxUserID).(int)
+
+	exportId, err := getIdFromRequest(r)
+	if err != nil {
+		writeError(w, r, "getExport", badRequest(err))
+		return
+	}
+
+	export, err := h.privacyService.GetExport(r.Context(), userId, exportId)
+	if err != nil {
+		writeError(w, r, "getExport", err)
+		return
+	}
+
+	render(w, r, "getExport", http.StatusOK, export)
+}
+
+// @Summary Download Data Export
+// @Security BearerAuth
+// @Tags Privacy
+// @ID download-export
+// @Produce application/zip
+// @Param id path int true "Export ID"
+// @Success 200 {file} file
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /auth/home/exports/{id}/download [get]
+func (h *Handler) downloadExport(w http.ResponseWriter, r *http.Request) {
+	userId := r.Context().Value(ctxUserID).(int)
+
+	exportId, err := getIdFromRequest(r)
+	if err != nil {
+		writeError(w, r, "downloadExport", badRequest(err))
+		return
+	}
+
+	archive, err := h.privacyService.GetExportArchive(r.Context(), userId, exportId)
+	if err != nil {
+		writeError(w, r, "downloadExport", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Length", strconv.Itoa(len(archive)))
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="export-%d.zip"`, exportId))
+	w.WriteHeader(http.StatusOK)
+	w.Write(archive)
+}
+
+// @Summary Delete Account
+// @Description Schedules the account deletion. Until the returned time it can be cancelled, then the account
+// @Description is deleted with everything it owns and the user is only referred to by id in audit records.
+// @Security BearerAuth
+// @Tags Privacy
+// @ID delete-account
+// @Accept json
+// @Produce json
+// @Param input body domain.DeleteAccountInput true "The password"
+// @Success 202 {object} domain.AccountDeletion
+// @Failure 400 {object} Problem
+// @Failure 413 {object} Problem
+// @Failure 415 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 429 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /auth/home/deletion [post]
+func (h *Handler) deleteAccount(w http.ResponseWriter, r *http.Request) {
+	userId := r.Context().Value(ctxUserID).(int)
+
+	input, err := decode[domain.DeleteAccountInput](w, r)
+	if err != nil {
+		writeError(w, r, "deleteAccount", err)
+		return
+	}
+
+	deletion, err := h.privacyService.ScheduleDeletion(r.Context(), userId, input)
+	if err != nil {
+		writeError(w, r, "deleteAccount", err)
+		return
+	}
+
+	render(w, r, "deleteAccount", http.StatusAccepted, deletion)
+}
+
+// @Summary Cancel Account Deletion
+// @Security BearerAuth
+// @Tags Privacy
+// @ID cancel-account-deletion
+// @Produce json
+// @Success 200
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 409 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /auth/home/deletion [delete]
+func (h *Handler) cancelAccountDeletion(w http.ResponseWriter, r *http.Request) {
+	userId := r.Context().Value(ctxUserID).(int)
+
+	if err := h.privacyService.CancelDeletion(r.Context(), userId); err != nil {
+		writeError(w, r, "cancelAccountDeletion", err)
+		return
+	}
+
+	render(w, r, "cancelAccountDeletion", http.StatusOK, statusResponse{Status: "account deletion cancelled"})
+}
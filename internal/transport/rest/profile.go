@@ -0,0 +1,194 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/xopxe23/news-server/internal/domain"
+)
+
+// @Summary Get Profile
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Tags Profile
+// @ID get-profile
+// @Produce json
+// @Success 200 {object} domain.Profile
+// @Failure 401 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /auth/home/profile [get]
+func (h *Handler) getProfile(w http.ResponseWriter, r *http.Request) {
+	userId := r.Context().Value(ctxUserID).(int)
+
+	profile, err := h.usersService.GetProfile(r.Context(), userId)
+	if err != nil {
+		writeError(w, r, "getProfile", err)
+		return
+	}
+
+	render(w, r, "getProfile", http.StatusOK, profile)
+}
+
+// @Summary Update Profile
+// @Description Changes the name and the avatar. An empty avatar_url removes the avatar.
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Tags Profile
+// @ID update-profile
+// @Accept json
+// @Produce json
+// @Param input body domain.UpdateProfileInput true "Profile fields to change"
+// @Success 200 {object} domain.Profile
+// @Failure 400 {object} Problem
+// @Failure 413 {object} Problem
+// @Failure 415 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /auth/home/profile [patch]
+func (h *Handler) updateProfile(w http.ResponseWriter, r *http.Request) {
+	userId := r.Context().Value(ctxUserID).(int)
+
+	input, err := decode[domain.UpdateProfileInput](w, r)
+	if err != nil {
+		writeError(w, r, "updateProfile", err)
+		return
+	}
+
+	profile, err := h.usersService.UpdateProfile(r.Context(), userId, input)
+	if err != nil {
+		writeError(w, r, "updateProfile", err)
+		return
+	}
+
+	render(w, r, "updateProfile", http.StatusOK, profile)
+}
+
+// @Summary Change Password
+// @Description Ends all sessions of the user and returns new tokens for the current one.
+// @Security BearerAuth
+// @Tags Profile
+// @ID change-password
+// @Accept json
+// @Produce json
+// @Param input body domain.ChangePasswordInput true "Current and new password"
+// @Success 200 {object} tokenResponse
+// @Failure 400 {object} Problem
+// @Failure 413 {object} Problem
+// @Failure 415 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 429 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /auth/home/password [post]
+func (h *Handler) changePassword(w http.ResponseWriter, r *http.Request) {
+	userId := r.Context().Value(ctxUserID).(int)
+
+	input, err := decode[domain.ChangePasswordInput](w, r)
+	if err != nil {
+		writeError(w, r, "changePassword", err)
+		return
+	}
+
+	accessToken, refreshToken, err := h.usersService.ChangePassword(r.Context(), userId, input)
+	if err != nil {
+		writeError(w, r, "changePassword", err)
+		return
+	}
+
+	setRefreshCookie(w, refreshToken)
+	render(w, r, "changePassword", http.StatusOK, tokenResponse{Token: accessToken})
+}
+
+// @Summary Change Email
+// @Description Sends a confirmation link to the new email. The email changes once it is confirmed at /auth/email/confirm.
+// @Security BearerAuth
+// @Tags Profile
+// @ID change-email
+// @Accept json
+// @Produce json
+// @Param input body domain.ChangeEmailInput true "New email and the password"
+// @Success 202
+// @Failure 400 {object} Problem
+// @Failure 413 {object} Problem
+// @Failure 415 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 409 {object} Problem
+// @Failure 429 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /auth/home/email [post]
+func (h *Handler) changeEmail(w http.ResponseWriter, r *http.Request) {
+	userId := r.Context().Value(ctxUserID).(int)
+
+	input, err := decode[domain.ChangeEmailInput](w, r)
+	if err != nil {
+		writeError(w, r, "changeEmail", err)
+		return
+	}
+
+	if err := h.usersService.RequestEmailChange(r.Context(), userId, input); err != nil {
+		writeError(w, r, "changeEmail", err)
+		return
+	}
+
+	render(w, r, "changeEmail", http.StatusAccepted, statusResponse{Status: "confirmation sent to the new email"})
+}
+
+// @Summary Confirm Email
+// @Tags Profile
+// @ID confirm-email
+// @Accept json
+// @Produce json
+// @Param input body domain.ConfirmEmailInput true "Token from the confirmation link"
+// @Success 200
+// @Failure 400 {object} Problem
+// @Failure 413 {object} Problem
+// @Failure 415 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 409 {object} Problem
+// @Failure 429 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /auth/email/confirm [post]
+func (h *Handler) confirmEmail(w http.ResponseWriter, r *http.Request) {
+	input, err := decode[domain.ConfirmEmailInput](w, r)
+	if err != nil {
+		writeError(w, r, "confirmEmail", err)
+		return
+	}
+
+	if err := h.usersService.ConfirmEmailChange(r.Context(), input); err != nil {
+		writeError(w, r, "confirmEmail", err)
+		return
+	}
+
+	render(w, r, "confirmEmail", http.StatusOK, statusResponse{Status: "email changed"})
+}
+
+// @Summary Reset Password
+// @Description Sets a new password with the token from the reset link and ends all sessions of the user.
+// @Tags Profile
+// @ID reset-password
+// @Accept json
+// @Produce json
+// @Param input body domain.ResetPasswordInput true "Token from the reset link and the new password"
+// @Success 200
+// @Failure 400 {object} Problem
+// @Failure 413 {object} Problem
+// @Failure 415 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 429 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /auth/password/reset [post]
+func (h *Handler) resetPassword(w http.ResponseWriter, r *http.Request) {
+	input, err := decode[domain.ResetPasswordInput](w, r)
+	if err != nil {
+		writeError(w, r, "resetPassword", err)
+		return
+	}
+
+	if err := h.usersService.ResetPassword(r.Context(), input); err != nil {
+		writeError(w, r, "resetPassword", err)
+		return
+	}
+
+	render(w, r, "resetPassword", http.StatusOK, statusResponse{Status: "password changed"})
+}
@@ -0,0 +1,69 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/xopxe23/news-server/internal/domain"
+)
+
+// RateLimitStore keeps token buckets of clients.
+type RateLimitStore interface {
+	Take(ctx context.Context, key string, limit domain.RateLimit, now time.Time) (domain.RateLimitResult, error)
+}
+
+type RateLimitConfig struct {
+	// Store is nil when rate limiting is disabled.
+	Store RateLimitStore
+	// Policies by name. Routes using a policy which isn't configured are not limited.
+	Policies map[string]domain.RateLimit
+}
+
+// rateLimitMiddleware limits requests of each user, or each IP for anonymous requests, by the named policy.
+// Failures of the store let requests through, so the API stays up when the limits can't be checked.
+func (h *Handler) rateLimitMiddleware(policy string) func(http.Handler) http.Handler {
+	limit, ok := h.cfg.RateLimit.Policies[policy]
+	if h.cfg.RateLimit.Store == nil || !ok {
+		return func(next http.Handler) http.Handler {
+			return next
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := fmt.Sprintf("%s:%s", policy, h.clientKey(r))
+			result, err := h.cfg.RateLimit.Store.Take(r.Context(), key, limit, time.Now())
+			if err != nil {
+				logError("rateLimitMiddleware", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+
+			if !result.Allowed {
+				writeError(w, r, "rateLimitMiddleware", domain.TooManyRequests("rate limit exceeded, retry later", result.RetryAfter))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// clientKey identifies the client by user id if the request is authenticated and by IP otherwise.
+func (h *Handler) clientKey(r *http.Request) string {
+	if userId, ok := r.Context().Value(ctxUserID).(int); ok {
+		return fmt.Sprintf("user:%d", userId)
+	}
+	return "ip:" + domain.ClientIPFrom(r.Context())
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
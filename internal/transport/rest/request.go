@@ -0,0 +1,119 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/xopxe23/news-server/internal/domain"
+)
+
+const maxBodySize = 1 << 20
+
+var errNotAcceptable = errors.New("not acceptable")
+
+type validatable interface {
+	Validate() error
+}
+
+// decode reads the request body into a new T and validates it if T knows how.
+// The body is limited to maxBodySize, unknown fields and trailing data are rejected.
+func decode[T any](w http.ResponseWriter, r *http.Request) (T, error) {
+	var input T
+
+	c, err := requestCodec(r)
+	if err != nil {
+		return input, err
+	}
+
+	dec := c.newDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err := dec.Decode(&input); err != nil {
+		return input, decodeError(err)
+	}
+	if err := dec.Decode(&struct{}{}); err != io.EOF {
+		return input, domain.InvalidInput("body must contain a single value", nil)
+	}
+
+	if v, ok := any(&input).(validatable); ok {
+		if err := v.Validate(); err != nil {
+			return input, err
+		}
+	}
+	return input, nil
+}
+
+// requestCodec picks the codec matching the Content-Type of the request among the ones the route supports.
+func requestCodec(r *http.Request) (codec, error) {
+	header := r.Header.Get("Content-Type")
+	if header == "" {
+		return codec{}, domain.NewError(domain.ErrUnsupportedMediaType, "Content-Type header is required", nil)
+	}
+
+	mediaType, _, err := mime.ParseMediaType(header)
+	if err == nil {
+		for _, c := range codecsFrom(r.Context()) {
+			for _, t := range c.mediaTypes {
+				if t == mediaType {
+					return c, nil
+				}
+			}
+		}
+	}
+	return codec{}, domain.NewError(domain.ErrUnsupportedMediaType, fmt.Sprintf("unsupported Content-Type %q", header), err)
+}
+
+// decodeError explains to the client what is wrong with the body it sent.
+func decodeError(err error) error {
+	var (
+		maxBytesErr   *http.MaxBytesError
+		syntaxErr     *json.SyntaxError
+		typeErr       *json.UnmarshalTypeError
+		xmlElementErr *unknownElementError
+	)
+
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return domain.NewError(domain.ErrTooLarge, fmt.Sprintf("body must not be larger than %d bytes", maxBytesErr.Limit), err)
+	case errors.Is(err, io.EOF):
+		return domain.InvalidInput("body must not be empty", err)
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return domain.InvalidInput("body is truncated", err)
+	case errors.As(err, &syntaxErr):
+		return domain.InvalidInput(fmt.Sprintf("body contains malformed JSON at offset %d", syntaxErr.Offset), err)
+	case errors.As(err, &typeErr):
+		return fieldError(typeErr.Field, fmt.Sprintf("must be of type %s", typeErr.Type), err)
+	case errors.As(err, &xmlElementErr):
+		return fieldError(xmlElementErr.name, "is not allowed", err)
+	}
+
+	if field, ok := unknownField(err); ok {
+		return fieldError(field, "is not allowed", err)
+	}
+	return badRequest(err)
+}
+
+const unknownFieldMessage = "unknown field "
+
+// unknownField returns the field the JSON or msgpack decoder didn't expect. Neither has an error type for
+// unknown fields, both only say `unknown field "name"` in the message, so this is the one place matching it.
+func unknownField(err error) (string, bool) {
+	message := err.Error()
+	i := strings.Index(message, unknownFieldMessage)
+	if i < 0 {
+		return "", false
+	}
+	return strings.Trim(message[i+len(unknownFieldMessage):], `"`), true
+}
+
+func fieldError(field, message string, cause error) error {
+	return &domain.Error{
+		Kind:    domain.ErrValidation,
+		Message: "request has invalid fields",
+		Fields:  []domain.FieldError{{Field: field, Message: message}},
+		Err:     cause,
+	}
+}
@@ -0,0 +1,110 @@
+package rest
+
+import (
+	"encoding/xml"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type statusResponse struct {
+	XMLName xml.Name `json:"-" xml:"response"`
+	Status  string   `json:"status" xml:"status"`
+}
+
+type tokenResponse struct {
+	XMLName xml.Name `json:"-" xml:"response"`
+	Token   string   `json:"token" xml:"token"`
+}
+
+type dataResponse struct {
+	XMLName xml.Name    `json:"-" xml:"response"`
+	Data    interface{} `json:"data"`
+}
+
+// render writes v with the given status in the format the client accepts best.
+// Routes which don't declare their formats fall back to the first one for anything they can't produce.
+func render(w http.ResponseWriter, r *http.Request, handler string, status int, v interface{}) {
+	codecs := codecsFrom(r.Context())
+	c, ok := negotiate(r.Header.Get("Accept"), codecs)
+	if !ok {
+		c = codecs[0]
+	}
+
+	response, err := c.marshal(v)
+	if err != nil {
+		writeError(w, r, handler, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", c.contentType())
+	w.Header().Add("Vary", "Accept")
+	w.WriteHeader(status)
+	w.Write(response)
+}
+
+// negotiate picks the codec for the Accept header. A missing header accepts the first codec.
+func negotiate(accept string, codecs []codec) (codec, bool) {
+	if accept == "" {
+		return codecs[0], true
+	}
+
+	for _, mediaRange := range parseAccept(accept) {
+		for _, c := range codecs {
+			for _, mediaType := range c.mediaTypes {
+				if matchMediaRange(mediaRange, mediaType) {
+					return c, true
+				}
+			}
+		}
+	}
+	return codec{}, false
+}
+
+func matchMediaRange(mediaRange, contentType string) bool {
+	if mediaRange == "*/*" || mediaRange == contentType {
+		return true
+	}
+	if strings.HasSuffix(mediaRange, "/*") {
+		return strings.HasPrefix(contentType, strings.TrimSuffix(mediaRange, "*"))
+	}
+	return false
+}
+
+// parseAccept returns media ranges of the Accept header ordered by preference. Ranges with q=0 are dropped.
+func parseAccept(accept string) []string {
+	type weighted struct {
+		mediaRange string
+		q          float64
+	}
+
+	var ranges []weighted
+	for _, part := range strings.Split(accept, ",") {
+		mediaRange, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(value, 64); err != nil {
+				continue
+			}
+		}
+		if q > 0 {
+			ranges = append(ranges, weighted{mediaRange, q})
+		}
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].q > ranges[j].q
+	})
+
+	result := make([]string, 0, len(ranges))
+	for _, r := range ranges {
+		result = append(result, r.mediaRange)
+	}
+	return result
+}
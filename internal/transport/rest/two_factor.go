@@ -0,0 +1,134 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/xopxe23/news-server/internal/domain"
+)
+
+type challengeResponse struct {
+	ChallengeToken string `json:"challenge_token"`
+}
+
+// @Summary Verify Two-Factor Code
+// @Description Exchanges the challenge token from sign-in and a code from the authenticator app,
+// @Description or a recovery code, for tokens.
+// @Tags Users auth
+// @ID verify-mfa
+// @Accept json
+// @Produce json
+// @Param input body domain.MFAVerifyInput true "Challenge and code"
+// @Success 200 {object} tokenResponse
+// @Failure 400 {object} Problem
+// @Failure 413 {object} Problem
+// @Failure 415 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 429 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /auth/2fa/verify [post]
+func (h *Handler) verifyMFA(w http.ResponseWriter, r *http.Request) {
+	input, err := decode[domain.MFAVerifyInput](w, r)
+	if err != nil {
+		writeError(w, r, "verifyMFA", err)
+		return
+	}
+
+	accessToken, refreshToken, err := h.usersService.VerifyMFA(r.Context(), input)
+	if err != nil {
+		writeError(w, r, "verifyMFA", err)
+		return
+	}
+
+	setRefreshCookie(w, refreshToken)
+	render(w, r, "verifyMFA", http.StatusOK, tokenResponse{Token: accessToken})
+}
+
+// @Summary Start Two-Factor Enrollment
+// @Description Returns a new TOTP secret and its otpauth URI to show as a QR code.
+// @Description Two-factor authentication is enabled once a code is confirmed.
+// @Security BearerAuth
+// @Tags Users auth
+// @ID enroll-totp
+// @Produce json
+// @Success 200 {object} domain.TOTPEnrollment
+// @Failure 401 {object} Problem
+// @Failure 409 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /auth/home/2fa/enroll [post]
+func (h *Handler) enrollTOTP(w http.ResponseWriter, r *http.Request) {
+	userId := r.Context().Value(ctxUserID).(int)
+
+	enrollment, err := h.usersService.EnrollTOTP(r.Context(), userId)
+	if err != nil {
+		writeError(w, r, "enrollTOTP", err)
+		return
+	}
+
+	render(w, r, "enrollTOTP", http.StatusOK, enrollment)
+}
+
+// @Summary Confirm Two-Factor Enrollment
+// @Description Enables two-factor authentication and returns recovery codes, which are shown only once.
+// @Security BearerAuth
+// @Tags Users auth
+// @ID confirm-totp
+// @Accept json
+// @Produce json
+// @Param input body domain.TOTPCodeInput true "Code from the authenticator app"
+// @Success 200 {object} domain.RecoveryCodes
+// @Failure 400 {object} Problem
+// @Failure 413 {object} Problem
+// @Failure 415 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 409 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /auth/home/2fa/confirm [post]
+func (h *Handler) confirmTOTP(w http.ResponseWriter, r *http.Request) {
+	userId := r.Context().Value(ctxUserID).(int)
+
+	input, err := decode[domain.TOTPCodeInput](w, r)
+	if err != nil {
+		writeError(w, r, "confirmTOTP", err)
+		return
+	}
+
+	codes, err := h.usersService.ConfirmTOTP(r.Context(), userId, input)
+	if err != nil {
+		writeError(w, r, "confirmTOTP", err)
+		return
+	}
+
+	render(w, r, "confirmTOTP", http.StatusOK, codes)
+}
+
+// @Summary Disable Two-Factor Authentication
+// @Security BearerAuth
+// @Tags Users auth
+// @ID disable-totp
+// @Accept json
+// @Produce json
+// @Param input body domain.TOTPCodeInput true "Code from the authenticator app or a recovery code"
+// @Success 200
+// @Failure 400 {object} Problem
+// @Failure 413 {object} Problem
+// @Failure 415 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 409 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /auth/home/2fa/disable [post]
+func (h *Handler) disableTOTP(w http.ResponseWriter, r *http.Request) {
+	userId := r.Context().Value(ctxUserID).(int)
+
+	input, err := decode[domain.TOTPCodeInput](w, r)
+	if err != nil {
+		writeError(w, r, "disableTOTP", err)
+		return
+	}
+
+	if err := h.usersService.DisableTOTP(r.Context(), userId, input); err != nil {
+		writeError(w, r, "disableTOTP", err)
+		return
+	}
+
+	render(w, r, "disableTOTP", http.StatusOK, statusResponse{Status: "two-factor authentication disabled"})
+}
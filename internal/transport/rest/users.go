@@ -2,21 +2,48 @@ package rest
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
-	"io"
 	"net/http"
 
-	"github.com/sirupsen/logrus"
 	"github.com/xopxe23/news-server/internal/domain"
 )
 
+const refreshCookie = "refresh-token"
+
 type UsersService interface {
 	SignUp(ctx context.Context, input domain.SignUpInput) error
-	SignIn(ctx context.Context, input domain.SignInInput) (string, string, error)
+	SignIn(ctx context.Context, input domain.SignInInput) (domain.SignInResult, error)
+	VerifyMFA(ctx context.Context, input domain.MFAVerifyInput) (string, string, error)
 	RefreshTokens(ctx context.Context, token string) (string, string, error)
 	ParseToken(ctx context.Context, token string) (int, error)
-	GetBookmarks(ctx context.Context, userId int) ([]domain.ArticleOutput, error)
+	GetBookmarks(ctx context.Context, userId int, query domain.ArticleQuery) ([]domain.ArticleOutput, error)
+	IsAdmin(ctx context.Context, userId int) (bool, error)
+	SetRole(ctx context.Context, userId int, input domain.SetRoleInput) error
+	UnlockUser(ctx context.Context, userId int) error
+	EnrollTOTP(ctx context.Context, userId int) (domain.TOTPEnrollment, error)
+	ConfirmTOTP(ctx context.Context, userId int, input domain.TOTPCodeInput) (domain.RecoveryCodes, error)
+	DisableTOTP(ctx context.Context, userId int, input domain.TOTPCodeInput) error
+	CreateAPIKey(ctx context.Context, userId int, input domain.CreateAPIKeyInput) (domain.CreatedAPIKey, error)
+	GetAPIKeys(ctx context.Context, userId int) ([]domain.APIKey, error)
+	RevokeAPIKey(ctx context.Context, userId, keyId int) error
+	AuthenticateAPIKey(ctx context.Context, raw string) (domain.APIKey, error)
+	StartOIDC(ctx context.Context) (domain.OIDCLogin, error)
+	FinishOIDC(ctx context.Context, callback domain.OIDCCallback) (domain.SignInResult, error)
+	GetProfile(ctx context.Context, userId int) (domain.Profile, error)
+	UpdateProfile(ctx context.Context, userId int, input domain.UpdateProfileInput) (domain.Profile, error)
+	ChangePassword(ctx context.Context, userId int, input domain.ChangePasswordInput) (string, string, error)
+	RequestEmailChange(ctx context.Context, userId int, input domain.ChangeEmailInput) error
+	ConfirmEmailChange(ctx context.Context, input domain.ConfirmEmailInput) error
+	ListUsers(ctx context.Context, filter domain.UserFilter) (domain.UsersPage, error)
+	GetUserDetails(ctx context.Context, userId int) (domain.AdminUserDetails, error)
+	DisableUser(ctx context.Context, userId int) error
+	EnableUser(ctx context.Context, userId int) error
+	LogoutUser(ctx context.Context, userId int) error
+	RequestPasswordReset(ctx context.Context, userId int) error
+	ResetPassword(ctx context.Context, input domain.ResetPasswordInput) error
+	CheckActive(ctx context.Context, userId int) error
+	ParseAccessToken(ctx context.Context, token string) (domain.Principal, error)
+	Impersonate(ctx context.Context, adminId, userId int) (domain.ImpersonationToken, error)
+	StopImpersonation(ctx context.Context, principal domain.Principal) error
 }
 
 // @Summary Sign Up
@@ -25,99 +52,71 @@ type UsersService interface {
 // @Accept json
 // @Produce json
 // @Param input body domain.SignUpInput true "Sign up input"
-// @Success 200
-// @Failure 400
+// @Success 201
+// @Failure 400 {object} Problem
+// @Failure 413 {object} Problem
+// @Failure 415 {object} Problem
+// @Failure 409 {object} Problem
+// @Failure 429 {object} Problem
+// @Failure 500 {object} Problem
 // @Router /auth/sign-up [post]
 func (h *Handler) signUp(w http.ResponseWriter, r *http.Request) {
-	reqBytes, err := io.ReadAll(r.Body)
+	input, err := decode[domain.SignUpInput](w, r)
 	if err != nil {
-		logError("signUp", err)
-		w.WriteHeader(http.StatusBadRequest)
-		return
-	}
-
-	var input domain.SignUpInput
-	if err := json.Unmarshal(reqBytes, &input); err != nil {
-		logError("signUp", err)
-		w.WriteHeader(http.StatusBadRequest)
-		return
-	}
-
-	if err := input.Validate(); err != nil {
-		logError("signUp", err)
-		w.WriteHeader(http.StatusBadRequest)
+		writeError(w, r, "signUp", err)
 		return
 	}
 
 	if err := h.usersService.SignUp(r.Context(), input); err != nil {
-		logError("signUp", err)
-		w.WriteHeader(http.StatusBadRequest)
-		return
-	}
-
-	response, err := json.Marshal(map[string]string{
-		"status": "success",
-	})
-	if err != nil {
-		logError("signUp", err)
-		w.WriteHeader(http.StatusInternalServerError)
+		writeError(w, r, "signUp", err)
 		return
 	}
 
-	w.Header().Add("Content-Type", "application/json")
-	w.Write(response)
+	render(w, r, "signUp", http.StatusCreated, statusResponse{Status: "user registered"})
 }
 
 // @Summary Sign In
+// @Description Users with two-factor authentication get a challenge token instead of the access token,
+// @Description it is exchanged at /auth/2fa/verify.
 // @Tags Users auth
 // @ID sign-in
 // @Accept json
 // @Produce json
 // @Param input body domain.SignInInput true "Sign in input"
-// @Success 200 {string} string
-// @Failure 400
-// @Failure 500
+// @Success 200 {object} tokenResponse
+// @Success 202 {object} challengeResponse
+// @Failure 400 {object} Problem
+// @Failure 413 {object} Problem
+// @Failure 415 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 429 {object} Problem
+// @Failure 500 {object} Problem
 // @Router /auth/sign-in [post]
 func (h *Handler) signIn(w http.ResponseWriter, r *http.Request) {
-	reqBytes, err := io.ReadAll(r.Body)
+	input, err := decode[domain.SignInInput](w, r)
 	if err != nil {
-		logError("signIn", err)
-		w.WriteHeader(http.StatusBadRequest)
-		return
-	}
-
-	var input domain.SignInInput
-	if err := json.Unmarshal(reqBytes, &input); err != nil {
-		logError("signIn", err)
-		w.WriteHeader(http.StatusBadRequest)
+		writeError(w, r, "signIn", err)
 		return
 	}
 
-	if err := input.Validate(); err != nil {
-		logError("sigIn", err)
-		w.WriteHeader(http.StatusBadRequest)
-		return
-	}
-
-	accessToken, refreshToken, err := h.usersService.SignIn(r.Context(), input)
+	result, err := h.usersService.SignIn(r.Context(), input)
 	if err != nil {
-		logError("signIn", err)
-		w.WriteHeader(http.StatusInternalServerError)
+		writeError(w, r, "signIn", err)
 		return
 	}
 
-	response, err := json.Marshal(map[string]string{
-		"token": accessToken,
-	})
-	if err != nil {
-		logError("signIn", err)
-		w.WriteHeader(http.StatusInternalServerError)
+	renderSignIn(w, r, "signIn", result)
+}
+
+// renderSignIn answers with the access token, or with the challenge token if a second factor is required.
+func renderSignIn(w http.ResponseWriter, r *http.Request, handler string, result domain.SignInResult) {
+	if result.ChallengeToken != "" {
+		render(w, r, handler, http.StatusAccepted, challengeResponse{ChallengeToken: result.ChallengeToken})
 		return
 	}
 
-	w.Header().Add("Set-Cookie", fmt.Sprintf("refresh-token=%s; HttpOnly", refreshToken))
-	w.Header().Add("Content-Type", "application/json")
-	w.Write(response)
+	setRefreshCookie(w, result.RefreshToken)
+	render(w, r, handler, http.StatusOK, tokenResponse{Token: result.AccessToken})
 }
 
 // @Summary Refresh
@@ -126,65 +125,62 @@ func (h *Handler) signIn(w http.ResponseWriter, r *http.Request) {
 // @Accept json
 // @Produce json
 // @Success 200 {string} string
-// @Failure 500
+// @Failure 401 {object} Problem
+// @Failure 429 {object} Problem
+// @Failure 500 {object} Problem
 // @Router /auth/refresh [get]
 func (h *Handler) refresh(w http.ResponseWriter, r *http.Request) {
-	cookie, err := r.Cookie("refresh-token")
+	cookie, err := r.Cookie(refreshCookie)
 	if err != nil {
-		logError("refresh", err)
-		w.WriteHeader(http.StatusInternalServerError)
+		writeError(w, r, "refresh", domain.NewError(domain.ErrUnauthorized, "missing refresh token", err))
 		return
 	}
 
-	logrus.Infof("%s", cookie.Value)
-
 	accessToken, refreshToken, err := h.usersService.RefreshTokens(r.Context(), cookie.Value)
 	if err != nil {
-		logError("refresh", err)
-		w.WriteHeader(http.StatusInternalServerError)
+		writeError(w, r, "refresh", err)
 		return
 	}
 
-	response, err := json.Marshal(map[string]string{
-		"token": accessToken,
-	})
-	if err != nil {
-		logError("refresh", err)
-		w.WriteHeader(http.StatusInternalServerError)
-	}
-
-	w.Header().Add("Set-Cookie", fmt.Sprintf("refresh-token=%s; HttpOnly", refreshToken))
-	w.Header().Add("Content-Type", "application/json")
-	w.Write(response)
+	setRefreshCookie(w, refreshToken)
+	render(w, r, "refresh", http.StatusOK, tokenResponse{Token: accessToken})
 }
 
 // @Summary Get Bookmarks
 // @Security BearerAuth
+// @Security APIKeyAuth
 // @Tags Users auth
 // @ID get-bookmarks
 // @Accept json
 // @Produce json
+// @Param fields query string false "Comma separated fields to return, e.g. id,title,excerpt, the id is always returned. summary returns all but content and content_html"
+// @Param include query string false "Related resources to embed" Enums(author)
 // @Success 200 {array} domain.Article
-// @Failure 400
-// @Failure 500
+// @Failure 400 {object} Problem
+// @Failure 500 {object} Problem
 // @Router /auth/home/bookmarks [get]
 func (h *Handler) getBookmarks(w http.ResponseWriter, r *http.Request) {
 	userId := r.Context().Value(ctxUserID).(int)
-	articles, err := h.usersService.GetBookmarks(r.Context(), userId)
+	query, err := articleQueryFrom(r.URL.Query())
 	if err != nil {
-		logError("getBookmarks", err)
-		w.WriteHeader(http.StatusInternalServerError)
+		writeError(w, r, "getBookmarks", err)
 		return
 	}
 
-	response, err := json.Marshal(articles)
+	articles, err := h.usersService.GetBookmarks(r.Context(), userId, query)
 	if err != nil {
-		logError("getBookmarks", err)
-		w.WriteHeader(http.StatusInternalServerError)
+		writeError(w, r, "getBookmarks", err)
 		return
 	}
 
-	w.Header().Add("Content-Type", "application/json")
-	w.Write(response)
+	render(w, r, "getBookmarks", http.StatusOK, sparseArticles(articles, query))
+}
+
+func setRefreshCookie(w http.ResponseWriter, token string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     refreshCookie,
+		Value:    token,
+		HttpOnly: true,
+	})
 }
  
\ No newline at end of file
@@ -0,0 +1,245 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/xopxe23/news-server/internal/domain"
+)
+
+type WebhooksService interface {
+	CreateWebhook(ctx context.Context, input domain.WebhookInput) (int, error)
+	GetAllWebhooks(ctx context.Context) ([]domain.Webhook, error)
+	GetWebhookById(ctx context.Context, id int) (domain.Webhook, error)
+	UpdateWebhook(ctx context.Context, id int, input domain.UpdateWebhookInput) error
+	DeleteWebhook(ctx context.Context, id int) error
+	GetDeliveries(ctx context.Context, webhookId int) ([]domain.WebhookDelivery, error)
+	Redeliver(ctx context.Context, webhookId, deliveryId int) error
+}
+
+// @Summary Get All Webhooks
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Tags Webhooks
+// @ID get-all-webhooks
+// @Accept json
+// @Produce json
+// @Success 200 {array} domain.Webhook
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /webhooks [get]
+func (h *Handler) getAllWebhooks(w http.ResponseWriter, r *http.Request) {
+	webhooks, err := h.webhooksService.GetAllWebhooks(r.Context())
+	if err != nil {
+		writeError(w, r, "getAllWebhooks", err)
+		return
+	}
+
+	render(w, r, "getAllWebhooks", http.StatusOK, dataResponse{Data: webhooks})
+}
+
+// @Summary Create Webhook
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Tags Webhooks
+// @ID create-webhook
+// @Accept json
+// @Produce json
+// @Param input body domain.WebhookInput true "Webhook input"
+// @Success 201 {integer} integer
+// @Failure 400 {object} Problem
+// @Failure 413 {object} Problem
+// @Failure 415 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /webhooks [post]
+func (h *Handler) createWebhook(w http.ResponseWriter, r *http.Request) {
+	input, err := decode[domain.WebhookInput](w, r)
+	if err != nil {
+		writeError(w, r, "createWebhook", err)
+		return
+	}
+
+	webhookId, err := h.webhooksService.CreateWebhook(r.Context(), input)
+	if err != nil {
+		writeError(w, r, "createWebhook", err)
+		return
+	}
+
+	render(w, r, "createWebhook", http.StatusCreated, map[string]int{
+		"webhook id": webhookId,
+	})
+}
+
+// @Summary Get Webhook By Id
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Tags Webhooks
+// @ID get-webhook-by-id
+// @Accept json
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Success 200 {object} domain.Webhook
+// @Failure 400 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /webhooks/{id} [get]
+func (h *Handler) getWebhookById(w http.ResponseWriter, r *http.Request) {
+	webhookId, err := getIdFromRequest(r)
+	if err != nil {
+		writeError(w, r, "getWebhookById", badRequest(err))
+		return
+	}
+
+	webhook, err := h.webhooksService.GetWebhookById(r.Context(), webhookId)
+	if err != nil {
+		writeError(w, r, "getWebhookById", err)
+		return
+	}
+
+	render(w, r, "getWebhookById", http.StatusOK, webhook)
+}
+
+// @Summary Update Webhook
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Tags Webhooks
+// @ID update-webhook
+// @Accept json
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Param input body domain.UpdateWebhookInput true "Update Webhook input"
+// @Success 200
+// @Failure 400 {object} Problem
+// @Failure 413 {object} Problem
+// @Failure 415 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /webhooks/{id} [put]
+func (h *Handler) updateWebhook(w http.ResponseWriter, r *http.Request) {
+	webhookId, err := getIdFromRequest(r)
+	if err != nil {
+		writeError(w, r, "updateWebhook", badRequest(err))
+		return
+	}
+
+	input, err := decode[domain.UpdateWebhookInput](w, r)
+	if err != nil {
+		writeError(w, r, "updateWebhook", err)
+		return
+	}
+
+	if err := h.webhooksService.UpdateWebhook(r.Context(), webhookId, input); err != nil {
+		writeError(w, r, "updateWebhook", err)
+		return
+	}
+
+	render(w, r, "updateWebhook", http.StatusOK, statusResponse{Status: fmt.Sprintf("webhook №%d updated", webhookId)})
+}
+
+// @Summary Delete Webhook
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Tags Webhooks
+// @ID delete-webhook
+// @Accept json
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Success 200
+// @Failure 400 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /webhooks/{id} [delete]
+func (h *Handler) deleteWebhook(w http.ResponseWriter, r *http.Request) {
+	webhookId, err := getIdFromRequest(r)
+	if err != nil {
+		writeError(w, r, "deleteWebhook", badRequest(err))
+		return
+	}
+
+	if err := h.webhooksService.DeleteWebhook(r.Context(), webhookId); err != nil {
+		writeError(w, r, "deleteWebhook", err)
+		return
+	}
+
+	render(w, r, "deleteWebhook", http.StatusOK, statusResponse{Status: fmt.Sprintf("webhook №%d deleted", webhookId)})
+}
+
+// @Summary Get Webhook Deliveries
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Tags Webhooks
+// @ID get-webhook-deliveries
+// @Accept json
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Success 200 {array} domain.WebhookDelivery
+// @Failure 400 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /webhooks/{id}/deliveries [get]
+func (h *Handler) getWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	webhookId, err := getIdFromRequest(r)
+	if err != nil {
+		writeError(w, r, "getWebhookDeliveries", badRequest(err))
+		return
+	}
+
+	deliveries, err := h.webhooksService.GetDeliveries(r.Context(), webhookId)
+	if err != nil {
+		writeError(w, r, "getWebhookDeliveries", err)
+		return
+	}
+
+	render(w, r, "getWebhookDeliveries", http.StatusOK, dataResponse{Data: deliveries})
+}
+
+// @Summary Redeliver Webhook Delivery
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Tags Webhooks
+// @ID redeliver-webhook-delivery
+// @Accept json
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Param delivery path int true "Delivery ID"
+// @Success 200
+// @Failure 400 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /webhooks/{id}/deliveries/{delivery}/redeliver [post]
+func (h *Handler) redeliverWebhook(w http.ResponseWriter, r *http.Request) {
+	webhookId, err := getIdFromRequest(r)
+	if err != nil {
+		writeError(w, r, "redeliverWebhook", badRequest(err))
+		return
+	}
+
+	deliveryId, err := strconv.Atoi(mux.Vars(r)["delivery"])
+	if err != nil {
+		writeError(w, r, "redeliverWebhook", badRequest(err))
+		return
+	}
+
+	if err := h.webhooksService.Redeliver(r.Context(), webhookId, deliveryId); err != nil {
+		writeError(w, r, "redeliverWebhook", err)
+		return
+	}
+
+	render(w, r, "redeliverWebhook", http.StatusOK, statusResponse{Status: fmt.Sprintf("delivery №%d queued", deliveryId)})
+}
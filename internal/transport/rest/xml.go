@@ -0,0 +1,188 @@
+package rest
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// unknownElementError is an element of the body the target value has no field for.
+type unknownElementError struct {
+	name string
+}
+
+func (e *unknownElementError) Error() string {
+	return fmt.Sprintf("xml: unknown element <%s>", e.name)
+}
+
+var (
+	xmlUnmarshalerType  = reflect.TypeOf((*xml.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// xmlDecoder rejects elements the target has no field for, like the JSON and msgpack decoders reject unknown
+// fields, encoding/xml skips them silently. Attributes are still ignored.
+type xmlDecoder struct {
+	dec *xml.Decoder
+}
+
+func newXMLDecoder(r io.Reader) *xmlDecoder {
+	return &xmlDecoder{dec: xml.NewDecoder(r)}
+}
+
+// Decode reads the next element, checks its children against the type of v and decodes it into v.
+func (d *xmlDecoder) Decode(v interface{}) error {
+	tokens, err := d.readElement(reflect.TypeOf(v))
+	if err != nil {
+		return err
+	}
+	return xml.NewTokenDecoder(&tokenReader{tokens: tokens}).Decode(v)
+}
+
+// readElement reads the tokens of the next element, skipping the prolog, comments and whitespace before it.
+func (d *xmlDecoder) readElement(t reflect.Type) ([]xml.Token, error) {
+	var (
+		tokens []xml.Token
+		// types of the open elements, nil for elements whose content isn't checked
+		open []reflect.Type
+	)
+	for {
+		token, err := d.dec.Token()
+		if err == io.EOF && len(open) > 0 {
+			return nil, io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch token := token.(type) {
+		case xml.StartElement:
+			if len(open) == 0 {
+				open = append(open, xmlContentType(t))
+				break
+			}
+			parent := open[len(open)-1]
+			if parent == nil {
+				open = append(open, nil)
+				break
+			}
+			child, ok := xmlChildType(parent, token.Name.Local)
+			if !ok {
+				return nil, &unknownElementError{name: token.Name.Local}
+			}
+			open = append(open, child)
+		case xml.EndElement:
+			open = open[:len(open)-1]
+		case xml.CharData:
+			if len(open) == 0 && len(bytes.TrimSpace(token)) > 0 {
+				return nil, fmt.Errorf("xml: unexpected text %q outside of the root element", token)
+			}
+		}
+
+		if len(tokens) > 0 || len(open) > 0 {
+			tokens = append(tokens, xml.CopyToken(token))
+		}
+		if len(tokens) > 0 && len(open) == 0 {
+			return tokens, nil
+		}
+	}
+}
+
+// xmlContentType returns the struct type whose fields the children of an element decoded into t must match,
+// or nil if any content is accepted. Values which aren't structs, like strings and times, accept no children.
+func xmlContentType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8 {
+		if t.Implements(xmlUnmarshalerType) {
+			return nil
+		}
+		t = t.Elem()
+	}
+	switch {
+	case t.Implements(xmlUnmarshalerType), reflect.PtrTo(t).Implements(xmlUnmarshalerType):
+		return nil
+	case t.Kind() == reflect.Interface:
+		return nil
+	case t.Kind() != reflect.Struct, reflect.PtrTo(t).Implements(textUnmarshalerType):
+		return reflect.TypeOf(struct{}{})
+	}
+	return t
+}
+
+// xmlChildType returns the content type of the child element with the name, see xmlContentType.
+// The field names follow the rules of encoding/xml.
+func xmlChildType(parent reflect.Type, name string) (reflect.Type, bool) {
+	for i := 0; i < parent.NumField(); i++ {
+		field := parent.Field(i)
+		if field.Name == "XMLName" || !field.IsExported() && !field.Anonymous {
+			continue
+		}
+
+		tag := field.Tag.Get("xml")
+		if tag == "-" {
+			continue
+		}
+		fieldName, options, _ := strings.Cut(tag, ",")
+		switch {
+		case hasXMLOption(options, "any"), hasXMLOption(options, "innerxml"):
+			return nil, true
+		case hasXMLOption(options, "attr"), hasXMLOption(options, "chardata"), hasXMLOption(options, "cdata"),
+			hasXMLOption(options, "comment"):
+			continue
+		}
+
+		if field.Anonymous && fieldName == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				if child, ok := xmlChildType(embedded, name); ok {
+					return child, true
+				}
+				continue
+			}
+		}
+
+		if fieldName == "" {
+			fieldName = field.Name
+		}
+		// a>b nests the field, its content isn't checked
+		if first, _, nested := strings.Cut(fieldName, ">"); nested {
+			if first == name {
+				return nil, true
+			}
+			continue
+		}
+		if fieldName == name {
+			return xmlContentType(field.Type), true
+		}
+	}
+	return nil, false
+}
+
+func hasXMLOption(options, option string) bool {
+	for _, o := range strings.Split(options, ",") {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
+
+// tokenReader replays the tokens read by xmlDecoder.
+type tokenReader struct {
+	tokens []xml.Token
+}
+
+func (r *tokenReader) Token() (xml.Token, error) {
+	if len(r.tokens) == 0 {
+		return nil, io.EOF
+	}
+	token := r.tokens[0]
+	r.tokens = r.tokens[1:]
+	return token, nil
+}
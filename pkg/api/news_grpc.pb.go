@@ -0,0 +1,660 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             v4.24.4
+// source: news.proto
+
+package api
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	AuthorsService_CreateAuthor_FullMethodName      = "/news.AuthorsService/CreateAuthor"
+	AuthorsService_GetAllAuthors_FullMethodName     = "/news.AuthorsService/GetAllAuthors"
+	AuthorsService_GetAuthorById_FullMethodName     = "/news.AuthorsService/GetAuthorById"
+	AuthorsService_GetAuthorArticles_FullMethodName = "/news.AuthorsService/GetAuthorArticles"
+	AuthorsService_UpdateAuthor_FullMethodName      = "/news.AuthorsService/UpdateAuthor"
+	AuthorsService_DeleteAuthor_FullMethodName      = "/news.AuthorsService/DeleteAuthor"
+)
+
+// AuthorsServiceClient is the client API for AuthorsService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type AuthorsServiceClient interface {
+	CreateAuthor(ctx context.Context, in *CreateAuthorRequest, opts ...grpc.CallOption) (*CreateResponse, error)
+	GetAllAuthors(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*AuthorsResponse, error)
+	GetAuthorById(ctx context.Context, in *IdRequest, opts ...grpc.CallOption) (*Author, error)
+	GetAuthorArticles(ctx context.Context, in *IdRequest, opts ...grpc.CallOption) (*ArticlesResponse, error)
+	UpdateAuthor(ctx context.Context, in *UpdateAuthorRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	DeleteAuthor(ctx context.Context, in *IdRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+}
+
+type authorsServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAuthorsServiceClient(cc grpc.ClientConnInterface) AuthorsServiceClient {
+	return &authorsServiceClient{cc}
+}
+
+func (c *authorsServiceClient) CreateAuthor(ctx context.Context, in *CreateAuthorRequest, opts ...grpc.CallOption) (*CreateResponse, error) {
+	out := new(CreateResponse)
+	err := c.cc.Invoke(ctx, AuthorsService_CreateAuthor_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authorsServiceClient) GetAllAuthors(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*AuthorsResponse, error) {
+	out := new(AuthorsResponse)
+	err := c.cc.Invoke(ctx, AuthorsService_GetAllAuthors_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authorsServiceClient) GetAuthorById(ctx context.Context, in *IdRequest, opts ...grpc.CallOption) (*Author, error) {
+	out := new(Author)
+	err := c.cc.Invoke(ctx, AuthorsService_GetAuthorById_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authorsServiceClient) GetAuthorArticles(ctx context.Context, in *IdRequest, opts ...grpc.CallOption) (*ArticlesResponse, error) {
+	out := new(ArticlesResponse)
+	err := c.cc.Invoke(ctx, AuthorsService_GetAuthorArticles_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authorsServiceClient) UpdateAuthor(ctx context.Context, in *UpdateAuthorRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, AuthorsService_UpdateAuthor_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authorsServiceClient) DeleteAuthor(ctx context.Context, in *IdRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, AuthorsService_DeleteAuthor_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AuthorsServiceServer is the server API for AuthorsService service.
+// All implementations must embed UnimplementedAuthorsServiceServer
+// for forward compatibility
+type AuthorsServiceServer interface {
+	CreateAuthor(context.Context, *CreateAuthorRequest) (*CreateResponse, error)
+	GetAllAuthors(context.Context, *emptypb.Empty) (*AuthorsResponse, error)
+	GetAuthorById(context.Context, *IdRequest) (*Author, error)
+	GetAuthorArticles(context.Context, *IdRequest) (*ArticlesResponse, error)
+	UpdateAuthor(context.Context, *UpdateAuthorRequest) (*emptypb.Empty, error)
+	DeleteAuthor(context.Context, *IdRequest) (*emptypb.Empty, error)
+	mustEmbedUnimplementedAuthorsServiceServer()
+}
+
+// UnimplementedAuthorsServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedAuthorsServiceServer struct {
+}
+
+func (UnimplementedAuthorsServiceServer) CreateAuthor(context.Context, *CreateAuthorRequest) (*CreateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateAuthor not implemented")
+}
+func (UnimplementedAuthorsServiceServer) GetAllAuthors(context.Context, *emptypb.Empty) (*AuthorsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAllAuthors not implemented")
+}
+func (UnimplementedAuthorsServiceServer) GetAuthorById(context.Context, *IdRequest) (*Author, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAuthorById not implemented")
+}
+func (UnimplementedAuthorsServiceServer) GetAuthorArticles(context.Context, *IdRequest) (*ArticlesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAuthorArticles not implemented")
+}
+func (UnimplementedAuthorsServiceServer) UpdateAuthor(context.Context, *UpdateAuthorRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateAuthor not implemented")
+}
+func (UnimplementedAuthorsServiceServer) DeleteAuthor(context.Context, *IdRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteAuthor not implemented")
+}
+func (UnimplementedAuthorsServiceServer) mustEmbedUnimplementedAuthorsServiceServer() {}
+
+// UnsafeAuthorsServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AuthorsServiceServer will
+// result in compilation errors.
+type UnsafeAuthorsServiceServer interface {
+	mustEmbedUnimplementedAuthorsServiceServer()
+}
+
+func RegisterAuthorsServiceServer(s grpc.ServiceRegistrar, srv AuthorsServiceServer) {
+	s.RegisterService(&AuthorsService_ServiceDesc, srv)
+}
+
+func _AuthorsService_CreateAuthor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateAuthorRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthorsServiceServer).CreateAuthor(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AuthorsService_CreateAuthor_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthorsServiceServer).CreateAuthor(ctx, req.(*CreateAuthorRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AuthorsService_GetAllAuthors_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthorsServiceServer).GetAllAuthors(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AuthorsService_GetAllAuthors_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthorsServiceServer).GetAllAuthors(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AuthorsService_GetAuthorById_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IdRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthorsServiceServer).GetAuthorById(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AuthorsService_GetAuthorById_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthorsServiceServer).GetAuthorById(ctx, req.(*IdRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AuthorsService_GetAuthorArticles_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IdRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthorsServiceServer).GetAuthorArticles(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AuthorsService_GetAuthorArticles_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthorsServiceServer).GetAuthorArticles(ctx, req.(*IdRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AuthorsService_UpdateAuthor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateAuthorRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthorsServiceServer).UpdateAuthor(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AuthorsService_UpdateAuthor_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthorsServiceServer).UpdateAuthor(ctx, req.(*UpdateAuthorRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AuthorsService_DeleteAuthor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IdRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthorsServiceServer).DeleteAuthor(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AuthorsService_DeleteAuthor_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthorsServiceServer).DeleteAuthor(ctx, req.(*IdRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// AuthorsService_ServiceDesc is the grpc.ServiceDesc for AuthorsService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var AuthorsService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "news.AuthorsService",
+	HandlerType: (*AuthorsServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateAuthor",
+			Handler:    _AuthorsService_CreateAuthor_Handler,
+		},
+		{
+			MethodName: "GetAllAuthors",
+			Handler:    _AuthorsService_GetAllAuthors_Handler,
+		},
+		{
+			MethodName: "GetAuthorById",
+			Handler:    _AuthorsService_GetAuthorById_Handler,
+		},
+		{
+			MethodName: "GetAuthorArticles",
+			Handler:    _AuthorsService_GetAuthorArticles_Handler,
+		},
+		{
+			MethodName: "UpdateAuthor",
+			Handler:    _AuthorsService_UpdateAuthor_Handler,
+		},
+		{
+			MethodName: "DeleteAuthor",
+			Handler:    _AuthorsService_DeleteAuthor_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "news.proto",
+}
+
+const (
+	ArticlesService_CreateArticle_FullMethodName  = "/news.ArticlesService/CreateArticle"
+	ArticlesService_GetAllArticles_FullMethodName = "/news.ArticlesService/GetAllArticles"
+	ArticlesService_GetArticleById_FullMethodName = "/news.ArticlesService/GetArticleById"
+	ArticlesService_UpdateArticle_FullMethodName  = "/news.ArticlesService/UpdateArticle"
+	ArticlesService_DeleteArticle_FullMethodName  = "/news.ArticlesService/DeleteArticle"
+)
+
+// ArticlesServiceClient is the client API for ArticlesService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ArticlesServiceClient interface {
+	CreateArticle(ctx context.Context, in *CreateArticleRequest, opts ...grpc.CallOption) (*CreateResponse, error)
+	GetAllArticles(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ArticlesResponse, error)
+	GetArticleById(ctx context.Context, in *IdRequest, opts ...grpc.CallOption) (*Article, error)
+	UpdateArticle(ctx context.Context, in *UpdateArticleRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	DeleteArticle(ctx context.Context, in *IdRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+}
+
+type articlesServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewArticlesServiceClient(cc grpc.ClientConnInterface) ArticlesServiceClient {
+	return &articlesServiceClient{cc}
+}
+
+func (c *articlesServiceClient) CreateArticle(ctx context.Context, in *CreateArticleRequest, opts ...grpc.CallOption) (*CreateResponse, error) {
+	out := new(CreateResponse)
+	err := c.cc.Invoke(ctx, ArticlesService_CreateArticle_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *articlesServiceClient) GetAllArticles(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ArticlesResponse, error) {
+	out := new(ArticlesResponse)
+	err := c.cc.Invoke(ctx, ArticlesService_GetAllArticles_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *articlesServiceClient) GetArticleById(ctx context.Context, in *IdRequest, opts ...grpc.CallOption) (*Article, error) {
+	out := new(Article)
+	err := c.cc.Invoke(ctx, ArticlesService_GetArticleById_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *articlesServiceClient) UpdateArticle(ctx context.Context, in *UpdateArticleRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, ArticlesService_UpdateArticle_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *articlesServiceClient) DeleteArticle(ctx context.Context, in *IdRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, ArticlesService_DeleteArticle_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ArticlesServiceServer is the server API for ArticlesService service.
+// All implementations must embed UnimplementedArticlesServiceServer
+// for forward compatibility
+type ArticlesServiceServer interface {
+	CreateArticle(context.Context, *CreateArticleRequest) (*CreateResponse, error)
+	GetAllArticles(context.Context, *emptypb.Empty) (*ArticlesResponse, error)
+	GetArticleById(context.Context, *IdRequest) (*Article, error)
+	UpdateArticle(context.Context, *UpdateArticleRequest) (*emptypb.Empty, error)
+	DeleteArticle(context.Context, *IdRequest) (*emptypb.Empty, error)
+	mustEmbedUnimplementedArticlesServiceServer()
+}
+
+// UnimplementedArticlesServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedArticlesServiceServer struct {
+}
+
+func (UnimplementedArticlesServiceServer) CreateArticle(context.Context, *CreateArticleRequest) (*CreateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateArticle not implemented")
+}
+func (UnimplementedArticlesServiceServer) GetAllArticles(context.Context, *emptypb.Empty) (*ArticlesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAllArticles not implemented")
+}
+func (UnimplementedArticlesServiceServer) GetArticleById(context.Context, *IdRequest) (*Article, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetArticleById not implemented")
+}
+func (UnimplementedArticlesServiceServer) UpdateArticle(context.Context, *UpdateArticleRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateArticle not implemented")
+}
+func (UnimplementedArticlesServiceServer) DeleteArticle(context.Context, *IdRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteArticle not implemented")
+}
+func (UnimplementedArticlesServiceServer) mustEmbedUnimplementedArticlesServiceServer() {}
+
+// UnsafeArticlesServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ArticlesServiceServer will
+// result in compilation errors.
+type UnsafeArticlesServiceServer interface {
+	mustEmbedUnimplementedArticlesServiceServer()
+}
+
+func RegisterArticlesServiceServer(s grpc.ServiceRegistrar, srv ArticlesServiceServer) {
+	s.RegisterService(&ArticlesService_ServiceDesc, srv)
+}
+
+func _ArticlesService_CreateArticle_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateArticleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ArticlesServiceServer).CreateArticle(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ArticlesService_CreateArticle_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ArticlesServiceServer).CreateArticle(ctx, req.(*CreateArticleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ArticlesService_GetAllArticles_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ArticlesServiceServer).GetAllArticles(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ArticlesService_GetAllArticles_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ArticlesServiceServer).GetAllArticles(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ArticlesService_GetArticleById_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IdRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ArticlesServiceServer).GetArticleById(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ArticlesService_GetArticleById_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ArticlesServiceServer).GetArticleById(ctx, req.(*IdRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ArticlesService_UpdateArticle_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateArticleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ArticlesServiceServer).UpdateArticle(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ArticlesService_UpdateArticle_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ArticlesServiceServer).UpdateArticle(ctx, req.(*UpdateArticleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ArticlesService_DeleteArticle_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IdRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ArticlesServiceServer).DeleteArticle(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ArticlesService_DeleteArticle_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ArticlesServiceServer).DeleteArticle(ctx, req.(*IdRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ArticlesService_ServiceDesc is the grpc.ServiceDesc for ArticlesService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ArticlesService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "news.ArticlesService",
+	HandlerType: (*ArticlesServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateArticle",
+			Handler:    _ArticlesService_CreateArticle_Handler,
+		},
+		{
+			MethodName: "GetAllArticles",
+			Handler:    _ArticlesService_GetAllArticles_Handler,
+		},
+		{
+			MethodName: "GetArticleById",
+			Handler:    _ArticlesService_GetArticleById_Handler,
+		},
+		{
+			MethodName: "UpdateArticle",
+			Handler:    _ArticlesService_UpdateArticle_Handler,
+		},
+		{
+			MethodName: "DeleteArticle",
+			Handler:    _ArticlesService_DeleteArticle_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "news.proto",
+}
+
+const (
+	BookmarksService_AddBookmark_FullMethodName  = "/news.BookmarksService/AddBookmark"
+	BookmarksService_GetBookmarks_FullMethodName = "/news.BookmarksService/GetBookmarks"
+)
+
+// BookmarksServiceClient is the client API for BookmarksService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type BookmarksServiceClient interface {
+	AddBookmark(ctx context.Context, in *IdRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	GetBookmarks(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ArticlesResponse, error)
+}
+
+type bookmarksServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewBookmarksServiceClient(cc grpc.ClientConnInterface) BookmarksServiceClient {
+	return &bookmarksServiceClient{cc}
+}
+
+func (c *bookmarksServiceClient) AddBookmark(ctx context.Context, in *IdRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, BookmarksService_AddBookmark_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookmarksServiceClient) GetBookmarks(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ArticlesResponse, error) {
+	out := new(ArticlesResponse)
+	err := c.cc.Invoke(ctx, BookmarksService_GetBookmarks_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// BookmarksServiceServer is the server API for BookmarksService service.
+// All implementations must embed UnimplementedBookmarksServiceServer
+// for forward compatibility
+type BookmarksServiceServer interface {
+	AddBookmark(context.Context, *IdRequest) (*emptypb.Empty, error)
+	GetBookmarks(context.Context, *emptypb.Empty) (*ArticlesResponse, error)
+	mustEmbedUnimplementedBookmarksServiceServer()
+}
+
+// UnimplementedBookmarksServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedBookmarksServiceServer struct {
+}
+
+func (UnimplementedBookmarksServiceServer) AddBookmark(context.Context, *IdRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddBookmark not implemented")
+}
+func (UnimplementedBookmarksServiceServer) GetBookmarks(context.Context, *emptypb.Empty) (*ArticlesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBookmarks not implemented")
+}
+func (UnimplementedBookmarksServiceServer) mustEmbedUnimplementedBookmarksServiceServer() {}
+
+// UnsafeBookmarksServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to BookmarksServiceServer will
+// result in compilation errors.
+type UnsafeBookmarksServiceServer interface {
+	mustEmbedUnimplementedBookmarksServiceServer()
+}
+
+func RegisterBookmarksServiceServer(s grpc.ServiceRegistrar, srv BookmarksServiceServer) {
+	s.RegisterService(&BookmarksService_ServiceDesc, srv)
+}
+
+func _BookmarksService_AddBookmark_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IdRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookmarksServiceServer).AddBookmark(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BookmarksService_AddBookmark_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookmarksServiceServer).AddBookmark(ctx, req.(*IdRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BookmarksService_GetBookmarks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookmarksServiceServer).GetBookmarks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BookmarksService_GetBookmarks_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookmarksServiceServer).GetBookmarks(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// BookmarksService_ServiceDesc is the grpc.ServiceDesc for BookmarksService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var BookmarksService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "news.BookmarksService",
+	HandlerType: (*BookmarksServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "AddBookmark",
+			Handler:    _BookmarksService_AddBookmark_Handler,
+		},
+		{
+			MethodName: "GetBookmarks",
+			Handler:    _BookmarksService_GetBookmarks_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "news.proto",
+}
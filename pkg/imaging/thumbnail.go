@@ -0,0 +1,74 @@
+// Package imaging makes thumbnails of decoded images with the standard library only.
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// Thumbnail scales the image down to fit into a size×size square keeping its aspect ratio, images which
+// already fit are kept at their size. Each pixel of the result averages the box of source pixels it covers,
+// transparent areas are laid over white, so the result can be encoded as JPEG.
+func Thumbnail(src image.Image, size int) *image.RGBA {
+	bounds := src.Bounds()
+	width, height := fit(bounds.Dx(), bounds.Dy(), size)
+
+	// compositing over white once spares the averaging from dealing with alpha
+	flat := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(flat, flat.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), src, bounds.Min, draw.Over)
+
+	if width == bounds.Dx() && height == bounds.Dy() {
+		return flat
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0, y1 := span(y, height, bounds.Dy())
+		for x := 0; x < width; x++ {
+			x0, x1 := span(x, width, bounds.Dx())
+
+			var r, g, b, n int
+			for sy := y0; sy < y1; sy++ {
+				row := flat.Pix[sy*flat.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					p := row[sx*4:]
+					r += int(p[0])
+					g += int(p[1])
+					b += int(p[2])
+					n++
+				}
+			}
+			dst.SetRGBA(x, y, color.RGBA{R: uint8(r / n), G: uint8(g / n), B: uint8(b / n), A: 0xff})
+		}
+	}
+	return dst
+}
+
+// fit returns the size of a width×height image scaled down to fit into a size×size square.
+func fit(width, height, size int) (int, int) {
+	if width <= size && height <= size {
+		return width, height
+	}
+	if width >= height {
+		return size, max(1, height*size/width)
+	}
+	return max(1, width*size/height), size
+}
+
+// span returns the range of source pixels covered by the i-th of n destination pixels.
+func span(i, n, total int) (int, int) {
+	from, to := i*total/n, (i+1)*total/n
+	if to == from {
+		to = from + 1
+	}
+	return from, to
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,84 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+func uniform(width, height int, c color.Color) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(c), image.Point{}, draw.Src)
+	return img
+}
+
+func TestFit(t *testing.T) {
+	tests := []struct {
+		width, height, size int
+		wantW, wantH        int
+	}{
+		{100, 50, 200, 100, 50},
+		{200, 200, 200, 200, 200},
+		{400, 200, 200, 200, 100},
+		{200, 400, 200, 100, 200},
+		{1000, 1, 100, 100, 1},
+		{1, 1000, 100, 1, 100},
+	}
+	for _, tt := range tests {
+		if w, h := fit(tt.width, tt.height, tt.size); w != tt.wantW || h != tt.wantH {
+			t.Errorf("fit(%d, %d, %d) = %d×%d, want %d×%d", tt.width, tt.height, tt.size, w, h, tt.wantW, tt.wantH)
+		}
+	}
+}
+
+func TestThumbnailScalesDown(t *testing.T) {
+	thumb := Thumbnail(uniform(300, 150, color.NRGBA{R: 200, G: 100, B: 50, A: 0xff}), 100)
+
+	if got := thumb.Bounds(); got != image.Rect(0, 0, 100, 50) {
+		t.Fatalf("got bounds %v, want 100×50", got)
+	}
+	if got := thumb.RGBAAt(99, 49); got != (color.RGBA{R: 200, G: 100, B: 50, A: 0xff}) {
+		t.Errorf("got %v, want the source color", got)
+	}
+}
+
+func TestThumbnailAveragesPixels(t *testing.T) {
+	// black and white columns make a gray thumbnail
+	src := uniform(4, 2, color.White)
+	for y := 0; y < 2; y++ {
+		src.Set(0, y, color.Black)
+		src.Set(2, y, color.Black)
+	}
+
+	thumb := Thumbnail(src, 2)
+	if got := thumb.Bounds(); got != image.Rect(0, 0, 2, 1) {
+		t.Fatalf("got bounds %v, want 2×1", got)
+	}
+	for x := 0; x < 2; x++ {
+		if got := thumb.RGBAAt(x, 0); got != (color.RGBA{R: 127, G: 127, B: 127, A: 0xff}) {
+			t.Errorf("pixel %d: got %v, want gray", x, got)
+		}
+	}
+}
+
+func TestThumbnailKeepsSmallImages(t *testing.T) {
+	thumb := Thumbnail(uniform(30, 20, color.Black), 100)
+	if got := thumb.Bounds(); got != image.Rect(0, 0, 30, 20) {
+		t.Errorf("got bounds %v, want the size kept", got)
+	}
+}
+
+func TestThumbnailFlattensTransparency(t *testing.T) {
+	src := uniform(10, 10, color.Transparent)
+	// a sub-image whose bounds don't start at the origin
+	sub := src.SubImage(image.Rect(5, 5, 10, 10))
+
+	thumb := Thumbnail(sub, 100)
+	if got := thumb.Bounds(); got != image.Rect(0, 0, 5, 5) {
+		t.Fatalf("got bounds %v, want 5×5", got)
+	}
+	if got := thumb.RGBAAt(4, 4); got != (color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}) {
+		t.Errorf("got %v, want opaque white", got)
+	}
+}
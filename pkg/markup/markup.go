@@ -0,0 +1,52 @@
+// Package markup renders article bodies to HTML which is safe to embed into pages as is.
+package markup
+
+import (
+	"html"
+	"strings"
+
+	"github.com/russross/blackfriday/v2"
+	nethtml "golang.org/x/net/html"
+)
+
+// Markdown renders the source and sanitizes the result, raw HTML in the source is subject to the same policy.
+func Markdown(source string) string {
+	return Sanitize(string(blackfriday.Run([]byte(source))))
+}
+
+// PlainText renders text as paragraphs separated by blank lines, single line breaks are kept.
+func PlainText(source string) string {
+	source = strings.ReplaceAll(strings.TrimSpace(source), "\r\n", "\n")
+	if source == "" {
+		return ""
+	}
+
+	var b strings.Builder
+	for _, paragraph := range strings.Split(source, "\n\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		if paragraph == "" {
+			continue
+		}
+		b.WriteString("<p>")
+		b.WriteString(strings.ReplaceAll(html.EscapeString(paragraph), "\n", "<br>\n"))
+		b.WriteString("</p>\n")
+	}
+	return b.String()
+}
+
+// Text returns the text of an HTML fragment with entities decoded and runs of whitespace collapsed to single spaces.
+// Elements separate words, so paragraphs don't run into each other.
+func Text(fragment string) string {
+	var b strings.Builder
+	z := nethtml.NewTokenizer(strings.NewReader(fragment))
+	for {
+		switch z.Next() {
+		case nethtml.ErrorToken:
+			return strings.Join(strings.Fields(b.String()), " ")
+		case nethtml.TextToken:
+			b.Write(z.Text())
+		default:
+			b.WriteByte(' ')
+		}
+	}
+}
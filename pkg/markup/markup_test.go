@@ -0,0 +1,10 @@
+package markup
+
+import "testing"
+
+func TestText(t *testing.T) {
+	got := Text("<h1>Title</h1><p>Tom &amp; Jerry<br>don&#39;t\n\n stop</p>")
+	if want := "Title Tom & Jerry don't stop"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
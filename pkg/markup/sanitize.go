@@ -0,0 +1,167 @@
+package markup
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+
+	nethtml "golang.org/x/net/html"
+)
+
+// allowedTags are the elements kept by Sanitize with the attributes they may have.
+// Everything else is dropped, the text inside is kept.
+var allowedTags = map[string][]string{
+	"p": nil, "br": nil, "hr": nil,
+	"h1": nil, "h2": nil, "h3": nil, "h4": nil, "h5": nil, "h6": nil,
+	"strong": nil, "em": nil, "b": nil, "i": nil, "del": nil, "s": nil, "sup": nil, "sub": nil,
+	"blockquote": nil, "pre": nil, "code": {"class"},
+	"ul": nil, "ol": {"start"}, "li": nil, "dl": nil, "dt": nil, "dd": nil,
+	"table": nil, "thead": nil, "tbody": nil, "tr": nil, "th": {"align"}, "td": {"align"},
+	"a":   {"href", "title"},
+	"img": {"src", "alt", "title"},
+}
+
+// droppedWithContent are the elements whose content makes no sense as text.
+var droppedWithContent = map[string]bool{
+	"script": true, "style": true, "iframe": true, "object": true, "embed": true,
+	"noscript": true, "noembed": true, "noframes": true, "template": true, "textarea": true, "title": true, "xmp": true,
+}
+
+var voidTags = map[string]bool{"br": true, "hr": true, "img": true}
+
+var (
+	codeClass = regexp.MustCompile(`^language-[A-Za-z0-9_+#.-]+$`)
+	number    = regexp.MustCompile(`^[0-9]{1,6}$`)
+
+	alignments = map[string]bool{"left": true, "center": true, "right": true}
+	// link schemes, relative URLs are allowed too
+	linkSchemes  = map[string]bool{"http": true, "https": true, "mailto": true}
+	imageSchemes = map[string]bool{"http": true, "https": true}
+)
+
+// Sanitize keeps only the allowed elements and attributes of the HTML fragment and makes it well-formed.
+// Links get rel="nofollow noopener", URLs with other schemes than http, https and mailto are removed.
+func Sanitize(fragment string) string {
+	var (
+		b       strings.Builder
+		open    []string
+		skipTag string
+		skipped int
+	)
+
+	z := nethtml.NewTokenizer(strings.NewReader(fragment))
+	for {
+		// the tokenizer stops with io.EOF or on input it can't read further, either ends the fragment
+		if z.Next() == nethtml.ErrorToken {
+			break
+		}
+		token := z.Token()
+
+		if skipTag != "" {
+			// nested elements of the same name can't hide the end of the skipped one
+			switch {
+			case token.Type == nethtml.StartTagToken && token.Data == skipTag:
+				skipped++
+			case token.Type == nethtml.EndTagToken && token.Data == skipTag:
+				if skipped--; skipped == 0 {
+					skipTag = ""
+				}
+			}
+			continue
+		}
+
+		switch token.Type {
+		case nethtml.TextToken:
+			b.WriteString(html.EscapeString(token.Data))
+
+		case nethtml.StartTagToken, nethtml.SelfClosingTagToken:
+			if droppedWithContent[token.Data] {
+				if token.Type == nethtml.StartTagToken {
+					skipTag, skipped = token.Data, 1
+				}
+				continue
+			}
+			attributes, ok := allowedTags[token.Data]
+			if !ok {
+				continue
+			}
+			writeStartTag(&b, token, attributes)
+			if !voidTags[token.Data] && token.Type == nethtml.StartTagToken {
+				open = append(open, token.Data)
+			}
+
+		case nethtml.EndTagToken:
+			// close the elements opened since the matching start tag, end tags without one are dropped
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] != token.Data {
+					continue
+				}
+				for j := len(open) - 1; j >= i; j-- {
+					b.WriteString("</" + open[j] + ">")
+				}
+				open = open[:i]
+				break
+			}
+		}
+	}
+
+	for i := len(open) - 1; i >= 0; i-- {
+		b.WriteString("</" + open[i] + ">")
+	}
+	return b.String()
+}
+
+func writeStartTag(b *strings.Builder, token nethtml.Token, allowed []string) {
+	b.WriteString("<" + token.Data)
+	for _, attr := range token.Attr {
+		if attr.Namespace != "" || !contains(allowed, attr.Key) {
+			continue
+		}
+		value, ok := attributeValue(token.Data, attr.Key, strings.TrimSpace(attr.Val))
+		if !ok {
+			continue
+		}
+		b.WriteString(" " + attr.Key + `="` + html.EscapeString(value) + `"`)
+	}
+	if token.Data == "a" {
+		b.WriteString(` rel="nofollow noopener"`)
+	}
+	b.WriteString(">")
+}
+
+// attributeValue checks the value of an allowed attribute.
+func attributeValue(tag, key, value string) (string, bool) {
+	switch key {
+	case "href":
+		return value, safeURL(value, linkSchemes)
+	case "src":
+		return value, safeURL(value, imageSchemes)
+	case "class":
+		return value, tag == "code" && codeClass.MatchString(value)
+	case "start":
+		return value, number.MatchString(value)
+	case "align":
+		value = strings.ToLower(value)
+		return value, alignments[value]
+	default:
+		return value, true
+	}
+}
+
+func safeURL(value string, schemes map[string]bool) bool {
+	u, err := url.Parse(value)
+	if err != nil {
+		return false
+	}
+	return u.Scheme == "" && u.Opaque == "" || schemes[strings.ToLower(u.Scheme)]
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package markup
+
+import (
+	"strings"
+	"testing"
+
+	nethtml "golang.org/x/net/html"
+)
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"allowed markup", `<p>Hi <a href="https://example.com" title="t">there</a></p>`,
+			`<p>Hi <a href="https://example.com" title="t" rel="nofollow noopener">there</a></p>`},
+		{"relative link", `<a href="/articles/1">x</a>`, `<a href="/articles/1" rel="nofollow noopener">x</a>`},
+
+		// schemes
+		{"javascript link", `<a href="javascript:alert(1)">x</a>`, `<a rel="nofollow noopener">x</a>`},
+		{"mixed case scheme", `<a href=" JaVaScRiPt:alert(1)">x</a>`, `<a rel="nofollow noopener">x</a>`},
+		{"decimal entity", `<a href="&#106;avascript:alert(1)">x</a>`, `<a rel="nofollow noopener">x</a>`},
+		{"hex entities", `<a href="&#x6A;&#x61;&#x76;&#x61;&#x73;&#x63;&#x72;&#x69;&#x70;&#x74;&#x3A;alert(1)">x</a>`,
+			`<a rel="nofollow noopener">x</a>`},
+		{"named colon entity", `<a href="javascript&colon;alert(1)">x</a>`, `<a rel="nofollow noopener">x</a>`},
+		{"encoded tab", `<a href="java&#x09;script:alert(1)">x</a>`, `<a rel="nofollow noopener">x</a>`},
+		{"newline", "<a href=\"java\nscript:alert(1)\">x</a>", `<a rel="nofollow noopener">x</a>`},
+		{"control character", `<a href="&#1;javascript:alert(1)">x</a>`, `<a rel="nofollow noopener">x</a>`},
+		{"vbscript", `<a href="vbscript:msgbox(1)">x</a>`, `<a rel="nofollow noopener">x</a>`},
+		{"data link", `<a href="data:text/html;base64,PHNjcmlwdD5hbGVydCgxKTwvc2NyaXB0Pg==">x</a>`,
+			`<a rel="nofollow noopener">x</a>`},
+		{"javascript image", `<img src="javascript:alert(1)">`, `<img>`},
+		{"mailto image", `<img src="mailto:a@example.com">`, `<img>`},
+		{"data image", `<img src="data:image/svg+xml,&lt;svg onload=alert(1)&gt;">`, `<img>`},
+		{"event handler", `<img src=x onerror=alert(1)>`, `<img src="x">`},
+		{"quotes in a value", `<a title='x" onclick="alert(1)'>x</a>`,
+			`<a title="x&#34; onclick=&#34;alert(1)" rel="nofollow noopener">x</a>`},
+		{"entities in a URL", `<a href="https://example.com/?q=&quot;&gt;&lt;script&gt;">x</a>`,
+			`<a href="https://example.com/?q=&#34;&gt;&lt;script&gt;" rel="nofollow noopener">x</a>`},
+
+		// elements dropped with their content
+		{"nested script", `<script><script>alert(1)</script>alert(2)</script>after`, `alert(2)after`},
+		{"nested style", `<style><style>body{}</style>x</style>after`, `xafter`},
+		{"nested object", `<object><object></object><script>alert(1)</script></object>after`, `after`},
+		{"split script tag", `<scr<script>ipt>alert(1)</script>`, `ipt&gt;alert(1)`},
+		{"style in svg", `<svg><style><img src=x onerror=alert(1)></style></svg>`, ``},
+		{"noscript", `<noscript><p title="</noscript><img src=x onerror=alert(1)>"></noscript>`, `<img src="x">&#34;&gt;`},
+		{"comment", `<!--<script>alert(1)</script>-->ok`, `ok`},
+		{"plaintext", `<p>before<plaintext><script>alert(1)</script></p>`,
+			`<p>before&lt;script&gt;alert(1)&lt;/script&gt;&lt;/p&gt;</p>`},
+
+		// structure
+		{"stray end tags", `</p></div>text<b>bold`, `text<b>bold</b>`},
+		{"misnested", `<em><strong>x</em>y</strong>`, `<em><strong>x</strong></em>y`},
+		{"unclosed", `<p><b>x</p>`, `<p><b>x</b></p>`},
+		{"unterminated tag", `<a href="javascript:alert(1)`, ``},
+
+		// namespaced attributes
+		{"xlink href", `<a xlink:href="javascript:alert(1)" href="https://example.com">x</a>`,
+			`<a href="https://example.com" rel="nofollow noopener">x</a>`},
+		{"xlink in svg", `<svg xmlns:xlink="http://www.w3.org/1999/xlink"><a xlink:href="javascript:alert(1)">x</a></svg>`,
+			`<a rel="nofollow noopener">x</a>`},
+		{"xml src", `<img xml:src="javascript:alert(1)" src="https://example.com/a.png">`, `<img src="https://example.com/a.png">`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Sanitize(tt.in)
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			assertSafe(t, got)
+		})
+	}
+}
+
+// assertSafe tokenizes the output again and checks it holds only allowed elements and attributes with safe URLs.
+func assertSafe(t *testing.T, fragment string) {
+	t.Helper()
+
+	z := nethtml.NewTokenizer(strings.NewReader(fragment))
+	for z.Next() != nethtml.ErrorToken {
+		token := z.Token()
+		if token.Type != nethtml.StartTagToken && token.Type != nethtml.SelfClosingTagToken {
+			continue
+		}
+		allowed, ok := allowedTags[token.Data]
+		if !ok {
+			t.Errorf("element %s is not allowed", token.Data)
+			continue
+		}
+		for _, attr := range token.Attr {
+			if attr.Key == "rel" && token.Data == "a" {
+				continue
+			}
+			if !contains(allowed, attr.Key) {
+				t.Errorf("attribute %s of %s is not allowed", attr.Key, token.Data)
+			}
+			if (attr.Key == "href" || attr.Key == "src") && !safeURL(attr.Val, linkSchemes) {
+				t.Errorf("unsafe URL %q", attr.Val)
+			}
+		}
+	}
+}
@@ -0,0 +1,323 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testClientID     = "news-server"
+	testClientSecret = "client-secret"
+	testRedirectURL  = "https://news.example.com/callback"
+	testCode         = "auth-code"
+)
+
+type signingKey struct {
+	kid string
+	key *rsa.PrivateKey
+}
+
+// fakeIssuer is an identity provider which signs ID tokens with generated RSA keys. The keys can be rotated.
+type fakeIssuer struct {
+	t      *testing.T
+	server *httptest.Server
+	issuer string // the issuer announced in the discovery document, the server URL by default
+
+	mu       sync.Mutex
+	key      signingKey
+	claims   jwt.MapClaims // claims of the next ID token on top of the defaults
+	verifier string        // the code verifier the token endpoint expects
+	jwksHits int
+}
+
+func newFakeIssuer(t *testing.T) *fakeIssuer {
+	f := &fakeIssuer{t: t, key: newSigningKey(t, "key-1")}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", f.discovery)
+	mux.HandleFunc("/jwks", f.jwks)
+	mux.HandleFunc("/token", f.token)
+	f.server = httptest.NewServer(mux)
+	f.issuer = f.server.URL
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+func newSigningKey(t *testing.T, kid string) signingKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signingKey{kid: kid, key: key}
+}
+
+func (f *fakeIssuer) provider() *Provider {
+	return NewProvider(Config{
+		Issuer:       f.server.URL,
+		ClientID:     testClientID,
+		ClientSecret: testClientSecret,
+		RedirectURL:  testRedirectURL,
+	}, f.server.Client())
+}
+
+func (f *fakeIssuer) rotate(kid string) {
+	key := newSigningKey(f.t, kid)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.key = key
+}
+
+func (f *fakeIssuer) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{
+		"issuer":                 f.issuer,
+		"authorization_endpoint": f.server.URL + "/authorize",
+		"token_endpoint":         f.server.URL + "/token",
+		"jwks_uri":               f.server.URL + "/jwks",
+	})
+}
+
+func (f *fakeIssuer) jwks(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.jwksHits++
+	pub := f.key.key.PublicKey
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": f.key.kid,
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	})
+}
+
+func (f *fakeIssuer) token(w http.ResponseWriter, r *http.Request) {
+	id, secret, _ := r.BasicAuth()
+	if id != testClientID || secret != testClientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+	if r.PostFormValue("grant_type") != "authorization_code" || r.PostFormValue("code") != testCode ||
+		r.PostFormValue("redirect_uri") != testRedirectURL || r.PostFormValue("code_verifier") != f.verifier {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"id_token": f.sign(f.claims)})
+}
+
+// sign returns an ID token for the client signed with the current key.
+func (f *fakeIssuer) sign(claims jwt.MapClaims) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	all := jwt.MapClaims{
+		"iss":   f.server.URL,
+		"sub":   "subject-1",
+		"aud":   testClientID,
+		"iat":   time.Now().Unix(),
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"email": "user@example.com",
+	}
+	for k, v := range claims {
+		all[k] = v
+	}
+
+	t := jwt.NewWithClaims(jwt.SigningMethodRS256, all)
+	t.Header["kid"] = f.key.kid
+	signed, err := t.SignedString(f.key.key)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	return signed
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func TestAuthCodeURL(t *testing.T) {
+	issuer := newFakeIssuer(t)
+
+	raw, err := issuer.provider().AuthCodeURL(context.Background(), "state-1", "nonce-1", "challenge-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := u.Scheme + "://" + u.Host + u.Path; got != issuer.server.URL+"/authorize" {
+		t.Errorf("got endpoint %s", got)
+	}
+
+	want := map[string]string{
+		"response_type":         "code",
+		"client_id":             testClientID,
+		"redirect_uri":          testRedirectURL,
+		"scope":                 "openid email profile",
+		"state":                 "state-1",
+		"nonce":                 "nonce-1",
+		"code_challenge":        "challenge-1",
+		"code_challenge_method": "S256",
+	}
+	for k, v := range want {
+		if got := u.Query().Get(k); got != v {
+			t.Errorf("got %s %q, want %q", k, got, v)
+		}
+	}
+}
+
+func TestDiscoveryIssuerMismatch(t *testing.T) {
+	issuer := newFakeIssuer(t)
+	issuer.issuer = "https://evil.example.com"
+
+	if _, err := issuer.provider().AuthCodeURL(context.Background(), "state", "nonce", "challenge"); err == nil {
+		t.Fatal("want an error for a discovery document of another issuer")
+	}
+}
+
+func TestExchange(t *testing.T) {
+	issuer := newFakeIssuer(t)
+	verifier, err := NewCodeVerifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+	issuer.verifier = verifier
+	issuer.claims = jwt.MapClaims{"nonce": "nonce-1", "email_verified": "true", "name": "User"}
+
+	claims, err := issuer.provider().Exchange(context.Background(), testCode, verifier, "nonce-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Issuer != issuer.server.URL || claims.Subject != "subject-1" || claims.Email != "user@example.com" ||
+		!bool(claims.EmailVerified) || claims.Name != "User" {
+		t.Errorf("got %+v", claims)
+	}
+}
+
+func TestExchangeRejectedByTokenEndpoint(t *testing.T) {
+	issuer := newFakeIssuer(t)
+	issuer.verifier = "expected-verifier"
+
+	if _, err := issuer.provider().Exchange(context.Background(), testCode, "other-verifier", "nonce-1"); err == nil {
+		t.Fatal("want an error for a code verifier the issuer doesn't accept")
+	}
+}
+
+func TestVerifyRejectsInvalidTokens(t *testing.T) {
+	issuer := newFakeIssuer(t)
+	stranger := newSigningKey(t, "key-1")
+
+	tests := []struct {
+		name  string
+		token func() string
+	}{
+		{"nonce mismatch", func() string { return issuer.sign(jwt.MapClaims{"nonce": "other-nonce"}) }},
+		{"no nonce", func() string { return issuer.sign(nil) }},
+		{"other audience", func() string { return issuer.sign(jwt.MapClaims{"nonce": "nonce-1", "aud": "other-client"}) }},
+		{"other issuer", func() string {
+			return issuer.sign(jwt.MapClaims{"nonce": "nonce-1", "iss": "https://evil.example.com"})
+		}},
+		{"expired", func() string {
+			return issuer.sign(jwt.MapClaims{"nonce": "nonce-1", "exp": time.Now().Add(-time.Hour).Unix()})
+		}},
+		{"no subject", func() string { return issuer.sign(jwt.MapClaims{"nonce": "nonce-1", "sub": ""}) }},
+		{"signed with another key", func() string {
+			t := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+				"iss":   issuer.server.URL,
+				"sub":   "subject-1",
+				"aud":   testClientID,
+				"exp":   time.Now().Add(time.Hour).Unix(),
+				"nonce": "nonce-1",
+			})
+			t.Header["kid"] = stranger.kid
+			signed, _ := t.SignedString(stranger.key)
+			return signed
+		}},
+		{"not signed", func() string {
+			signed, _ := jwt.NewWithClaims(jwt.SigningMethodNone, jwt.MapClaims{
+				"iss":   issuer.server.URL,
+				"sub":   "subject-1",
+				"aud":   testClientID,
+				"exp":   time.Now().Add(time.Hour).Unix(),
+				"nonce": "nonce-1",
+			}).SignedString(jwt.UnsafeAllowNoneSignatureType)
+			return signed
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := issuer.provider().Verify(context.Background(), tt.token(), "nonce-1")
+			if !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("got %v, want ErrInvalidToken", err)
+			}
+		})
+	}
+}
+
+func TestVerifyFetchesRotatedKeys(t *testing.T) {
+	issuer := newFakeIssuer(t)
+	p := issuer.provider()
+	ctx := context.Background()
+
+	if _, err := p.Verify(ctx, issuer.sign(jwt.MapClaims{"nonce": "n"}), "n"); err != nil {
+		t.Fatal(err)
+	}
+
+	issuer.rotate("key-2")
+	rotated := issuer.sign(jwt.MapClaims{"nonce": "n"})
+
+	// the keys were fetched just now, an unknown key id doesn't make the provider hammer the issuer
+	if _, err := p.Verify(ctx, rotated, "n"); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("got %v, want ErrInvalidToken before the keys may be refetched", err)
+	}
+	if issuer.jwksHits != 1 {
+		t.Fatalf("got %d key fetches, want 1", issuer.jwksHits)
+	}
+
+	p.mu.Lock()
+	p.keysFetchedAt = time.Now().Add(-keysRefreshInterval)
+	p.mu.Unlock()
+
+	if _, err := p.Verify(ctx, rotated, "n"); err != nil {
+		t.Fatalf("got %v, want the rotated key to be fetched", err)
+	}
+	if issuer.jwksHits != 2 {
+		t.Errorf("got %d key fetches, want 2", issuer.jwksHits)
+	}
+}
+
+func TestCodeChallenge(t *testing.T) {
+	// RFC 7636, appendix B
+	got := CodeChallenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk")
+	if want := "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+
+	verifier, err := NewCodeVerifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(verifier) < 43 || len(verifier) > 128 || strings.ContainsAny(verifier, "+/=") {
+		t.Errorf("got invalid verifier %q", verifier)
+	}
+}
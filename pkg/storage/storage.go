@@ -0,0 +1,23 @@
+// Package storage keeps files by key in a local directory or in an S3-compatible bucket.
+// Keys are slash separated paths like "media/abc/original.png".
+package storage
+
+import (
+	"errors"
+	"path"
+	"strings"
+)
+
+// ErrNotFound is returned for keys which hold no file.
+var ErrNotFound = errors.New("file not found")
+
+var errInvalidKey = errors.New("invalid key")
+
+// cleanKey rejects keys which are empty or would escape the storage root.
+func cleanKey(key string) (string, error) {
+	cleaned := path.Clean("/" + key)[1:]
+	if cleaned == "" || cleaned != strings.TrimPrefix(key, "/") {
+		return "", errInvalidKey
+	}
+	return cleaned, nil
+}
@@ -0,0 +1,114 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCleanKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+		err  bool
+	}{
+		{key: "media/abc/original.png", want: "media/abc/original.png"},
+		{key: "/media/abc/original.png", want: "media/abc/original.png"},
+		{key: "", err: true},
+		{key: "/", err: true},
+		{key: "..", err: true},
+		{key: "../etc/passwd", err: true},
+		{key: "/../etc/passwd", err: true},
+		{key: "media/../../etc/passwd", err: true},
+		{key: "media/../other", err: true},
+		{key: "media/./abc", err: true},
+		{key: "media//abc", err: true},
+		{key: "media/abc/", err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			got, err := cleanKey(tt.key)
+			if tt.err {
+				if !errors.Is(err, errInvalidKey) {
+					t.Errorf("got %q, %v, want errInvalidKey", got, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("got %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocal(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewLocal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	if err := s.Put(ctx, "media/abc/original.png", "image/png", []byte("data")); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, s, "media/abc/original.png"); got != "data" {
+		t.Errorf("got %q, want data", got)
+	}
+
+	if err := s.Put(ctx, "media/abc/original.png", "image/png", []byte("new data")); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, s, "media/abc/original.png"); got != "new data" {
+		t.Errorf("got %q, want the file replaced", got)
+	}
+	entries, err := os.ReadDir(filepath.Join(dir, "media", "abc"))
+	if err != nil || len(entries) != 1 {
+		t.Errorf("got %d files, %v, want no temporary files left", len(entries), err)
+	}
+
+	if err := s.Delete(ctx, "media/abc/original.png"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Get(ctx, "media/abc/original.png"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("got %v, want ErrNotFound", err)
+	}
+	if err := s.Delete(ctx, "media/abc/original.png"); err != nil {
+		t.Errorf("got %v, deleting a missing file isn't an error", err)
+	}
+}
+
+func TestLocalRejectsKeysOutsideDir(t *testing.T) {
+	s, err := NewLocal(filepath.Join(t.TempDir(), "storage"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Put(context.Background(), "../escaped", "text/plain", []byte("data")); !errors.Is(err, errInvalidKey) {
+		t.Errorf("got %v, want errInvalidKey", err)
+	}
+	if _, err := s.Get(context.Background(), "media/../../escaped"); !errors.Is(err, errInvalidKey) {
+		t.Errorf("got %v, want errInvalidKey", err)
+	}
+}
+
+func readFile(t *testing.T, s interface {
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+}, key string) string {
+	t.Helper()
+
+	f, err := s.Get(context.Background(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
@@ -0,0 +1,93 @@
+package totp
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA-1 seed of RFC 6238 Appendix B, the ASCII string "12345678901234567890".
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+// rfcVectors are the SHA-1 test vectors of RFC 6238 Appendix B. The RFC lists eight digit codes,
+// six digit codes are their last six digits.
+var rfcVectors = []struct {
+	unix int64
+	step int64
+	code string
+}{
+	{59, 0x1, "287082"},
+	{1111111109, 0x23523EC, "081804"},
+	{1111111111, 0x23523ED, "050471"},
+	{1234567890, 0x273EF07, "005924"},
+	{2000000000, 0x3F940AA, "279037"},
+	{20000000000, 0x27BC86AA, "353130"},
+}
+
+func TestCode(t *testing.T) {
+	for _, v := range rfcVectors {
+		step := Step(time.Unix(v.unix, 0))
+		if step != v.step {
+			t.Errorf("%d: got step %#x, want %#x", v.unix, step, v.step)
+		}
+		code, err := Code(rfcSecret, step)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code != v.code {
+			t.Errorf("%d: got code %s, want %s", v.unix, code, v.code)
+		}
+	}
+}
+
+func TestCodeLowercaseSecret(t *testing.T) {
+	code, err := Code("gezdgnbvgy3tqojqgezdgnbvgy3tqojq", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code != "287082" {
+		t.Errorf("got %s, want 287082", code)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, v := range rfcVectors {
+		at := time.Unix(v.unix, 0)
+		tests := []struct {
+			name string
+			at   time.Time
+			ok   bool
+		}{
+			{"same step", at, true},
+			{"one step later", at.Add(Period), true},
+			{"one step earlier", at.Add(-Period), true},
+			{"two steps later", at.Add(2 * Period), false},
+			{"two steps earlier", at.Add(-2 * Period), false},
+		}
+		for _, tt := range tests {
+			// steps start at the Unix epoch
+			if tt.at.Unix() < 0 {
+				continue
+			}
+			step, ok := Validate(rfcSecret, v.code, tt.at)
+			if ok != tt.ok {
+				t.Errorf("%d, %s: got %v, want %v", v.unix, tt.name, ok, tt.ok)
+			}
+			if ok && step != v.step {
+				t.Errorf("%d, %s: got step %#x, want the step of the code %#x", v.unix, tt.name, step, v.step)
+			}
+		}
+	}
+}
+
+func TestValidateRejectsMalformed(t *testing.T) {
+	at := time.Unix(59, 0)
+	for _, code := range []string{"", "28708", "2870820", "94287082", "abcdef"} {
+		if _, ok := Validate(rfcSecret, code, at); ok {
+			t.Errorf("code %q must not be accepted", code)
+		}
+	}
+	if _, ok := Validate("not base32!", "287082", at); ok {
+		t.Error("an invalid secret must not accept codes")
+	}
+}
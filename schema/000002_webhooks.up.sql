@@ -0,0 +1,26 @@
+ALTER TABLE users ADD COLUMN role varchar(32) not null default 'reader';
+
+CREATE TABLE webhooks (
+    id serial not null unique,
+    url varchar(2048) not null,
+    secret varchar(255) not null,
+    events text[] not null default '{}',
+    active boolean not null default true,
+    created_at timestamp default now() not null
+);
+
+CREATE TABLE webhook_deliveries (
+    id serial not null unique,
+    webhook_id int references webhooks (id) on delete cascade not null,
+    event varchar(64) not null,
+    payload jsonb not null,
+    status varchar(16) not null default 'pending',
+    attempts int not null default 0,
+    next_attempt_at timestamp default now() not null,
+    last_error text not null default '',
+    response_code int not null default 0,
+    created_at timestamp default now() not null,
+    delivered_at timestamp
+);
+
+CREATE INDEX webhook_deliveries_due_idx ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
@@ -0,0 +1,4 @@
+DROP INDEX webhook_deliveries_idempotency_idx;
+ALTER TABLE webhook_deliveries DROP COLUMN idempotency_key;
+DROP TABLE processed_events;
+DROP TABLE outbox;
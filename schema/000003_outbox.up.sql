@@ -0,0 +1,25 @@
+CREATE TABLE outbox (
+    id bigserial not null unique,
+    event_type varchar(64) not null,
+    aggregate_id int not null,
+    payload jsonb not null,
+    idempotency_key varchar(64) not null unique,
+    attempts int not null default 0,
+    last_error text not null default '',
+    next_attempt_at timestamp default now() not null,
+    created_at timestamp default now() not null,
+    published_at timestamp
+);
+
+CREATE INDEX outbox_unpublished_idx ON outbox (next_attempt_at) WHERE published_at IS NULL;
+
+CREATE TABLE processed_events (
+    subscriber varchar(64) not null,
+    idempotency_key varchar(64) not null,
+    processed_at timestamp default now() not null,
+    PRIMARY KEY (subscriber, idempotency_key)
+);
+
+ALTER TABLE webhook_deliveries ADD COLUMN idempotency_key varchar(64);
+
+CREATE UNIQUE INDEX webhook_deliveries_idempotency_idx ON webhook_deliveries (webhook_id, idempotency_key);
@@ -0,0 +1,8 @@
+CREATE UNLOGGED TABLE rate_limits (
+    key varchar(255) not null primary key,
+    tokens double precision not null,
+    updated_at timestamptz not null,
+    full_at timestamptz not null
+);
+
+CREATE INDEX rate_limits_full_at_idx ON rate_limits (full_at);
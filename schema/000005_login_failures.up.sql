@@ -0,0 +1,6 @@
+CREATE TABLE login_failures (
+    key varchar(320) not null primary key,
+    failures int not null default 0,
+    last_failure_at timestamptz not null,
+    locked_until timestamptz
+);
@@ -0,0 +1,12 @@
+ALTER TABLE users ADD COLUMN totp_secret varchar(64);
+ALTER TABLE users ADD COLUMN totp_enabled boolean not null default false;
+ALTER TABLE users ADD COLUMN totp_last_step bigint not null default 0;
+
+CREATE TABLE recovery_codes (
+    id serial not null unique,
+    user_id int references users (id) on delete cascade not null,
+    code_hash varchar(255) not null,
+    used_at timestamp
+);
+
+CREATE INDEX recovery_codes_user_idx ON recovery_codes (user_id);
@@ -0,0 +1,13 @@
+CREATE TABLE api_keys (
+    id serial not null unique,
+    user_id int references users (id) on delete cascade not null,
+    name varchar(64) not null,
+    prefix varchar(16) not null,
+    key_hash varchar(64) not null unique,
+    scopes varchar(16)[] not null,
+    expires_at timestamptz,
+    last_used_at timestamptz,
+    created_at timestamptz not null default now()
+);
+
+CREATE INDEX api_keys_user_idx ON api_keys (user_id);
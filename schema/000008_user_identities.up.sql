@@ -0,0 +1,10 @@
+CREATE TABLE user_identities (
+    id serial not null unique,
+    user_id int references users (id) on delete cascade not null,
+    issuer varchar(255) not null,
+    subject varchar(255) not null,
+    created_at timestamptz not null default now(),
+    unique (issuer, subject)
+);
+
+CREATE INDEX user_identities_user_idx ON user_identities (user_id);
@@ -0,0 +1,33 @@
+CREATE TABLE oauth_clients (
+    id serial not null unique,
+    client_id varchar(64) not null unique,
+    secret_hash varchar(64),
+    name varchar(64) not null,
+    redirect_uris text[] not null,
+    scopes varchar(16)[] not null,
+    owner_id int references users (id) on delete cascade not null,
+    created_at timestamptz not null default now()
+);
+
+CREATE TABLE oauth_codes (
+    code_hash varchar(64) not null primary key,
+    client_id int references oauth_clients (id) on delete cascade not null,
+    user_id int references users (id) on delete cascade not null,
+    redirect_uri text not null,
+    scopes varchar(16)[] not null,
+    code_challenge varchar(128) not null,
+    expires_at timestamptz not null
+);
+
+CREATE TABLE oauth_tokens (
+    id serial not null unique,
+    token_hash varchar(64) not null unique,
+    client_id int references oauth_clients (id) on delete cascade not null,
+    user_id int references users (id) on delete cascade not null,
+    scopes varchar(16)[] not null,
+    expires_at timestamptz not null,
+    revoked_at timestamptz,
+    created_at timestamptz not null default now()
+);
+
+CREATE INDEX oauth_clients_owner_idx ON oauth_clients (owner_id);
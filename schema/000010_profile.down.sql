@@ -0,0 +1,4 @@
+ALTER TABLE users DROP COLUMN email_token_expires_at;
+ALTER TABLE users DROP COLUMN email_token_hash;
+ALTER TABLE users DROP COLUMN pending_email;
+ALTER TABLE users DROP COLUMN avatar_url;
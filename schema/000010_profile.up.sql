@@ -0,0 +1,4 @@
+ALTER TABLE users ADD COLUMN avatar_url text;
+ALTER TABLE users ADD COLUMN pending_email varchar(255);
+ALTER TABLE users ADD COLUMN email_token_hash varchar(64) unique;
+ALTER TABLE users ADD COLUMN email_token_expires_at timestamptz;
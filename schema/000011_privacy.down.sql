@@ -0,0 +1,3 @@
+DROP INDEX users_deletion_idx;
+DROP TABLE data_exports;
+ALTER TABLE users DROP COLUMN deletion_scheduled_at;
@@ -0,0 +1,16 @@
+ALTER TABLE users ADD COLUMN deletion_scheduled_at timestamptz;
+
+CREATE TABLE data_exports (
+    id serial not null unique,
+    user_id int references users (id) on delete cascade not null,
+    status varchar(16) not null default 'pending',
+    archive bytea,
+    error text not null default '',
+    claimed_until timestamptz,
+    created_at timestamptz not null default now(),
+    completed_at timestamptz,
+    expires_at timestamptz
+);
+
+CREATE INDEX data_exports_pending_idx ON data_exports (created_at) WHERE status = 'pending';
+CREATE INDEX users_deletion_idx ON users (deletion_scheduled_at) WHERE deletion_scheduled_at IS NOT NULL;
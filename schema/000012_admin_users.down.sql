@@ -0,0 +1,4 @@
+ALTER TABLE users DROP COLUMN password_reset_expires_at;
+ALTER TABLE users DROP COLUMN password_reset_hash;
+ALTER TABLE users DROP COLUMN sessions_revoked_at;
+ALTER TABLE users DROP COLUMN disabled_at;
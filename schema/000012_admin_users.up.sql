@@ -0,0 +1,4 @@
+ALTER TABLE users ADD COLUMN disabled_at timestamptz;
+ALTER TABLE users ADD COLUMN sessions_revoked_at timestamptz;
+ALTER TABLE users ADD COLUMN password_reset_hash varchar(64) unique;
+ALTER TABLE users ADD COLUMN password_reset_expires_at timestamptz;
@@ -0,0 +1,9 @@
+CREATE TABLE impersonations (
+    id serial not null unique,
+    -- the impersonation outlives the admin for the audit trail, the user's own rows go with the user
+    admin_id int references users (id) on delete set null,
+    user_id int references users (id) on delete cascade not null,
+    created_at timestamptz not null default now(),
+    expires_at timestamptz not null,
+    ended_at timestamptz
+);
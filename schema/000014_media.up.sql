@@ -0,0 +1,22 @@
+CREATE TABLE media (
+    id serial not null unique,
+    owner_id int references users (id) on delete set null,
+    storage_key varchar(255) not null,
+    thumbnail_key varchar(255) not null,
+    content_type varchar(100) not null,
+    size bigint not null,
+    width int not null,
+    height int not null,
+    created_at timestamptz not null default now()
+);
+
+CREATE TABLE article_media (
+    article_id int references articles (id) on delete cascade not null,
+    media_id int references media (id) on delete cascade not null,
+    caption text not null default '',
+    credit varchar(255) not null default '',
+    position int not null,
+    PRIMARY KEY (article_id, media_id)
+);
+
+CREATE INDEX article_media_media_id_idx ON article_media (media_id);
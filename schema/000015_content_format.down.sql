@@ -0,0 +1 @@
+ALTER TABLE articles DROP COLUMN content_html, DROP COLUMN content_format;
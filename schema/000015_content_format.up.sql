@@ -0,0 +1,14 @@
+ALTER TABLE articles
+    ADD COLUMN content_format varchar(20) not null default 'plain',
+    ADD COLUMN content_html text not null default '';
+
+-- existing articles are plain text, render them the way the service does: escaped, one paragraph per blank line
+UPDATE articles SET content_html = (
+    SELECT COALESCE(string_agg('<p>' || replace(btrim(p, E' \t\r\n'), E'\n', E'<br>\n') || E'</p>\n', '' ORDER BY n), '')
+    FROM regexp_split_to_table(
+        replace(replace(replace(replace(replace(
+            replace(btrim(content, E' \t\r\n'), E'\r\n', E'\n'),
+            '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&#34;'), '''', '&#39;'),
+        E'\n\n') WITH ORDINALITY AS paragraphs(p, n)
+    WHERE btrim(p, E' \t\r\n') <> ''
+);
@@ -0,0 +1 @@
+ALTER TABLE articles DROP COLUMN metadata_stale, DROP COLUMN reading_time, DROP COLUMN word_count, DROP COLUMN excerpt;
@@ -0,0 +1,8 @@
+ALTER TABLE articles
+    ADD COLUMN excerpt text not null default '',
+    ADD COLUMN word_count int not null default 0,
+    ADD COLUMN reading_time int not null default 0,
+    -- existing articles get their metadata from the service after the upgrade, see ArticlesService.BackfillMetadata
+    ADD COLUMN metadata_stale boolean not null default true;
+
+ALTER TABLE articles ALTER COLUMN metadata_stale SET DEFAULT false;
@@ -0,0 +1,5 @@
+-- set once the user has proven to own the email: by confirming it, by resetting the password
+-- through it or by signing in with an identity provider which verified it
+ALTER TABLE users ADD COLUMN email_verified boolean not null default false;
+
+UPDATE users SET email_verified = true WHERE id IN (SELECT user_id FROM user_identities);